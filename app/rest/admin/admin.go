@@ -0,0 +1,173 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
+	"github.com/x-color/calendar/auth/model"
+	as "github.com/x-color/calendar/auth/service"
+	cs "github.com/x-color/calendar/calendar/service"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// defaultLimit is the number of users returned when limit is not given.
+const defaultLimit = 20
+
+type UserContent struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Verified  bool   `json:"verified"`
+	Admin     bool   `json:"admin"`
+	Disabled  bool   `json:"disabled"`
+	Calendars *int   `json:"calendars,omitempty"`
+	Plans     *int   `json:"plans,omitempty"`
+}
+
+func userModelToContent(user model.User) UserContent {
+	return UserContent{
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Verified: user.Verified,
+		Admin:    user.Admin,
+		Disabled: user.Disabled,
+	}
+}
+
+type adminEndpoint struct {
+	authService as.Service
+	calService  cs.Service
+}
+
+func (e *adminEndpoint) SearchUsersHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	offset, limit := 0, defaultLimit
+	var err error
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	ul, err := e.authService.SearchUsers(r.Context(), q.Get("q"), offset, limit)
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	users := make([]UserContent, len(ul))
+	for i, u := range ul {
+		users[i] = userModelToContent(u)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(users)
+}
+
+func (e *adminEndpoint) GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	user, err := e.authService.GetUser(r.Context(), vars["id"])
+	if errors.Is(err, cerror.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	usage, err := e.calService.GetUsage(r.Context(), user.ID)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	res := userModelToContent(user)
+	res.Calendars = &usage.Calendars
+	res.Plans = &usage.Plans
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (e *adminEndpoint) disableHandler(disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		adminID := r.Context().Value(cctx.UserIDKey).(string)
+		err := e.authService.DisableUser(r.Context(), adminID, vars["id"], disabled)
+		if errors.Is(err, cerror.ErrInvalidContent) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		} else if errors.Is(err, cerror.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			response.WriteServerError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (e *adminEndpoint) SignoutUserHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	adminID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.authService.SignoutUser(r.Context(), adminID, vars["id"])
+	if errors.Is(err, cerror.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *adminEndpoint) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	adminID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.authService.ResetUserPassword(r.Context(), adminID, vars["id"])
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if errors.Is(err, cerror.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func NewRouter(r *mux.Router, authService as.Service, calService cs.Service) {
+	e := adminEndpoint{authService, calService}
+	r.Use(middlewares.ResponseHeaderMiddleware)
+	r.Use(middlewares.AuthorizationMiddleware(authService))
+	r.Use(middlewares.AdminMiddleware(authService))
+	r.HandleFunc("/users", e.SearchUsersHandler).Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}", e.GetUserHandler).Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}/disable", e.disableHandler(true)).Methods(http.MethodPost)
+	r.HandleFunc("/users/{id}/enable", e.disableHandler(false)).Methods(http.MethodPost)
+	r.HandleFunc("/users/{id}/signout", e.SignoutUserHandler).Methods(http.MethodPost)
+	r.HandleFunc("/users/{id}/password/reset", e.ResetPasswordHandler).Methods(http.MethodPost)
+}
@@ -0,0 +1,370 @@
+package admin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	. "github.com/x-color/calendar/app/rest/admin"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/testutils"
+	as "github.com/x-color/calendar/auth/service"
+	cs "github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/mail"
+)
+
+func makeUser(repo as.Repogitory, name string, admin bool) (string, *http.Cookie) {
+	userID := uuid.New().String()
+	sessionID := uuid.New().String()
+	repo.User().Create(context.Background(), as.UserData{
+		ID:    userID,
+		Name:  name,
+		Email: strings.ToLower(name) + "@example.com",
+		Admin: admin,
+	})
+	repo.Session().Create(context.Background(), as.SessionData{
+		ID:      sessionID,
+		UserID:  userID,
+		Expires: time.Now().Add(time.Hour).Unix(),
+	})
+	return userID, &http.Cookie{Name: "session_id", Value: sessionID}
+}
+
+func newRouter(authRepo as.Repogitory, calRepo cs.Repogitory, mailer mail.Mailer) *mux.Router {
+	l := testutils.NewLogger()
+	authService := as.NewService(authRepo, l)
+	authService.SetMailer(mailer, "http://example.com")
+	calendarService := cs.NewService(calRepo, l)
+	r := mux.NewRouter()
+	r.Use(middlewares.ReqIDMiddleware)
+	NewRouter(r.PathPrefix("/admin").Subrouter(), authService, calendarService)
+	return r
+}
+
+func TestNewRouter_Authorization(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	calRepo := testutils.NewCalRepo()
+	_, adminCookie := makeUser(authRepo, "Alice", true)
+	_, userCookie := makeUser(authRepo, "Bob", false)
+	mailer := mail.NewLogMailer(&bytes.Buffer{}, "calendar@example.com")
+	r := newRouter(authRepo, calRepo, &mailer)
+
+	testcases := []struct {
+		name   string
+		cookie *http.Cookie
+		code   int
+	}{
+		{
+			name:   "no cookie",
+			cookie: nil,
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "not admin",
+			cookie: userCookie,
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "admin",
+			cookie: adminCookie,
+			code:   http.StatusOK,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+}
+
+func TestNewRouter_SearchUsers(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	calRepo := testutils.NewCalRepo()
+	adminID, adminCookie := makeUser(authRepo, "Alice", true)
+	bobID, _ := makeUser(authRepo, "Bob", false)
+	bobbyID, _ := makeUser(authRepo, "Bobby", false)
+	mailer := mail.NewLogMailer(&bytes.Buffer{}, "calendar@example.com")
+	r := newRouter(authRepo, calRepo, &mailer)
+
+	testcases := []struct {
+		name  string
+		query string
+		code  int
+		ids   []string
+	}{
+		{
+			name:  "all users",
+			query: "",
+			code:  http.StatusOK,
+			ids:   []string{adminID, bobID, bobbyID},
+		},
+		{
+			name:  "search by name",
+			query: "?q=bob",
+			code:  http.StatusOK,
+			ids:   []string{bobID, bobbyID},
+		},
+		{
+			name:  "search by email",
+			query: "?q=bobby@",
+			code:  http.StatusOK,
+			ids:   []string{bobbyID},
+		},
+		{
+			name:  "paging",
+			query: "?offset=1&limit=1",
+			code:  http.StatusOK,
+			ids:   []string{bobID},
+		},
+		{
+			name:  "invalid limit",
+			query: "?limit=1000",
+			code:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/users"+tc.query, nil)
+			req.AddCookie(adminCookie)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+
+			var users []UserContent
+			if len(rec.Body.Bytes()) > 0 {
+				if err := json.Unmarshal(rec.Body.Bytes(), &users); err != nil {
+					t.Errorf("invalid response body: %v", rec.Body.String())
+				}
+			}
+			var ids []string
+			for _, u := range users {
+				ids = append(ids, u.ID)
+			}
+			if d := cmp.Diff(tc.ids, ids); d != "" {
+				t.Errorf("invalid users: \n%v", d)
+			}
+		})
+	}
+}
+
+func TestNewRouter_GetUser(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	calRepo := testutils.NewCalRepo()
+	_, adminCookie := makeUser(authRepo, "Alice", true)
+	bobID, _ := makeUser(authRepo, "Bob", false)
+	calRepo.User().Create(context.Background(), cs.UserData{ID: bobID})
+	calID := uuid.New().String()
+	calRepo.Calendar().Create(context.Background(), cs.CalendarData{
+		ID:     calID,
+		UserID: bobID,
+		Name:   "My plans",
+		Color:  "red",
+		Shares: []string{bobID},
+	})
+	calRepo.Plan().Create(context.Background(), cs.PlanData{
+		ID:         uuid.New().String(),
+		CalendarID: calID,
+		UserID:     bobID,
+		Name:       "plan",
+		Color:      "red",
+		Shares:     []string{calID},
+		Begin:      time.Now().Unix(),
+		End:        time.Now().Add(time.Hour).Unix(),
+	})
+	mailer := mail.NewLogMailer(&bytes.Buffer{}, "calendar@example.com")
+	r := newRouter(authRepo, calRepo, &mailer)
+
+	one := 1
+	testcases := []struct {
+		name string
+		id   string
+		code int
+		res  UserContent
+	}{
+		{
+			name: "user does not exist",
+			id:   uuid.New().String(),
+			code: http.StatusNotFound,
+		},
+		{
+			name: "get user",
+			id:   bobID,
+			code: http.StatusOK,
+			res: UserContent{
+				ID:        bobID,
+				Name:      "Bob",
+				Email:     "bob@example.com",
+				Calendars: &one,
+				Plans:     &one,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/users/"+tc.id, nil)
+			req.AddCookie(adminCookie)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+
+			var actual UserContent
+			if len(rec.Body.Bytes()) > 0 {
+				if err := json.Unmarshal(rec.Body.Bytes(), &actual); err != nil {
+					t.Errorf("invalid response body: %v", rec.Body.String())
+				}
+			}
+			if d := cmp.Diff(tc.res, actual); d != "" {
+				t.Errorf("invalid response body: \n%v", d)
+			}
+		})
+	}
+}
+
+func TestNewRouter_ManageUser(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	calRepo := testutils.NewCalRepo()
+	adminID, adminCookie := makeUser(authRepo, "Alice", true)
+	bobID, bobCookie := makeUser(authRepo, "Bob", true)
+	mails := bytes.Buffer{}
+	mailer := mail.NewLogMailer(&mails, "calendar@example.com")
+	r := newRouter(authRepo, calRepo, &mailer)
+
+	// bobCanUse tells whether Bob's session is still accepted.
+	bobCanUse := func() bool {
+		req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+		req.AddCookie(bobCookie)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code != http.StatusUnauthorized
+	}
+
+	testcases := []struct {
+		name   string
+		path   string
+		code   int
+		bobUse bool
+	}{
+		{
+			name:   "disable itself",
+			path:   "/admin/users/" + adminID + "/disable",
+			code:   http.StatusBadRequest,
+			bobUse: true,
+		},
+		{
+			name:   "disable user does not exist",
+			path:   "/admin/users/" + uuid.New().String() + "/disable",
+			code:   http.StatusNotFound,
+			bobUse: true,
+		},
+		{
+			name:   "force sign out",
+			path:   "/admin/users/" + bobID + "/signout",
+			code:   http.StatusNoContent,
+			bobUse: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.AddCookie(adminCookie)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+			if bobCanUse() != tc.bobUse {
+				t.Errorf("Bob's session: want usable %v", tc.bobUse)
+			}
+		})
+	}
+
+	t.Run("disable user", func(t *testing.T) {
+		_, bobCookie = makeSession(authRepo, bobID)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/users/"+bobID+"/disable", nil)
+		req.AddCookie(adminCookie)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		if bobCanUse() {
+			t.Errorf("disabled user can use session")
+		}
+
+		// Sessions made before disabling are rejected even if they remain.
+		_, bobCookie = makeSession(authRepo, bobID)
+		if bobCanUse() {
+			t.Errorf("disabled user can use session")
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/admin/users/"+bobID+"/enable", nil)
+		req.AddCookie(adminCookie)
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		if !bobCanUse() {
+			t.Errorf("enabled user can not use session")
+		}
+	})
+
+	t.Run("reset password", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/admin/users/"+bobID+"/password/reset", nil)
+		req.AddCookie(adminCookie)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		if bobCanUse() {
+			t.Errorf("user can use session after password reset")
+		}
+		if !strings.Contains(mails.String(), "To: bob@example.com") || !strings.Contains(mails.String(), "/reset-password?token=") {
+			t.Errorf("reset mail is not sent: %v", mails.String())
+		}
+		user, _ := authRepo.User().Find(context.Background(), bobID)
+		if user.Password != "" {
+			t.Errorf("password is not invalidated")
+		}
+	})
+}
+
+func makeSession(repo as.Repogitory, userID string) (string, *http.Cookie) {
+	sessionID := uuid.New().String()
+	repo.Session().Create(context.Background(), as.SessionData{
+		ID:      sessionID,
+		UserID:  userID,
+		Expires: time.Now().Add(time.Hour).Unix(),
+	})
+	return sessionID, &http.Cookie{Name: "session_id", Value: sessionID}
+}
@@ -3,25 +3,60 @@ package auth
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"net"
 	"net/http"
-	"os"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
+	"github.com/x-color/calendar/auth/model"
 	"github.com/x-color/calendar/auth/service"
 	cerror "github.com/x-color/calendar/model/error"
 )
 
-var secure = len(os.Getenv("SSL_DISABLE")) == 0
+// Options are settings of the endpoints depending on how the server is deployed.
+type Options struct {
+	// Secure sends cookies only over HTTPS. It must be disabled only without TLS.
+	Secure bool
+	// TrustProxy enables to use X-Forwarded-For header as client IP.
+	// It must be enabled only behind a reverse proxy appending the header.
+	TrustProxy bool
+}
+
+func (e *authEndpoint) clientIP(r *http.Request) string {
+	if e.opts.TrustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			l := strings.Split(xff, ",")
+			return strings.TrimSpace(l[len(l)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
 
 type userContent struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 	Password string `json:"password"`
+	Email    string `json:"email"`
+}
+
+type resetContent struct {
+	Email    string `json:"email"`
+	Token    string `json:"token"`
+	Password string `json:"password"`
 }
 
 type authEndpoint struct {
 	service service.Service
+	csrf    middlewares.CSRF
+	opts    Options
 }
 
 func (e *authEndpoint) SignupHandler(w http.ResponseWriter, r *http.Request) {
@@ -31,7 +66,7 @@ func (e *authEndpoint) SignupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := e.service.Signup(r.Context(), req.Name, req.Password)
+	_, err := e.service.Signup(r.Context(), req.Name, req.Password, req.Email)
 	if errors.Is(err, cerror.ErrInvalidContent) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
@@ -39,7 +74,7 @@ func (e *authEndpoint) SignupHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusConflict)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
@@ -53,32 +88,205 @@ func (e *authEndpoint) SigninHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, err := e.service.Signin(r.Context(), req.Name, req.Password)
+	session, err := e.service.Signin(r.Context(), req.Name, req.Password, e.clientIP(r))
 	if errors.Is(err, cerror.ErrInvalidContent) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
+	} else if errors.Is(err, cerror.ErrTooManyAttempts) {
+		d, _ := cerror.RetryAfter(err)
+		w.Header().Set("Retry-After", fmt.Sprint(int64(math.Ceil(d.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
 	} else if errors.Is(err, cerror.ErrAuthorization) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
+	e.setSessionCookie(w, session)
+
+	json.NewEncoder(w).Encode(userContent{
+		ID: session.UserID,
+	})
+}
+
+func (e *authEndpoint) RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	req := resetContent{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// It responds same status whether the email is registered or not.
+	// Otherwise, the response tells registered emails to anyone.
+	err := e.service.RequestPasswordReset(r.Context(), req.Email)
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if err != nil && !errors.Is(err, cerror.ErrNotFound) {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *authEndpoint) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	req := resetContent{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := e.service.ResetPassword(r.Context(), req.Token, req.Password)
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if errors.Is(err, cerror.ErrAuthorization) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *authEndpoint) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	err := e.service.VerifyEmail(r.Context(), r.URL.Query().Get("token"))
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if errors.Is(err, cerror.ErrAuthorization) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	// The link is opened from the mail. So it redirects to the application.
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func (e *authEndpoint) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	req := userContent{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// It responds same status whether the email is registered or not
+	// as RequestPasswordResetHandler does.
+	err := e.service.ResendVerification(r.Context(), req.Email)
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if err != nil && !errors.Is(err, cerror.ErrNotFound) {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setSessionCookie sets the session and CSRF token bound to it into cookies.
+func (e *authEndpoint) setSessionCookie(w http.ResponseWriter, session model.Session) {
 	cookie := &http.Cookie{
 		Name:     "session_id",
 		Value:    session.ID,
 		Expires:  session.Expires,
 		Path:     "/",
-		Secure:   secure,
+		Secure:   e.opts.Secure,
 		HttpOnly: true,
 		SameSite: http.SameSiteStrictMode,
 	}
 	http.SetCookie(w, cookie)
+	e.csrf.SetCookie(w, session.ID, session.Expires)
+}
+
+func (e *authEndpoint) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	u, flow, err := e.service.OIDCLogin(r.Context())
+	if errors.Is(err, cerror.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	// The callback is a cross-site navigation from the provider.
+	// So the cookie must be Lax to be sent with it.
+	cookie := &http.Cookie{
+		Name:     "oidc_flow",
+		Value:    strings.Join([]string{flow.State, flow.Nonce, flow.Verifier}, "."),
+		MaxAge:   600,
+		Path:     "/",
+		Secure:   e.opts.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(w, cookie)
 
-	json.NewEncoder(w).Encode(userContent{
-		ID: session.UserID,
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+func (e *authEndpoint) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie("oidc_flow")
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     "oidc_flow",
+		MaxAge:   -1,
+		Path:     "/",
+		Secure:   e.opts.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
 	})
+
+	l := strings.Split(cookie.Value, ".")
+	if len(l) != 3 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	flow := service.OIDCFlow{
+		State:    l[0],
+		Nonce:    l[1],
+		Verifier: l[2],
+	}
+
+	q := r.URL.Query()
+	if q.Get("error") != "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	session, err := e.service.OIDCCallback(r.Context(), flow, q.Get("state"), q.Get("code"))
+	if errors.Is(err, cerror.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if errors.Is(err, cerror.ErrAuthorization) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	} else if errors.Is(err, cerror.ErrDuplication) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	e.setSessionCookie(w, session)
+
+	http.Redirect(w, r, "/", http.StatusFound)
 }
 
 func (e *authEndpoint) SignoutHandler(w http.ResponseWriter, r *http.Request) {
@@ -93,18 +301,24 @@ func (e *authEndpoint) SignoutHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func NewRouter(r *mux.Router, s service.Service) {
-	e := authEndpoint{s}
+func NewRouter(r *mux.Router, s service.Service, csrf middlewares.CSRF, opts Options) {
+	e := authEndpoint{s, csrf, opts}
 	r.Use(middlewares.ReqIDMiddleware)
 	r.Use(middlewares.ResponseHeaderMiddleware)
 	r.HandleFunc("/signup", e.SignupHandler).Methods(http.MethodPost)
 	r.HandleFunc("/signin", e.SigninHandler).Methods(http.MethodPost)
 	r.HandleFunc("/signout", e.SignoutHandler).Methods(http.MethodPost)
+	r.HandleFunc("/password/reset-request", e.RequestPasswordResetHandler).Methods(http.MethodPost)
+	r.HandleFunc("/password/reset", e.ResetPasswordHandler).Methods(http.MethodPost)
+	r.HandleFunc("/verify", e.VerifyEmailHandler).Methods(http.MethodGet)
+	r.HandleFunc("/verify/resend", e.ResendVerificationHandler).Methods(http.MethodPost)
+	r.HandleFunc("/oidc/login", e.OIDCLoginHandler).Methods(http.MethodGet)
+	r.HandleFunc("/oidc/callback", e.OIDCCallbackHandler).Methods(http.MethodGet)
 }
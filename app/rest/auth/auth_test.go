@@ -3,16 +3,38 @@ package auth_test
 import (
 	"bytes"
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	. "github.com/x-color/calendar/app/rest/auth"
+	"github.com/x-color/calendar/app/rest/middlewares"
 	"github.com/x-color/calendar/app/rest/testutils"
+	"github.com/x-color/calendar/auth/oidc"
+	"github.com/x-color/calendar/auth/pwned"
+	"github.com/x-color/calendar/auth/repogitory/cached"
 	as "github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/auth/sessiontoken"
+	"github.com/x-color/calendar/cache"
+	cs "github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/clock"
+	"github.com/x-color/calendar/logging"
+	"github.com/x-color/calendar/mail"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -21,7 +43,7 @@ func TestNewRouter_Signup(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(repo, l)
 	r := mux.NewRouter()
-	NewRouter(r.PathPrefix("/auth").Subrouter(), authService)
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
 
 	testcases := []struct {
 		name string
@@ -30,22 +52,32 @@ func TestNewRouter_Signup(t *testing.T) {
 	}{
 		{
 			name: "invalid password",
-			body: map[string]string{"name": "Alice", "password": "Password"},
+			body: map[string]string{"name": "Alice", "password": "Password", "email": "alice@example.com"},
 			code: http.StatusBadRequest,
 		},
 		{
 			name: "invalid name",
-			body: map[string]string{"name": "", "password": "P@ssw0rd"},
+			body: map[string]string{"name": "", "password": "P@ssw0rd", "email": "alice@example.com"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid email",
+			body: map[string]string{"name": "Alice", "password": "P@ssw0rd", "email": "alice"},
 			code: http.StatusBadRequest,
 		},
 		{
 			name: "signup new user",
-			body: map[string]string{"name": "Alice", "password": "P@ssw0rd"},
+			body: map[string]string{"name": "Alice", "password": "P@ssw0rd", "email": "alice@example.com"},
 			code: http.StatusNoContent,
 		},
 		{
 			name: "user already exist",
-			body: map[string]string{"name": "Alice", "password": "P@ssw0rd"},
+			body: map[string]string{"name": "Alice", "password": "P@ssw0rd", "email": "alice2@example.com"},
+			code: http.StatusConflict,
+		},
+		{
+			name: "email already used",
+			body: map[string]string{"name": "Bob", "password": "P@ssw0rd", "email": "alice@example.com"},
 			code: http.StatusConflict,
 		},
 	}
@@ -65,6 +97,78 @@ func TestNewRouter_Signup(t *testing.T) {
 	}
 }
 
+func TestNewRouter_SignupPasswordPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pwned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	h := sha1.Sum([]byte("Br3ached!"))
+	hash := strings.ToUpper(hex.EncodeToString(h[:]))
+	content := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + hash[5:] + ":42\r\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := testutils.NewAuthRepo()
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	authService.SetBreachedPasswords(pwned.NewRangeDir(dir))
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	testcases := []struct {
+		name string
+		body map[string]string
+		code int
+	}{
+		{
+			name: "too short password",
+			body: map[string]string{"name": "Alice", "password": "P@ssw0r", "email": "alice@example.com"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "password without symbol",
+			body: map[string]string{"name": "Alice", "password": "Passw0rd", "email": "alice@example.com"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "password with repeated characters",
+			body: map[string]string{"name": "Alice", "password": "P@ssssw0rd", "email": "alice@example.com"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "password containing name",
+			body: map[string]string{"name": "Alice", "password": "ALICE@w0rd", "email": "alice@example.com"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "breached password",
+			body: map[string]string{"name": "Alice", "password": "Br3ached!", "email": "alice@example.com"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "valid password",
+			body: map[string]string{"name": "Alice", "password": "P@ssw0rd", "email": "alice@example.com"},
+			code: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.body)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/signup", bytes.NewBuffer(body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+}
+
 func TestNewRouter_Signin(t *testing.T) {
 	repo := testutils.NewAuthRepo()
 	userID := uuid.New().String()
@@ -78,7 +182,7 @@ func TestNewRouter_Signin(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(repo, l)
 	r := mux.NewRouter()
-	NewRouter(r.PathPrefix("/auth").Subrouter(), authService)
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
 
 	testcases := []struct {
 		name    string
@@ -100,7 +204,7 @@ func TestNewRouter_Signin(t *testing.T) {
 			name:    "signin",
 			body:    map[string]string{"name": "Alice", "password": "P@ssw0rd"},
 			code:    http.StatusOK,
-			cookies: 1,
+			cookies: 2,
 		},
 	}
 
@@ -140,7 +244,7 @@ func TestNewRouter_Signout(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(repo, l)
 	r := mux.NewRouter()
-	NewRouter(r.PathPrefix("/auth").Subrouter(), authService)
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
 
 	testcases := []struct {
 		name   string
@@ -193,3 +297,1036 @@ func TestNewRouter_Signout(t *testing.T) {
 		})
 	}
 }
+
+// cachedSessions reads sessions of the repogitory through the cache.
+type cachedSessions struct {
+	as.Repogitory
+	sessions as.SessionRepogitory
+}
+
+func (r cachedSessions) Session() as.SessionRepogitory {
+	return r.sessions
+}
+
+func TestNewRouter_SignoutCached(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	_, sessionID := testutils.MakeSession(repo)
+	c := cache.NewLRU(100, time.Minute)
+	sessions := cached.NewSessionRepogitory(repo.Session(), &c)
+
+	l := testutils.NewLogger()
+	authService := as.NewService(cachedSessions{repo, &sessions}, l)
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	ctx := context.WithValue(context.Background(), cctx.ReqIDKey, uuid.New().String())
+	if _, err := authService.Authorize(ctx, sessionID); err != nil {
+		t.Fatalf("want no error, but got %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/signout", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "session_id",
+		Value: sessionID,
+	})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+	}
+
+	// The cached session must be invalidated by the signout.
+	if _, err := authService.Authorize(ctx, sessionID); err == nil {
+		t.Errorf("want error, but got nil")
+	}
+}
+
+func TestNewRouter_SessionTokens(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	userID := uuid.New().String()
+	pwd, _ := bcrypt.GenerateFromPassword([]byte("P@ssw0rd"), bcrypt.MinCost)
+	repo.User().Create(context.Background(), as.UserData{
+		ID:       userID,
+		Name:     "Alice",
+		Password: string(pwd),
+	})
+	sealer, err := sessiontoken.NewSealer([]sessiontoken.Key{{ID: "1", Secret: make([]byte, 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	authService.SetSessionTokens(sealer)
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	signin := func() string {
+		body, _ := json.Marshal(map[string]string{"name": "Alice", "password": "P@ssw0rd"})
+		req := httptest.NewRequest(http.MethodPost, "/auth/signin", bytes.NewBuffer(body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+		}
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == "session_id" {
+				return c.Value
+			}
+		}
+		t.Fatalf("session cookie is not set")
+		return ""
+	}
+	signout := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/signout", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  "session_id",
+			Value: token,
+		})
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	ctx := context.WithValue(context.Background(), cctx.ReqIDKey, uuid.New().String())
+
+	t.Run("signout", func(t *testing.T) {
+		token := signin()
+		if _, err := repo.Session().Find(ctx, token); err == nil {
+			t.Errorf("session token is kept in the session store")
+		}
+		if id, err := authService.Authorize(ctx, token); err != nil || id != userID {
+			t.Fatalf("want %v, but got %v(%v)", userID, id, err)
+		}
+
+		if code := signout(token); code != http.StatusNoContent {
+			t.Errorf("status code: want %v but %v", http.StatusNoContent, code)
+		}
+		if _, err := authService.Authorize(ctx, token); err == nil {
+			t.Errorf("revoked token is authorized")
+		}
+		if code := signout(token); code != http.StatusUnauthorized {
+			t.Errorf("status code: want %v but %v", http.StatusUnauthorized, code)
+		}
+	})
+
+	t.Run("signout user", func(t *testing.T) {
+		token := signin()
+		if err := authService.SignoutUser(ctx, uuid.New().String(), userID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := authService.Authorize(ctx, token); err == nil {
+			t.Errorf("token of signed out user is authorized")
+		}
+	})
+
+	t.Run("forged token", func(t *testing.T) {
+		// The key has the same ID but the other secret.
+		other, _ := sessiontoken.NewSealer([]sessiontoken.Key{{ID: "1", Secret: []byte(strings.Repeat("k", 32))}})
+		token, _ := other.Seal(as.SessionClaims{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			IssuedAt: time.Now().Add(time.Minute).Unix(),
+			Expires:  time.Now().Add(time.Hour).Unix(),
+		})
+		if _, err := authService.Authorize(ctx, token); err == nil {
+			t.Errorf("forged token is authorized")
+		}
+	})
+}
+
+func TestService_SessionExpiry(t *testing.T) {
+	sealer, _ := sessiontoken.NewSealer([]sessiontoken.Key{{ID: "1", Secret: make([]byte, 32)}})
+	testcases := []struct {
+		name   string
+		tokens as.SessionTokens
+	}{
+		{
+			name: "session store",
+		},
+		{
+			name:   "session tokens",
+			tokens: sealer,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := testutils.NewAuthRepo()
+			userID := uuid.New().String()
+			pwd, _ := bcrypt.GenerateFromPassword([]byte("P@ssw0rd"), bcrypt.MinCost)
+			repo.User().Create(context.Background(), as.UserData{
+				ID:       userID,
+				Name:     "Alice",
+				Password: string(pwd),
+			})
+
+			now := clock.NewFake(time.Now())
+			authService := as.NewService(repo, testutils.NewLogger())
+			authService.SetClock(now)
+			authService.SetSessionLifetime(time.Hour)
+			if tc.tokens != nil {
+				authService.SetSessionTokens(tc.tokens)
+			}
+
+			ctx := context.WithValue(context.Background(), cctx.ReqIDKey, uuid.New().String())
+			session, err := authService.Signin(ctx, "Alice", "P@ssw0rd", "192.0.2.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !session.Expires.Equal(now.Now().Add(time.Hour)) {
+				t.Errorf("session expires at %v", session.Expires)
+			}
+
+			now.Advance(59 * time.Minute)
+			if _, err := authService.Authorize(ctx, session.ID); err != nil {
+				t.Errorf("want no error, but got %v", err)
+			}
+			now.Advance(2 * time.Minute)
+			if _, err := authService.Authorize(ctx, session.ID); err == nil {
+				t.Errorf("expired session is authorized")
+			}
+		})
+	}
+}
+
+// cachedUsers reads users of the repogitory through the cache.
+type cachedUsers struct {
+	as.Repogitory
+	users as.UserRepogitory
+}
+
+func (r cachedUsers) User() as.UserRepogitory {
+	return r.users
+}
+
+// countingUsers counts users found by ID.
+type countingUsers struct {
+	as.UserRepogitory
+	count *int
+}
+
+func (r countingUsers) Find(ctx context.Context, id string) (as.UserData, error) {
+	*r.count++
+	return r.UserRepogitory.Find(ctx, id)
+}
+
+func TestService_AuthorizeCached(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	userID, sessionID := testutils.MakeSession(repo)
+	c := cache.NewLRU(100, time.Minute)
+	found := 0
+	users := cached.NewUserRepogitory(countingUsers{repo.User(), &found}, &c)
+	authService := as.NewService(cachedUsers{repo, &users}, testutils.NewLogger())
+
+	ctx := context.WithValue(context.Background(), cctx.ReqIDKey, uuid.New().String())
+	for i := 0; i < 3; i++ {
+		if _, err := authService.Authorize(ctx, sessionID); err != nil {
+			t.Fatalf("want no error, but got %v", err)
+		}
+	}
+	if found != 1 {
+		t.Errorf("user is found on each authorization: %v times", found)
+	}
+
+	// The session is kept, so that only the cached user can reject it.
+	user, err := users.Find(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Disabled = true
+	if err := users.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.Authorize(ctx, sessionID); err == nil {
+		t.Errorf("want error, but got nil")
+	}
+}
+
+// cachedRevocations keeps revocations of the repogitory in memory.
+type cachedRevocations struct {
+	as.Repogitory
+	revocations as.RevocationRepogitory
+}
+
+func (r cachedRevocations) Revocation() as.RevocationRepogitory {
+	return r.revocations
+}
+
+// countingRevocations counts queries of revocations.
+type countingRevocations struct {
+	as.RevocationRepogitory
+	count *int
+}
+
+func (r countingRevocations) Find(ctx context.Context, ids []string) ([]as.RevocationData, error) {
+	*r.count++
+	return r.RevocationRepogitory.Find(ctx, ids)
+}
+
+func (r countingRevocations) FindSince(ctx context.Context, revokedAt int64) ([]as.RevocationData, error) {
+	*r.count++
+	return r.RevocationRepogitory.FindSince(ctx, revokedAt)
+}
+
+func TestService_RevocationList(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	userID := uuid.New().String()
+	pwd, _ := bcrypt.GenerateFromPassword([]byte("P@ssw0rd"), bcrypt.MinCost)
+	repo.User().Create(context.Background(), as.UserData{
+		ID:       userID,
+		Name:     "Alice",
+		Password: string(pwd),
+	})
+	sealer, _ := sessiontoken.NewSealer([]sessiontoken.Key{{ID: "1", Secret: make([]byte, 32)}})
+
+	now := clock.NewFake(time.Now())
+	queried := 0
+	revocations := cached.NewRevocationRepogitory(countingRevocations{repo.Revocation(), &queried}, time.Minute, now)
+	authService := as.NewService(cachedRevocations{repo, &revocations}, testutils.NewLogger())
+	authService.SetClock(now)
+	authService.SetSessionTokens(sealer)
+
+	ctx := context.WithValue(context.Background(), cctx.ReqIDKey, uuid.New().String())
+	signin := func() string {
+		// Tokens issued in the same second as revocations are revoked.
+		now.Advance(time.Second)
+		session, err := authService.Signin(ctx, "Alice", "P@ssw0rd", "192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return session.ID
+	}
+
+	token := signin()
+	for i := 0; i < 3; i++ {
+		if _, err := authService.Authorize(ctx, token); err != nil {
+			t.Fatalf("want no error, but got %v", err)
+		}
+	}
+	if queried != 1 {
+		t.Errorf("revocations are queried on each authorization: %v times", queried)
+	}
+
+	// Revocations saved by other servers are loaded after the interval.
+	err := repo.Revocation().Save(ctx, as.RevocationData{
+		ID:        "user:" + userID,
+		RevokedAt: now.Now().Unix(),
+		Expires:   now.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.Authorize(ctx, token); err != nil {
+		t.Errorf("want no error before the list is loaded, but got %v", err)
+	}
+	now.Advance(time.Minute)
+	if _, err := authService.Authorize(ctx, token); err == nil {
+		t.Errorf("token revoked by other server is authorized")
+	}
+
+	// Tokens of disabled users are rejected at once without looking up the users.
+	token = signin()
+	if _, err := authService.Authorize(ctx, token); err != nil {
+		t.Fatalf("want no error, but got %v", err)
+	}
+	if err := authService.DisableUser(ctx, uuid.New().String(), userID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.Authorize(ctx, token); err == nil {
+		t.Errorf("token of disabled user is authorized")
+	}
+}
+
+func TestNewRouter_CSRF(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	pwd, _ := bcrypt.GenerateFromPassword([]byte("P@ssw0rd"), bcrypt.DefaultCost)
+	repo.User().Create(context.Background(), as.UserData{
+		ID:       uuid.New().String(),
+		Name:     "Alice",
+		Password: string(pwd),
+	})
+
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	csrf := testutils.NewCSRF()
+	r := mux.NewRouter()
+	r.Use(csrf.Middleware)
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, csrf, Options{})
+
+	// Sign in does not require the token because it has no session yet.
+	body, _ := json.Marshal(map[string]string{"name": "Alice", "password": "P@ssw0rd"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/signin", bytes.NewBuffer(body))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("signin status code: want %v but %v", http.StatusOK, rec.Code)
+	}
+
+	var session, token *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		switch c.Name {
+		case "session_id":
+			session = c
+		case middlewares.CSRFCookieName:
+			token = c
+		}
+	}
+	if session == nil || token == nil {
+		t.Fatalf("cookies: %v", rec.Result().Cookies())
+	}
+	if token.HttpOnly {
+		t.Errorf("CSRF cookie must be readable by scripts")
+	}
+
+	testcases := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{
+			name:  "no token",
+			token: "",
+			code:  http.StatusForbidden,
+		},
+		{
+			name:  "token for other session",
+			token: csrf.Token(uuid.New().String()),
+			code:  http.StatusForbidden,
+		},
+		{
+			name:  "valid token",
+			token: token.Value,
+			code:  http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/auth/signout", nil)
+			req.AddCookie(session)
+			if tc.token != "" {
+				req.Header.Set(middlewares.CSRFHeaderName, tc.token)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+}
+
+func TestNewRouter_CSRFReissue(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	_, sessionID := testutils.MakeSession(repo)
+
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	csrf := testutils.NewCSRF()
+	r := mux.NewRouter()
+	r.Use(csrf.Middleware)
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, csrf, Options{})
+
+	// The token was made with the old key, such as before the key is changed.
+	stale := middlewares.NewCSRF([]byte("old-key"), false).Token(sessionID)
+	req := httptest.NewRequest(http.MethodPost, "/auth/signout", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	req.AddCookie(&http.Cookie{Name: middlewares.CSRFCookieName, Value: stale})
+	req.Header.Set(middlewares.CSRFHeaderName, stale)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status code with stale token: want %v but %v", http.StatusForbidden, rec.Code)
+	}
+
+	var token *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == middlewares.CSRFCookieName {
+			token = c
+		}
+	}
+	if token == nil || token.Value != csrf.Token(sessionID) {
+		t.Fatalf("reissued cookie: want %v but %v", csrf.Token(sessionID), token)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/signout", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	req.AddCookie(token)
+	req.Header.Set(middlewares.CSRFHeaderName, token.Value)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status code with reissued token: want %v but %v", http.StatusNoContent, rec.Code)
+	}
+}
+
+func TestNewRouter_SigninLockout(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	pwd, _ := bcrypt.GenerateFromPassword([]byte("P@ssw0rd"), bcrypt.DefaultCost)
+	repo.User().Create(context.Background(), as.UserData{
+		ID:       uuid.New().String(),
+		Name:     "Alice",
+		Password: string(pwd),
+	})
+
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	wrong := map[string]string{"name": "Alice", "password": "p@SSW0RD"}
+	right := map[string]string{"name": "Alice", "password": "P@ssw0rd"}
+
+	testcases := []struct {
+		name       string
+		body       map[string]string
+		code       int
+		retryAfter bool
+	}{
+		{name: "1st failure", body: wrong, code: http.StatusUnauthorized},
+		{name: "2nd failure", body: wrong, code: http.StatusUnauthorized},
+		{name: "3rd failure", body: wrong, code: http.StatusUnauthorized},
+		{name: "4th failure", body: wrong, code: http.StatusUnauthorized},
+		{name: "5th failure", body: wrong, code: http.StatusUnauthorized},
+		{name: "locked out", body: right, code: http.StatusTooManyRequests, retryAfter: true},
+		{
+			name: "other user is not locked out",
+			body: map[string]string{"name": "Bob", "password": "P@ssw0rd"},
+			code: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.body)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/signin", bytes.NewBuffer(body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+
+			if h := rec.Header().Get("Retry-After"); (h != "") != tc.retryAfter {
+				t.Errorf("Retry-After: %q", h)
+			}
+		})
+	}
+}
+
+func TestNewRouter_SigninLockoutConcurrent(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	pwd, _ := bcrypt.GenerateFromPassword([]byte("P@ssw0rd"), bcrypt.DefaultCost)
+	repo.User().Create(context.Background(), as.UserData{
+		ID:       uuid.New().String(),
+		Name:     "Alice",
+		Password: string(pwd),
+	})
+
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	signin := func(password string) int {
+		body, _ := json.Marshal(map[string]string{"name": "Alice", "password": password})
+		req := httptest.NewRequest(http.MethodPost, "/auth/signin", bytes.NewBuffer(body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Failures guessed at the same time are all counted.
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			signin("p@SSW0RD")
+		}()
+	}
+	wg.Wait()
+
+	if code := signin("P@ssw0rd"); code != http.StatusTooManyRequests {
+		t.Errorf("status code: want %v but %v", http.StatusTooManyRequests, code)
+	}
+}
+
+func TestNewRouter_SigninRehash(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	pwd, _ := bcrypt.GenerateFromPassword([]byte("P@ssw0rd"), bcrypt.MinCost)
+	user := as.UserData{
+		ID:       uuid.New().String(),
+		Name:     "Alice",
+		Password: string(pwd),
+	}
+	repo.User().Create(context.Background(), user)
+
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	hasher := as.NewArgon2idHasher(as.DefaultArgon2idParams)
+
+	testcases := []struct {
+		name string
+		body map[string]string
+		code int
+	}{
+		{
+			name: "sign in with bcrypt hash",
+			body: map[string]string{"name": "Alice", "password": "P@ssw0rd"},
+			code: http.StatusOK,
+		},
+		{
+			name: "sign in with argon2id hash",
+			body: map[string]string{"name": "Alice", "password": "P@ssw0rd"},
+			code: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.body)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/signin", bytes.NewBuffer(body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+
+			u, err := repo.User().Find(context.Background(), user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !hasher.Identifies(u.Password) || hasher.NeedsRehash(u.Password) {
+				t.Errorf("password is not rehashed: %v", u.Password)
+			}
+			if err := hasher.Verify(u.Password, "P@ssw0rd"); err != nil {
+				t.Errorf("rehashed password does not match: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewRouter_OIDC(t *testing.T) {
+	provider := testutils.NewOIDCProvider("calendar")
+	defer provider.Close()
+
+	repo := testutils.NewAuthRepo()
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	idp, err := oidc.NewProvider(
+		context.Background(),
+		provider.Server.URL,
+		provider.ClientID,
+		"secret",
+		"http://example.com/auth/oidc/callback",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := clock.NewFake(time.Now())
+	idp.SetClock(now)
+	authService.SetIdentityProvider(idp)
+	calRepo := testutils.NewCalRepo()
+	calService := cs.NewService(calRepo, l)
+	authService.SetUserRegistrar(&calService)
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	login := func() (*http.Cookie, string) {
+		return oidcLogin(t, r)
+	}
+
+	testcases := []struct {
+		name    string
+		cookie  bool
+		state   string
+		code    int
+		session bool
+	}{
+		{
+			name:   "no cookie",
+			cookie: false,
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "state does not match",
+			cookie: true,
+			state:  "invalid",
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:    "first signin",
+			cookie:  true,
+			code:    http.StatusFound,
+			session: true,
+		},
+		{
+			name:    "second signin",
+			cookie:  true,
+			code:    http.StatusFound,
+			session: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cookie, callback := login()
+			if tc.state != "" {
+				u, _ := url.Parse(callback)
+				q := u.Query()
+				q.Set("state", tc.state)
+				u.RawQuery = q.Encode()
+				callback = u.RequestURI()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, callback, nil)
+			if tc.cookie {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+
+			session := false
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == "session_id" && c.Value != "" {
+					session = true
+				}
+			}
+			if session != tc.session {
+				t.Errorf("session cookie: want %v but %v", tc.session, rec.Result().Cookies())
+			}
+		})
+	}
+
+	user, err := repo.User().FindByName(context.Background(), provider.Name)
+	if err != nil {
+		t.Fatalf("user is not created: %v", err)
+	}
+	identity, err := repo.Identity().Find(context.Background(), provider.Server.URL, provider.Subject)
+	if err != nil || identity.UserID != user.ID {
+		t.Errorf("identity is not linked to user(%v): %v", user.ID, identity)
+	}
+
+	// The user is registered with a calendar only at the first signin.
+	if _, err := calRepo.User().Find(context.Background(), user.ID); err != nil {
+		t.Errorf("user is not registered: %v", err)
+	}
+	if n, _ := calRepo.Calendar().CountByUserID(context.Background(), user.ID); n != 1 {
+		t.Errorf("number of calendars: want 1 but %v", n)
+	}
+
+	signin := func() int {
+		cookie, callback := login()
+		req := httptest.NewRequest(http.MethodGet, callback, nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Keys are not fetched again for every unknown kid, but fetched after the interval.
+	provider.KeyID = "unknown"
+	for i := 0; i < 2; i++ {
+		if signin() == http.StatusFound {
+			t.Errorf("token with unknown kid is accepted")
+		}
+	}
+	if n := provider.JWKSRequests(); n != 1 {
+		t.Errorf("requests of keys: want 1 but %v", n)
+	}
+	now.Advance(time.Minute)
+	if signin() == http.StatusFound {
+		t.Errorf("token with unknown kid is accepted")
+	}
+	if n := provider.JWKSRequests(); n != 2 {
+		t.Errorf("requests of keys after the interval: want 2 but %v", n)
+	}
+
+	// ID tokens expire an hour after they are issued.
+	provider.KeyID = "test"
+	now.Advance(2 * time.Hour)
+	if signin() == http.StatusFound {
+		t.Errorf("expired id token is accepted")
+	}
+}
+
+// oidcLogin starts the flow and returns cookie and URL of the callback the provider redirects to.
+func oidcLogin(t *testing.T, r http.Handler) (*http.Cookie, string) {
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login status code: want %v but %v", http.StatusFound, rec.Code)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := client.Get(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	u, err := url.Parse(res.Header.Get("Location"))
+	if err != nil || u.Query().Get("code") == "" {
+		t.Fatalf("invalid redirect from provider: %v", res.Header.Get("Location"))
+	}
+
+	return rec.Result().Cookies()[0], u.RequestURI()
+}
+
+// failingIdentities fails to create identities.
+type failingIdentities struct {
+	as.Repogitory
+}
+
+func (r failingIdentities) Identity() as.IdentityRepogitory {
+	return failingIdentityRepo{r.Repogitory.Identity()}
+}
+
+func (r failingIdentities) Transaction(ctx context.Context, f func(as.Repogitory) error) error {
+	return r.Repogitory.Transaction(ctx, func(repo as.Repogitory) error {
+		return f(failingIdentities{repo})
+	})
+}
+
+type failingIdentityRepo struct {
+	as.IdentityRepogitory
+}
+
+func (r failingIdentityRepo) Create(ctx context.Context, identity as.IdentityData) error {
+	return cerror.NewInternalError(nil, "failed to create identity")
+}
+
+func TestNewRouter_OIDCRollback(t *testing.T) {
+	provider := testutils.NewOIDCProvider("calendar")
+	defer provider.Close()
+
+	repo := testutils.NewAuthRepo()
+	authService := as.NewService(failingIdentities{repo}, testutils.NewLogger())
+	idp, err := oidc.NewProvider(
+		context.Background(),
+		provider.Server.URL,
+		provider.ClientID,
+		"secret",
+		"http://example.com/auth/oidc/callback",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authService.SetIdentityProvider(idp)
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	cookie, callback := oidcLogin(t, r)
+	req := httptest.NewRequest(http.MethodGet, callback, nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code == http.StatusFound {
+		t.Fatalf("user is signed in without identity")
+	}
+
+	// The user is not left without a way to sign in.
+	if _, err := repo.User().FindByName(context.Background(), provider.Name); err == nil {
+		t.Errorf("user is created without identity")
+	}
+}
+
+func TestNewRouter_PasswordReset(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	pwd, _ := bcrypt.GenerateFromPassword([]byte("P@ssw0rd"), bcrypt.DefaultCost)
+	userID := uuid.New().String()
+	repo.User().Create(context.Background(), as.UserData{
+		ID:       userID,
+		Name:     "Alice",
+		Password: string(pwd),
+		Email:    "alice@example.com",
+	})
+	sessionID := uuid.New().String()
+	repo.Session().Create(context.Background(), as.SessionData{
+		ID:      sessionID,
+		UserID:  userID,
+		Expires: time.Now().Add(time.Hour).Unix(),
+	})
+
+	logs := bytes.Buffer{}
+	l := logging.NewLogger(&logs)
+	authService := as.NewService(repo, &l)
+	mails := bytes.Buffer{}
+	mailer := mail.NewLogMailer(&mails, "calendar@example.com")
+	authService.SetMailer(&mailer, "http://example.com")
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	// token is read from the mail when it is used. So it must be a function.
+	token := func() string {
+		m := regexp.MustCompile(`token=([\w-]+)`).FindStringSubmatch(mails.String())
+		if m == nil {
+			return ""
+		}
+		return m[1]
+	}
+
+	testcases := []struct {
+		name string
+		path string
+		body func() map[string]string
+		code int
+	}{
+		{
+			name: "invalid email",
+			path: "/auth/password/reset-request",
+			body: func() map[string]string { return map[string]string{"email": "alice"} },
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "unknown email",
+			path: "/auth/password/reset-request",
+			body: func() map[string]string { return map[string]string{"email": "bob@example.com"} },
+			code: http.StatusNoContent,
+		},
+		{
+			name: "request reset",
+			path: "/auth/password/reset-request",
+			body: func() map[string]string { return map[string]string{"email": "alice@example.com"} },
+			code: http.StatusNoContent,
+		},
+		{
+			name: "invalid token",
+			path: "/auth/password/reset",
+			body: func() map[string]string { return map[string]string{"token": "invalid", "password": "N3w-P@ssw0rd"} },
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "invalid password",
+			path: "/auth/password/reset",
+			body: func() map[string]string { return map[string]string{"token": token(), "password": "password"} },
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "reset password",
+			path: "/auth/password/reset",
+			body: func() map[string]string { return map[string]string{"token": token(), "password": "N3w-P@ssw0rd"} },
+			code: http.StatusNoContent,
+		},
+		{
+			name: "used token",
+			path: "/auth/password/reset",
+			body: func() map[string]string { return map[string]string{"token": token(), "password": "N3w-P@ssw0rd"} },
+			code: http.StatusUnauthorized,
+		},
+		{
+			name: "signin with new password",
+			path: "/auth/signin",
+			body: func() map[string]string { return map[string]string{"name": "Alice", "password": "N3w-P@ssw0rd"} },
+			code: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.body())
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBuffer(body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+
+	ctx := context.WithValue(context.Background(), cctx.ReqIDKey, uuid.New().String())
+	if _, err := authService.Authorize(ctx, sessionID); err == nil {
+		t.Errorf("session created before reset is still valid")
+	}
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		if strings.Contains(logs.String(), email) {
+			t.Errorf("email is logged: %v", logs.String())
+		}
+	}
+}
+
+func TestNewRouter_VerifyEmail(t *testing.T) {
+	repo := testutils.NewAuthRepo()
+	l := testutils.NewLogger()
+	authService := as.NewService(repo, l)
+	mails := bytes.Buffer{}
+	mailer := mail.NewLogMailer(&mails, "calendar@example.com")
+	authService.SetMailer(&mailer, "http://example.com")
+	r := mux.NewRouter()
+	NewRouter(r.PathPrefix("/auth").Subrouter(), authService, testutils.NewCSRF(), Options{})
+
+	body, _ := json.Marshal(map[string]string{"name": "Alice", "password": "P@ssw0rd", "email": "alice@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/signup", bytes.NewBuffer(body))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("signup status code: want %v but %v", http.StatusNoContent, rec.Code)
+	}
+
+	user, _ := repo.User().FindByName(context.Background(), "Alice")
+	if user.Verified {
+		t.Fatalf("user is verified before verification")
+	}
+
+	m := regexp.MustCompile(`token=([\w-]+)`).FindStringSubmatch(mails.String())
+	if m == nil {
+		t.Fatalf("verification mail is not sent: %v", mails.String())
+	}
+
+	testcases := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{
+			name:  "invalid token",
+			token: "invalid",
+			code:  http.StatusUnauthorized,
+		},
+		{
+			name:  "verify",
+			token: m[1],
+			code:  http.StatusFound,
+		},
+		{
+			name:  "used token",
+			token: m[1],
+			code:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/auth/verify?token="+tc.token, nil)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+
+	user, _ = repo.User().FindByName(context.Background(), "Alice")
+	if !user.Verified {
+		t.Errorf("user is not verified")
+	}
+}
@@ -0,0 +1,103 @@
+package calendar_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/x-color/calendar/app/rest/testutils"
+	"github.com/x-color/calendar/cache"
+	"github.com/x-color/calendar/calendar/repogitory/cached"
+	cs "github.com/x-color/calendar/calendar/service"
+)
+
+func TestCachedRepogitory(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	userID, sessionID := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: userID})
+	cal := makeCalendar(calRepo, userID)
+
+	counter := &queryCounter{}
+	c := cache.NewLRU(100, time.Minute)
+	repo := cached.NewRepogitory(countingRepo{calRepo, counter}, &c)
+	r := testutils.NewCalendarRouter(authRepo, &repo)
+
+	ctx := context.Background()
+
+	t.Run("users are cached", func(t *testing.T) {
+		counter.reset()
+		for i := 0; i < 2; i++ {
+			if rec := testutils.Request(r, http.MethodGet, "/calendars", sessionID, nil); rec.Code != http.StatusOK {
+				t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+			}
+		}
+		if n := counter.reset(); n != 1 {
+			t.Errorf("user is found in %v queries", n)
+		}
+	})
+
+	t.Run("changed calendars are invalidated", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if _, err := repo.Calendar().Find(ctx, cal.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n := counter.reset(); n != 1 {
+			t.Errorf("calendar is found in %v queries", n)
+		}
+
+		rec := testutils.Request(r, http.MethodPatch, "/calendars/"+cal.ID, sessionID, map[string]interface{}{
+			"name":   "renamed",
+			"color":  "red",
+			"shares": []string{userID},
+		})
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+
+		found, err := repo.Calendar().Find(ctx, cal.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Name != "renamed" {
+			t.Errorf("stale calendar is found: %v", found.Name)
+		}
+	})
+
+	t.Run("cache is not read in transactions", func(t *testing.T) {
+		counter.reset()
+		errRollback := errors.New("rollback")
+		err := repo.Transaction(ctx, func(r cs.Repogitory) error {
+			found, err := r.Calendar().Find(ctx, cal.ID)
+			if err != nil {
+				return err
+			}
+			found.Name = "rolled back"
+			if err := r.Calendar().Update(ctx, found); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("want %v but %v", errRollback, err)
+		}
+		if n := counter.reset(); n != 1 {
+			t.Errorf("calendar is found in %v queries", n)
+		}
+
+		found, err := repo.Calendar().Find(ctx, cal.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Name != "renamed" {
+			t.Errorf("rolled back calendar is found: %v", found.Name)
+		}
+	})
+
+	if stats := c.Stats(); stats.Hits == 0 || stats.Misses == 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
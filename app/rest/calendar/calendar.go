@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
 	as "github.com/x-color/calendar/auth/service"
 	"github.com/x-color/calendar/calendar/model"
 	"github.com/x-color/calendar/calendar/service"
@@ -16,36 +17,46 @@ import (
 )
 
 type CalendarContent struct {
-	ID     string        `json:"id"`
-	UserID string        `json:"user_id"`
-	Name   string        `json:"name"`
-	Color  string        `json:"color"`
-	Shares []string      `json:"shares"`
-	Plans  []PlanContent `json:"plans"`
+	ID          string        `json:"id"`
+	UserID      string        `json:"user_id"`
+	UserName    string        `json:"user_name"`
+	OrgID       string        `json:"org_id"`
+	Name        string        `json:"name"`
+	Color       string        `json:"color"`
+	Shares      []string      `json:"shares"`
+	ShareNames  []string      `json:"share_names"`
+	GroupShares []string      `json:"group_shares,omitempty"`
+	Plans       []PlanContent `json:"plans"`
 }
 
-func calModelToContent(cal model.Calendar) CalendarContent {
+// calModelToContent converts calendar into content. names is display names of users in it.
+func calModelToContent(cal model.Calendar, names map[string]string) CalendarContent {
 	plans := make([]PlanContent, len(cal.Plans))
 	for i, p := range cal.Plans {
-		plans[i] = planModelToContent(p)
+		plans[i] = planModelToContent(p, names)
 	}
 
 	c := CalendarContent{
-		ID:     cal.ID,
-		UserID: cal.UserID,
-		Name:   cal.Name,
-		Color:  string(cal.Color),
-		Shares: cal.Shares,
-		Plans:  plans,
+		ID:          cal.ID,
+		UserID:      cal.UserID,
+		UserName:    names[cal.UserID],
+		OrgID:       cal.OrgID,
+		Name:        cal.Name,
+		Color:       string(cal.Color),
+		Shares:      cal.Shares,
+		ShareNames:  userNames(cal.Shares, names),
+		GroupShares: cal.GroupShares,
+		Plans:       plans,
 	}
 	return c
 }
 
-func planModelToContent(plan model.Plan) PlanContent {
+func planModelToContent(plan model.Plan, names map[string]string) PlanContent {
 	p := PlanContent{
 		ID:         plan.ID,
 		CalendarID: plan.CalendarID,
 		UserID:     plan.UserID,
+		UserName:   names[plan.UserID],
 		Name:       plan.Name,
 		Memo:       plan.Memo,
 		Color:      string(plan.Color),
@@ -58,6 +69,40 @@ func planModelToContent(plan model.Plan) PlanContent {
 	return p
 }
 
+func userNames(userIDs []string, names map[string]string) []string {
+	l := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		l[i] = names[id]
+	}
+	return l
+}
+
+// calendarUserIDs returns IDs of all users appearing in calendars.
+func calendarUserIDs(cals []model.Calendar) []string {
+	ids := []string{}
+	for _, c := range cals {
+		ids = append(ids, c.UserID)
+		ids = append(ids, c.Shares...)
+		for _, p := range c.Plans {
+			ids = append(ids, p.UserID)
+		}
+	}
+	return ids
+}
+
+// writeError writes the status code for the error returned by the service.
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+	} else if errors.Is(err, cerror.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else if errors.Is(err, cerror.ErrAuthorization) {
+		w.WriteHeader(http.StatusForbidden)
+	} else {
+		response.WriteServerError(w, err)
+	}
+}
+
 type calEndpoint struct {
 	service service.Service
 }
@@ -69,13 +114,19 @@ func (e *calEndpoint) GetCalendarsHandler(w http.ResponseWriter, r *http.Request
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
+		return
+	}
+
+	names, err := e.service.GetDisplayNames(r.Context(), calendarUserIDs(cl))
+	if err != nil {
+		response.WriteServerError(w, err)
 		return
 	}
 
 	cals := make([]CalendarContent, len(cl))
 	for i, c := range cl {
-		cals[i] = calModelToContent(c)
+		cals[i] = calModelToContent(c, names)
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -90,21 +141,40 @@ func (e *calEndpoint) MakeCalendarHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	userID := r.Context().Value(cctx.UserIDKey).(string)
-	cal, err := e.service.MakeCalendar(r.Context(), userID, req.Name, req.Color)
+	var cal model.Calendar
+	var err error
+	if req.OrgID == "" {
+		cal, err = e.service.MakeCalendar(r.Context(), userID, req.Name, req.Color)
+	} else {
+		cal, err = e.service.MakeOrgCalendar(r.Context(), userID, req.OrgID, req.Name, req.Color)
+	}
 	if errors.Is(err, cerror.ErrInvalidContent) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
+	} else if errors.Is(err, cerror.ErrAuthorization) {
+		w.WriteHeader(http.StatusForbidden)
+		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
+		return
+	}
+
+	names, err := e.service.GetDisplayNames(r.Context(), calendarUserIDs([]model.Calendar{cal}))
+	if err != nil {
+		response.WriteServerError(w, err)
 		return
 	}
 
+	setOperationID(w, r)
 	json.NewEncoder(w).Encode(CalendarContent{
-		ID:     cal.ID,
-		UserID: cal.UserID,
-		Name:   cal.Name,
-		Color:  string(cal.Color),
-		Shares: cal.Shares,
+		ID:         cal.ID,
+		UserID:     cal.UserID,
+		UserName:   names[cal.UserID],
+		OrgID:      cal.OrgID,
+		Name:       cal.Name,
+		Color:      string(cal.Color),
+		Shares:     cal.Shares,
+		ShareNames: userNames(cal.Shares, names),
 	})
 }
 
@@ -119,10 +189,11 @@ func (e *calEndpoint) RemoveCalendarHandler(w http.ResponseWriter, r *http.Reque
 		w.WriteHeader(http.StatusForbidden)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
+	setOperationID(w, r)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -140,11 +211,13 @@ func (e *calEndpoint) ChangeCalendarHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Current group shares are kept if group_shares is not in the body.
 	cal := model.Calendar{
-		ID:     vars["id"],
-		Name:   req.Name,
-		Color:  color,
-		Shares: req.Shares,
+		ID:          vars["id"],
+		Name:        req.Name,
+		Color:       color,
+		Shares:      req.Shares,
+		GroupShares: req.GroupShares,
 	}
 
 	userID := r.Context().Value(cctx.UserIDKey).(string)
@@ -159,10 +232,11 @@ func (e *calEndpoint) ChangeCalendarHandler(w http.ResponseWriter, r *http.Reque
 		w.WriteHeader(http.StatusForbidden)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
+	setOperationID(w, r)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -175,4 +249,9 @@ func NewCalendarRouter(r *mux.Router, calService cs.Service, authService as.Serv
 	r.HandleFunc("", e.MakeCalendarHandler).Methods(http.MethodPost)
 	r.HandleFunc("/{id}", e.RemoveCalendarHandler).Methods(http.MethodDelete)
 	r.HandleFunc("/{id}", e.ChangeCalendarHandler).Methods(http.MethodPatch)
+	r.HandleFunc("/{id}/audit", e.GetAuditHandler).Methods(http.MethodGet)
+	r.HandleFunc("/transfers", e.GetTransfersHandler).Methods(http.MethodGet)
+	r.HandleFunc("/{id}/transfer", e.RequestTransferHandler).Methods(http.MethodPost)
+	r.HandleFunc("/{id}/transfer", e.CancelTransferHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/{id}/transfer/accept", e.AcceptTransferHandler).Methods(http.MethodPost)
 }
@@ -27,13 +27,21 @@ func calModelToContent(cal model.Calendar) CalendarContent {
 		plans[i] = planModelToContent(p)
 	}
 
+	// Users without profile are shown by names of their accounts. All users made by
+	// MakeSession are named Alice.
+	shareNames := make([]string, len(cal.Shares))
+	for i := range cal.Shares {
+		shareNames[i] = "Alice"
+	}
 	c := CalendarContent{
-		ID:     cal.ID,
-		UserID: cal.UserID,
-		Name:   cal.Name,
-		Color:  string(cal.Color),
-		Shares: cal.Shares,
-		Plans:  plans,
+		ID:         cal.ID,
+		UserID:     cal.UserID,
+		UserName:   "Alice",
+		Name:       cal.Name,
+		Color:      string(cal.Color),
+		Shares:     cal.Shares,
+		ShareNames: shareNames,
+		Plans:      plans,
 	}
 	return c
 }
@@ -43,6 +51,7 @@ func planModelToContent(plan model.Plan) PlanContent {
 		ID:         plan.ID,
 		CalendarID: plan.CalendarID,
 		UserID:     plan.UserID,
+		UserName:   "Alice",
 		Name:       plan.Name,
 		Memo:       plan.Memo,
 		Color:      string(plan.Color),
@@ -64,6 +73,7 @@ func TestNewCalendarRouter_Authoraization(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(authRepo, l)
 	calendarService := cs.NewService(calRepo, l)
+	calendarService.SetUserNamer(&authService)
 	r := mux.NewRouter()
 	r.Use(middlewares.ReqIDMiddleware)
 	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
@@ -98,12 +108,14 @@ func TestNewCalendarRouter_Authoraization(t *testing.T) {
 			body:   map[string]interface{}{"name": "My plans", "color": "red"},
 			code:   http.StatusOK,
 			res: CalendarContent{
-				ID:     "",
-				UserID: userID,
-				Name:   "My plans",
-				Color:  "red",
-				Shares: []string{userID},
-				Plans:  nil,
+				ID:         "",
+				UserID:     userID,
+				UserName:   "Alice",
+				Name:       "My plans",
+				Color:      "red",
+				Shares:     []string{userID},
+				ShareNames: []string{"Alice"},
+				Plans:      nil,
 			},
 		},
 	}
@@ -147,6 +159,7 @@ func TestNewCalendarRouter_UserRegistrationChecker(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(authRepo, l)
 	calendarService := cs.NewService(calRepo, l)
+	calendarService.SetUserNamer(&authService)
 	r := mux.NewRouter()
 	r.Use(middlewares.ReqIDMiddleware)
 	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
@@ -180,12 +193,14 @@ func TestNewCalendarRouter_UserRegistrationChecker(t *testing.T) {
 			body:   map[string]interface{}{"name": "test", "color": "red"},
 			code:   http.StatusOK,
 			res: CalendarContent{
-				ID:     "",
-				UserID: userID,
-				Name:   "test",
-				Color:  "red",
-				Shares: []string{userID},
-				Plans:  nil,
+				ID:         "",
+				UserID:     userID,
+				UserName:   "Alice",
+				Name:       "test",
+				Color:      "red",
+				Shares:     []string{userID},
+				ShareNames: []string{"Alice"},
+				Plans:      nil,
 			},
 		},
 	}
@@ -240,6 +255,7 @@ func TestNewCalendarRouter_GetCalendars(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(authRepo, l)
 	calendarService := cs.NewService(calRepo, l)
+	calendarService.SetUserNamer(&authService)
 	r := mux.NewRouter()
 	r.Use(middlewares.ReqIDMiddleware)
 	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
@@ -339,6 +355,7 @@ func TestNewCalendarRouter_MakeCalendar(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(authRepo, l)
 	calendarService := cs.NewService(calRepo, l)
+	calendarService.SetUserNamer(&authService)
 	r := mux.NewRouter()
 	r.Use(middlewares.ReqIDMiddleware)
 	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
@@ -367,12 +384,14 @@ func TestNewCalendarRouter_MakeCalendar(t *testing.T) {
 			body:   map[string]interface{}{"name": "My plans", "color": "red"},
 			code:   http.StatusOK,
 			res: CalendarContent{
-				ID:     "",
-				UserID: userID,
-				Name:   "My plans",
-				Color:  "red",
-				Shares: []string{userID},
-				Plans:  nil,
+				ID:         "",
+				UserID:     userID,
+				UserName:   "Alice",
+				Name:       "My plans",
+				Color:      "red",
+				Shares:     []string{userID},
+				ShareNames: []string{"Alice"},
+				Plans:      nil,
 			},
 		},
 	}
@@ -441,6 +460,7 @@ func TestNewCalendarRouter_RemoveCalendar(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(authRepo, l)
 	calendarService := cs.NewService(calRepo, l)
+	calendarService.SetUserNamer(&authService)
 	r := mux.NewRouter()
 	r.Use(middlewares.ReqIDMiddleware)
 	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
@@ -512,6 +532,7 @@ func TestNewCalendarRouter_ChangeCalendar(t *testing.T) {
 	l := testutils.NewLogger()
 	authService := as.NewService(authRepo, l)
 	calendarService := cs.NewService(calRepo, l)
+	calendarService.SetUserNamer(&authService)
 	r := mux.NewRouter()
 	r.Use(middlewares.ReqIDMiddleware)
 	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
@@ -581,3 +602,79 @@ func TestNewCalendarRouter_ChangeCalendar(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCalendarRouter_ChangeCalendarWithUnverifiedUser(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	userID, sessionID := testutils.MakeSession(authRepo)
+	verifiedID, _ := testutils.MakeSession(authRepo)
+	unverifiedID, _ := testutils.MakeSession(authRepo)
+	user, _ := authRepo.User().Find(context.Background(), verifiedID)
+	user.Verified = true
+	authRepo.User().Update(context.Background(), user)
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: userID})
+	calRepo.User().Create(context.Background(), cs.UserData{ID: verifiedID})
+	calRepo.User().Create(context.Background(), cs.UserData{ID: unverifiedID})
+	cal := makeCalendar(calRepo, userID)
+	sharedCal := makeCalendar(calRepo, userID, unverifiedID)
+
+	l := testutils.NewLogger()
+	authService := as.NewService(authRepo, l)
+	calendarService := cs.NewService(calRepo, l)
+	calendarService.SetUserNamer(&authService)
+	calendarService.SetUserVerifier(&authService)
+	r := mux.NewRouter()
+	r.Use(middlewares.ReqIDMiddleware)
+	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
+
+	cookie := http.Cookie{
+		Name:  "session_id",
+		Value: sessionID,
+	}
+
+	testcases := []struct {
+		name   string
+		cookie *http.Cookie
+		calID  string
+		body   map[string]interface{}
+		code   int
+	}{
+		{
+			name:   "invite unverified user",
+			cookie: &cookie,
+			calID:  cal.ID,
+			body:   map[string]interface{}{"name": "Renamed", "color": "yellow", "shares": []interface{}{userID, unverifiedID}},
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "invite verified user",
+			cookie: &cookie,
+			calID:  cal.ID,
+			body:   map[string]interface{}{"name": "Renamed", "color": "yellow", "shares": []interface{}{userID, verifiedID}},
+			code:   http.StatusNoContent,
+		},
+		{
+			name:   "keep already shared unverified user",
+			cookie: &cookie,
+			calID:  sharedCal.ID,
+			body:   map[string]interface{}{"name": "Renamed", "color": "yellow", "shares": []interface{}{userID, unverifiedID}},
+			code:   http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.body)
+			req := httptest.NewRequest(http.MethodPatch, "/calendars/"+tc.calID, bytes.NewBuffer(body))
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+}
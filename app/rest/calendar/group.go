@@ -0,0 +1,140 @@
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
+	as "github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/calendar/model"
+	"github.com/x-color/calendar/calendar/service"
+	cs "github.com/x-color/calendar/calendar/service"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type GroupContent struct {
+	ID          string   `json:"id"`
+	UserID      string   `json:"user_id"`
+	Name        string   `json:"name"`
+	Members     []string `json:"members"`
+	MemberNames []string `json:"member_names"`
+}
+
+func groupModelToContent(group model.Group, names map[string]string) GroupContent {
+	return GroupContent{
+		ID:          group.ID,
+		UserID:      group.UserID,
+		Name:        group.Name,
+		Members:     group.Members,
+		MemberNames: userNames(group.Members, names),
+	}
+}
+
+type groupEndpoint struct {
+	service service.Service
+}
+
+func (e *groupEndpoint) GetGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	gl, err := e.service.GetGroups(r.Context(), userID)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	ids := []string{}
+	for _, g := range gl {
+		ids = append(ids, g.Members...)
+	}
+	names, err := e.service.GetDisplayNames(r.Context(), ids)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	groups := make([]GroupContent, len(gl))
+	for i, g := range gl {
+		groups[i] = groupModelToContent(g, names)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(groups)
+}
+
+func (e *groupEndpoint) MakeGroupHandler(w http.ResponseWriter, r *http.Request) {
+	req := GroupContent{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	group, err := e.service.MakeGroup(r.Context(), userID, req.Name)
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	names, err := e.service.GetDisplayNames(r.Context(), group.Members)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(groupModelToContent(group, names))
+}
+
+func (e *groupEndpoint) RemoveGroupHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.RemoveGroup(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *groupEndpoint) AddMemberHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.AddGroupMember(r.Context(), userID, vars["id"], vars["userID"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *groupEndpoint) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.RemoveGroupMember(r.Context(), userID, vars["id"], vars["userID"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func NewGroupRouter(r *mux.Router, calService cs.Service, authService as.Service) {
+	e := groupEndpoint{calService}
+	r.Use(middlewares.ResponseHeaderMiddleware)
+	r.Use(middlewares.AuthorizationMiddleware(authService))
+	r.Use(userCheckerMiddleware(calService))
+	r.HandleFunc("", e.GetGroupsHandler).Methods(http.MethodGet)
+	r.HandleFunc("", e.MakeGroupHandler).Methods(http.MethodPost)
+	r.HandleFunc("/{id}", e.RemoveGroupHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/{id}/members/{userID}", e.AddMemberHandler).Methods(http.MethodPut)
+	r.HandleFunc("/{id}/members/{userID}", e.RemoveMemberHandler).Methods(http.MethodDelete)
+}
@@ -0,0 +1,200 @@
+package calendar_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	. "github.com/x-color/calendar/app/rest/calendar"
+	"github.com/x-color/calendar/app/rest/testutils"
+	cs "github.com/x-color/calendar/calendar/service"
+)
+
+func TestNewGroupRouter_Members(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	memberID, memberSession := testutils.MakeSession(authRepo)
+	otherID, _ := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, memberID, otherID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	rec := testutils.Request(r, http.MethodPost, "/groups", ownerSession, map[string]interface{}{"name": "Team"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+	}
+	var group GroupContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &group); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+
+	testcases := []struct {
+		name      string
+		method    string
+		sessionID string
+		groupID   string
+		userID    string
+		code      int
+	}{
+		{
+			name:      "group does not exist",
+			method:    http.MethodPut,
+			sessionID: ownerSession,
+			groupID:   uuid.New().String(),
+			userID:    memberID,
+			code:      http.StatusNotFound,
+		},
+		{
+			name:      "user does not exist",
+			method:    http.MethodPut,
+			sessionID: ownerSession,
+			groupID:   group.ID,
+			userID:    uuid.New().String(),
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "add member",
+			method:    http.MethodPut,
+			sessionID: ownerSession,
+			groupID:   group.ID,
+			userID:    memberID,
+			code:      http.StatusNoContent,
+		},
+		{
+			name:      "add member by member",
+			method:    http.MethodPut,
+			sessionID: memberSession,
+			groupID:   group.ID,
+			userID:    otherID,
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "owner leaves",
+			method:    http.MethodDelete,
+			sessionID: ownerSession,
+			groupID:   group.ID,
+			userID:    ownerID,
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "remove owner by member",
+			method:    http.MethodDelete,
+			sessionID: memberSession,
+			groupID:   group.ID,
+			userID:    ownerID,
+			code:      http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testutils.Request(r, tc.method, "/groups/"+tc.groupID+"/members/"+tc.userID, tc.sessionID, nil)
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+
+	t.Run("get groups", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodGet, "/groups", memberSession, nil)
+		var groups []GroupContent
+		if err := json.Unmarshal(rec.Body.Bytes(), &groups); err != nil {
+			t.Fatalf("invalid response body: %v", rec.Body.String())
+		}
+		expected := []GroupContent{
+			{
+				ID:          group.ID,
+				UserID:      ownerID,
+				Name:        "Team",
+				Members:     []string{ownerID, memberID},
+				MemberNames: []string{ownerID, memberID},
+			},
+		}
+		if d := cmp.Diff(expected, groups); d != "" {
+			t.Errorf("invalid groups: \n%v", d)
+		}
+	})
+}
+
+func TestNewGroupRouter_GroupShares(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	memberID, memberSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, memberID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	cal := makeCalendar(calRepo, ownerID)
+	groupID := uuid.New().String()
+	calRepo.Group().Create(context.Background(), cs.GroupData{ID: groupID, UserID: ownerID, Name: "Team"})
+	calRepo.Group().AddMember(context.Background(), groupID, ownerID)
+	otherGroupID := uuid.New().String()
+	calRepo.Group().Create(context.Background(), cs.GroupData{ID: otherGroupID, UserID: memberID, Name: "Other"})
+	calRepo.Group().AddMember(context.Background(), otherGroupID, memberID)
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	// canUse tells whether the member sees the calendar and can schedule plans in it.
+	canUse := func() (bool, bool) {
+		rec := testutils.Request(r, http.MethodGet, "/calendars", memberSession, nil)
+		var cals []CalendarContent
+		json.Unmarshal(rec.Body.Bytes(), &cals)
+		visible := len(cals) == 1 && cals[0].ID == cal.ID
+
+		rec = testutils.Request(r, http.MethodPost, "/plans", memberSession, map[string]interface{}{
+			"calendar_id": cal.ID,
+			"name":        "meeting",
+			"color":       "red",
+			"shares":      []interface{}{cal.ID},
+			"is_all_day":  true,
+			"begin":       time.Date(2020, 4, 1, 0, 0, 0, 0, time.Local).Unix(),
+			"end":         time.Date(2020, 4, 1, 0, 0, 0, 0, time.Local).Unix(),
+		})
+		return visible, rec.Code == http.StatusOK
+	}
+
+	body := map[string]interface{}{
+		"name":         "Team plans",
+		"color":        "red",
+		"shares":       []interface{}{ownerID},
+		"group_shares": []interface{}{otherGroupID},
+	}
+	rec := testutils.Request(r, http.MethodPatch, "/calendars/"+cal.ID, ownerSession, body)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("share with group of others: want %v but %v", http.StatusBadRequest, rec.Code)
+	}
+
+	body["group_shares"] = []interface{}{groupID}
+	rec = testutils.Request(r, http.MethodPatch, "/calendars/"+cal.ID, ownerSession, body)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("share with group: want %v but %v", http.StatusNoContent, rec.Code)
+	}
+	if visible, schedulable := canUse(); visible || schedulable {
+		t.Errorf("user out of group can use calendar: visible %v, schedulable %v", visible, schedulable)
+	}
+
+	testutils.Request(r, http.MethodPut, "/groups/"+groupID+"/members/"+memberID, ownerSession, nil)
+	if visible, schedulable := canUse(); !visible || !schedulable {
+		t.Errorf("group member can not use calendar: visible %v, schedulable %v", visible, schedulable)
+	}
+
+	// Group shares are kept when they are not given.
+	delete(body, "group_shares")
+	testutils.Request(r, http.MethodPatch, "/calendars/"+cal.ID, ownerSession, body)
+	if visible, _ := canUse(); !visible {
+		t.Errorf("group shares are removed")
+	}
+
+	rec = testutils.Request(r, http.MethodDelete, "/groups/"+groupID+"/members/"+memberID, memberSession, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("member leaves: want %v but %v", http.StatusNoContent, rec.Code)
+	}
+	if visible, schedulable := canUse(); visible || schedulable {
+		t.Errorf("user left group can use calendar: visible %v, schedulable %v", visible, schedulable)
+	}
+}
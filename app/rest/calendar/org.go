@@ -0,0 +1,169 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
+	as "github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/calendar/model"
+	"github.com/x-color/calendar/calendar/service"
+	cs "github.com/x-color/calendar/calendar/service"
+	cctx "github.com/x-color/calendar/model/ctx"
+)
+
+type OrgContent struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type MemberContent struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type orgEndpoint struct {
+	service service.Service
+}
+
+func (e *orgEndpoint) GetOrgsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	ol, err := e.service.GetOrgs(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	orgs := make([]OrgContent, len(ol))
+	for i, o := range ol {
+		orgs[i] = OrgContent{
+			ID:   o.ID,
+			Name: o.Name,
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(orgs)
+}
+
+func (e *orgEndpoint) MakeOrgHandler(w http.ResponseWriter, r *http.Request) {
+	req := OrgContent{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	org, err := e.service.MakeOrg(r.Context(), userID, req.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(OrgContent{
+		ID:   org.ID,
+		Name: org.Name,
+	})
+}
+
+func (e *orgEndpoint) RemoveOrgHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.RemoveOrg(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *orgEndpoint) GetMembersHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	ml, err := e.service.GetMembers(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ids := make([]string, len(ml))
+	for i, m := range ml {
+		ids[i] = m.UserID
+	}
+	names, err := e.service.GetDisplayNames(r.Context(), ids)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	members := make([]MemberContent, len(ml))
+	for i, m := range ml {
+		members[i] = MemberContent{
+			ID:   m.UserID,
+			Name: names[m.UserID],
+			Role: string(m.Role),
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(members)
+}
+
+func (e *orgEndpoint) SetMemberHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	req := MemberContent{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	role, err := model.ConvertToRole(req.Role)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	member := model.Member{
+		OrgID:  vars["id"],
+		UserID: vars["userID"],
+		Role:   role,
+	}
+
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err = e.service.SetMember(r.Context(), userID, member)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *orgEndpoint) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.RemoveMember(r.Context(), userID, vars["id"], vars["userID"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func NewOrgRouter(r *mux.Router, calService cs.Service, authService as.Service) {
+	e := orgEndpoint{calService}
+	r.Use(middlewares.ResponseHeaderMiddleware)
+	r.Use(middlewares.AuthorizationMiddleware(authService))
+	r.Use(userCheckerMiddleware(calService))
+	r.HandleFunc("", e.GetOrgsHandler).Methods(http.MethodGet)
+	r.HandleFunc("", e.MakeOrgHandler).Methods(http.MethodPost)
+	r.HandleFunc("/{id}", e.RemoveOrgHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/{id}/members", e.GetMembersHandler).Methods(http.MethodGet)
+	r.HandleFunc("/{id}/members/{userID}", e.SetMemberHandler).Methods(http.MethodPut)
+	r.HandleFunc("/{id}/members/{userID}", e.RemoveMemberHandler).Methods(http.MethodDelete)
+}
@@ -0,0 +1,291 @@
+package calendar_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	. "github.com/x-color/calendar/app/rest/calendar"
+	"github.com/x-color/calendar/app/rest/testutils"
+	cs "github.com/x-color/calendar/calendar/service"
+)
+
+func makeOrg(calRepo cs.Repogitory, ownerID string) string {
+	orgID := uuid.New().String()
+	calRepo.Org().Create(context.Background(), cs.OrgData{ID: orgID, Name: "Team"})
+	calRepo.Member().Save(context.Background(), cs.MemberData{OrgID: orgID, UserID: ownerID, Role: "owner"})
+	return orgID
+}
+
+func TestNewOrgRouter_MakeOrg(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	userID, sessionID := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: userID})
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	rec := testutils.Request(r, http.MethodPost, "/orgs", sessionID, map[string]interface{}{"name": ""})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status code: want %v but %v", http.StatusBadRequest, rec.Code)
+	}
+
+	rec = testutils.Request(r, http.MethodPost, "/orgs", sessionID, map[string]interface{}{"name": "Team"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+	}
+	var org OrgContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &org); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+
+	rec = testutils.Request(r, http.MethodGet, "/orgs", sessionID, nil)
+	var orgs []OrgContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &orgs); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+	if d := cmp.Diff([]OrgContent{{ID: org.ID, Name: "Team"}}, orgs); d != "" {
+		t.Errorf("invalid organizations: \n%v", d)
+	}
+
+	rec = testutils.Request(r, http.MethodGet, "/orgs/"+org.ID+"/members", sessionID, nil)
+	var members []MemberContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &members); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+	if d := cmp.Diff([]MemberContent{{ID: userID, Name: userID, Role: "owner"}}, members); d != "" {
+		t.Errorf("maker is not owner: \n%v", d)
+	}
+}
+
+func TestNewOrgRouter_Members(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	adminID, adminSession := testutils.MakeSession(authRepo)
+	memberID, memberSession := testutils.MakeSession(authRepo)
+	otherID, otherSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, adminID, memberID, otherID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	orgID := makeOrg(calRepo, ownerID)
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	testcases := []struct {
+		name      string
+		method    string
+		sessionID string
+		userID    string
+		role      string
+		code      int
+	}{
+		{
+			name:      "add admin",
+			method:    http.MethodPut,
+			sessionID: ownerSession,
+			userID:    adminID,
+			role:      "admin",
+			code:      http.StatusNoContent,
+		},
+		{
+			name:      "invalid role",
+			method:    http.MethodPut,
+			sessionID: adminSession,
+			userID:    memberID,
+			role:      "guest",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "user does not exist",
+			method:    http.MethodPut,
+			sessionID: adminSession,
+			userID:    uuid.New().String(),
+			role:      "member",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "add member by admin",
+			method:    http.MethodPut,
+			sessionID: adminSession,
+			userID:    memberID,
+			role:      "member",
+			code:      http.StatusNoContent,
+		},
+		{
+			name:      "add member by member",
+			method:    http.MethodPut,
+			sessionID: memberSession,
+			userID:    otherID,
+			role:      "member",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "add member by not member",
+			method:    http.MethodPut,
+			sessionID: otherSession,
+			userID:    otherID,
+			role:      "member",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "make owner by admin",
+			method:    http.MethodPut,
+			sessionID: adminSession,
+			userID:    memberID,
+			role:      "owner",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "remove owner by admin",
+			method:    http.MethodDelete,
+			sessionID: adminSession,
+			userID:    ownerID,
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "last owner leaves",
+			method:    http.MethodDelete,
+			sessionID: ownerSession,
+			userID:    ownerID,
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "member leaves",
+			method:    http.MethodDelete,
+			sessionID: memberSession,
+			userID:    memberID,
+			code:      http.StatusNoContent,
+		},
+		{
+			name:      "remove not member",
+			method:    http.MethodDelete,
+			sessionID: ownerSession,
+			userID:    otherID,
+			code:      http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body interface{}
+			if tc.method == http.MethodPut {
+				body = map[string]interface{}{"role": tc.role}
+			}
+			rec := testutils.Request(r, tc.method, "/orgs/"+orgID+"/members/"+tc.userID, tc.sessionID, body)
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodGet, "/orgs/"+orgID+"/members", otherSession, nil)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("status code: want %v but %v", http.StatusForbidden, rec.Code)
+		}
+
+		rec = testutils.Request(r, http.MethodGet, "/orgs/"+orgID+"/members", adminSession, nil)
+		var members []MemberContent
+		if err := json.Unmarshal(rec.Body.Bytes(), &members); err != nil {
+			t.Fatalf("invalid response body: %v", rec.Body.String())
+		}
+		roles := map[string]string{}
+		for _, m := range members {
+			roles[m.ID] = m.Role
+		}
+		if d := cmp.Diff(map[string]string{ownerID: "owner", adminID: "admin"}, roles); d != "" {
+			t.Errorf("invalid members: \n%v", d)
+		}
+	})
+}
+
+func TestNewOrgRouter_OrgCalendar(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	memberID, memberSession := testutils.MakeSession(authRepo)
+	otherID, otherSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, memberID, otherID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	orgID := makeOrg(calRepo, ownerID)
+	calRepo.Member().Save(context.Background(), cs.MemberData{OrgID: orgID, UserID: memberID, Role: "member"})
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	rec := testutils.Request(r, http.MethodPost, "/calendars", memberSession, map[string]interface{}{"name": "Team", "color": "red", "org_id": orgID})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("member makes calendar: want %v but %v", http.StatusForbidden, rec.Code)
+	}
+
+	rec = testutils.Request(r, http.MethodPost, "/calendars", ownerSession, map[string]interface{}{"name": "Team", "color": "red", "org_id": orgID})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+	}
+	var cal CalendarContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &cal); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+	if cal.OrgID != orgID || len(cal.Shares) != 0 {
+		t.Errorf("invalid organization calendar: %+v", cal)
+	}
+
+	testcases := []struct {
+		name      string
+		sessionID string
+		visible   bool
+		code      int
+	}{
+		{
+			name:      "member",
+			sessionID: memberSession,
+			visible:   true,
+			code:      http.StatusOK,
+		},
+		{
+			name:      "not member",
+			sessionID: otherSession,
+			visible:   false,
+			code:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testutils.Request(r, http.MethodGet, "/calendars", tc.sessionID, nil)
+			var cals []CalendarContent
+			if err := json.Unmarshal(rec.Body.Bytes(), &cals); err != nil {
+				t.Fatalf("invalid response body: %v", rec.Body.String())
+			}
+			if visible := len(cals) == 1 && cals[0].ID == cal.ID; visible != tc.visible {
+				t.Errorf("calendar visible: want %v but %v", tc.visible, visible)
+			}
+
+			rec = testutils.Request(r, http.MethodPost, "/plans", tc.sessionID, map[string]interface{}{
+				"calendar_id": cal.ID,
+				"name":        "meeting",
+				"color":       "red",
+				"shares":      []interface{}{cal.ID},
+				"is_all_day":  true,
+				"begin":       time.Date(2020, 4, 1, 0, 0, 0, 0, time.Local).Unix(),
+				"end":         time.Date(2020, 4, 1, 0, 0, 0, 0, time.Local).Unix(),
+			})
+			if rec.Code != tc.code {
+				t.Errorf("schedule: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+
+	t.Run("remove calendar", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID, memberSession, nil)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("member removes calendar: want %v but %v", http.StatusForbidden, rec.Code)
+		}
+		rec = testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID, ownerSession, nil)
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("owner removes calendar: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+	})
+}
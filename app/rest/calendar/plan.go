@@ -8,6 +8,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
 	as "github.com/x-color/calendar/auth/service"
 	"github.com/x-color/calendar/calendar/model"
 	"github.com/x-color/calendar/calendar/service"
@@ -20,6 +21,7 @@ type PlanContent struct {
 	ID         string   `json:"id"`
 	CalendarID string   `json:"calendar_id"`
 	UserID     string   `json:"user_id"`
+	UserName   string   `json:"user_name"`
 	Name       string   `json:"name"`
 	Memo       string   `json:"memo"`
 	Color      string   `json:"color"`
@@ -67,13 +69,21 @@ func (e *planEndpoint) ScheduleHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
+	names, err := e.service.GetDisplayNames(r.Context(), []string{plan.UserID})
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	setOperationID(w, r)
 	json.NewEncoder(w).Encode(PlanContent{
 		ID:         plan.ID,
 		UserID:     plan.UserID,
+		UserName:   names[plan.UserID],
 		CalendarID: plan.CalendarID,
 		Name:       plan.Name,
 		Memo:       plan.Memo,
@@ -106,10 +116,11 @@ func (e *planEndpoint) UnsheduleHandler(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusForbidden)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
+	setOperationID(w, r)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -155,10 +166,11 @@ func (e *planEndpoint) ResheduleHandler(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusForbidden)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
+	setOperationID(w, r)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -170,4 +182,5 @@ func NewPlanRouter(r *mux.Router, calService cs.Service, authService as.Service)
 	r.HandleFunc("", e.ScheduleHandler).Methods(http.MethodPost)
 	r.HandleFunc("/{id}", e.UnsheduleHandler).Methods(http.MethodDelete)
 	r.HandleFunc("/{id}", e.ResheduleHandler).Methods(http.MethodPatch)
+	r.HandleFunc("/{id}/history", e.GetHistoryHandler).Methods(http.MethodGet)
 }
@@ -177,6 +177,7 @@ func TestNewPlanRouter_Authoraization(t *testing.T) {
 			res: PlanContent{
 				ID:         "",
 				UserID:     userID,
+				UserName:   userID,
 				CalendarID: cal.ID,
 				Name:       "all day plan",
 				Memo:       "sample text",
@@ -274,6 +275,7 @@ func TestNewPlanRouter_UserRegistrationChecker(t *testing.T) {
 			res: PlanContent{
 				ID:         "",
 				UserID:     userID,
+				UserName:   userID,
 				CalendarID: cal.ID,
 				Name:       "plan",
 				Memo:       "sample text",
@@ -451,6 +453,7 @@ func TestNewPlanRouter_Shedule(t *testing.T) {
 			res: PlanContent{
 				ID:         "",
 				UserID:     userID,
+				UserName:   userID,
 				CalendarID: cal.ID,
 				Name:       "plan",
 				Memo:       "sample text",
@@ -480,6 +483,7 @@ func TestNewPlanRouter_Shedule(t *testing.T) {
 			res: PlanContent{
 				ID:         "",
 				UserID:     userID,
+				UserName:   userID,
 				CalendarID: cal.ID,
 				Name:       "all day plan",
 				Memo:       "sample text",
@@ -509,6 +513,7 @@ func TestNewPlanRouter_Shedule(t *testing.T) {
 			res: PlanContent{
 				ID:         "",
 				UserID:     userID,
+				UserName:   userID,
 				CalendarID: sharedCal.ID,
 				Name:       "plan",
 				Memo:       "sample text",
@@ -0,0 +1,103 @@
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
+	as "github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/calendar/model"
+	"github.com/x-color/calendar/calendar/service"
+	cs "github.com/x-color/calendar/calendar/service"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type ProfileContent struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"display_name"`
+	AvatarURL         string `json:"avatar_url"`
+	TimeZone          string `json:"time_zone"`
+	Locale            string `json:"locale"`
+	WeekStart         int    `json:"week_start"`
+	DefaultCalendarID string `json:"default_calendar_id"`
+}
+
+func profileModelToContent(profile model.Profile) ProfileContent {
+	return ProfileContent{
+		ID:                profile.UserID,
+		DisplayName:       profile.DisplayName,
+		AvatarURL:         profile.AvatarURL,
+		TimeZone:          profile.TimeZone,
+		Locale:            profile.Locale,
+		WeekStart:         int(profile.WeekStart),
+		DefaultCalendarID: profile.DefaultCalendarID,
+	}
+}
+
+type profileEndpoint struct {
+	service service.Service
+}
+
+func (e *profileEndpoint) GetProfileHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	profile, err := e.service.GetProfile(r.Context(), userID)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(profileModelToContent(profile))
+}
+
+func (e *profileEndpoint) ChangeProfileHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	profile, err := e.service.GetProfile(r.Context(), userID)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	// Fields not in the body are kept as they are.
+	req := profileModelToContent(profile)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	profile = model.Profile{
+		UserID:            userID,
+		DisplayName:       req.DisplayName,
+		AvatarURL:         req.AvatarURL,
+		TimeZone:          req.TimeZone,
+		Locale:            req.Locale,
+		WeekStart:         time.Weekday(req.WeekStart),
+		DefaultCalendarID: req.DefaultCalendarID,
+	}
+
+	err = e.service.ChangeProfile(r.Context(), userID, profile)
+	if errors.Is(err, cerror.ErrInvalidContent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(profileModelToContent(profile))
+}
+
+func NewProfileRouter(r *mux.Router, calService cs.Service, authService as.Service) {
+	e := profileEndpoint{calService}
+	r.Use(middlewares.ResponseHeaderMiddleware)
+	r.Use(middlewares.AuthorizationMiddleware(authService))
+	r.Use(userCheckerMiddleware(calService))
+	r.HandleFunc("", e.GetProfileHandler).Methods(http.MethodGet)
+	r.HandleFunc("", e.ChangeProfileHandler).Methods(http.MethodPatch)
+}
@@ -0,0 +1,218 @@
+package calendar_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	. "github.com/x-color/calendar/app/rest/calendar"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/testutils"
+	as "github.com/x-color/calendar/auth/service"
+	cs "github.com/x-color/calendar/calendar/service"
+)
+
+func TestNewProfileRouter_GetProfile(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	userID, sessionID := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: userID})
+
+	l := testutils.NewLogger()
+	authService := as.NewService(authRepo, l)
+	calendarService := cs.NewService(calRepo, l)
+	r := mux.NewRouter()
+	r.Use(middlewares.ReqIDMiddleware)
+	NewProfileRouter(r.PathPrefix("/me").Subrouter(), calendarService, authService)
+
+	cookie := http.Cookie{
+		Name:  "session_id",
+		Value: sessionID,
+	}
+
+	testcases := []struct {
+		name   string
+		cookie *http.Cookie
+		code   int
+		res    ProfileContent
+	}{
+		{
+			name:   "no cookie",
+			cookie: nil,
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "default profile",
+			cookie: &cookie,
+			code:   http.StatusOK,
+			res: ProfileContent{
+				ID:        userID,
+				TimeZone:  "UTC",
+				Locale:    "en",
+				WeekStart: 0,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+
+			var actual ProfileContent
+			if len(rec.Body.Bytes()) > 0 {
+				if err := json.Unmarshal(rec.Body.Bytes(), &actual); err != nil {
+					t.Errorf("invalid response body: %v", rec.Body.String())
+				}
+			}
+
+			if d := cmp.Diff(tc.res, actual); d != "" {
+				t.Errorf("invalid response body: \n%v", d)
+			}
+		})
+	}
+}
+
+func TestNewProfileRouter_ChangeProfile(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	userID, sessionID := testutils.MakeSession(authRepo)
+	otherID, _ := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: userID})
+	calRepo.User().Create(context.Background(), cs.UserData{ID: otherID})
+
+	cal := makeCalendar(calRepo, userID)
+	otherCal := makeCalendar(calRepo, otherID)
+
+	l := testutils.NewLogger()
+	authService := as.NewService(authRepo, l)
+	calendarService := cs.NewService(calRepo, l)
+	r := mux.NewRouter()
+	r.Use(middlewares.ReqIDMiddleware)
+	NewProfileRouter(r.PathPrefix("/me").Subrouter(), calendarService, authService)
+	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
+
+	cookie := http.Cookie{
+		Name:  "session_id",
+		Value: sessionID,
+	}
+
+	testcases := []struct {
+		name string
+		body map[string]interface{}
+		code int
+		res  ProfileContent
+	}{
+		{
+			name: "invalid time zone",
+			body: map[string]interface{}{"time_zone": "Mars/Olympus_Mons"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid locale",
+			body: map[string]interface{}{"locale": "english"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid week start",
+			body: map[string]interface{}{"week_start": 7},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid avatar url",
+			body: map[string]interface{}{"avatar_url": "javascript:alert(1)"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "calendar of other user as default",
+			body: map[string]interface{}{"default_calendar_id": otherCal.ID},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "change profile",
+			body: map[string]interface{}{
+				"display_name":        "Alice",
+				"time_zone":           "Asia/Tokyo",
+				"locale":              "ja-JP",
+				"week_start":          1,
+				"default_calendar_id": cal.ID,
+			},
+			code: http.StatusOK,
+			res: ProfileContent{
+				ID:                userID,
+				DisplayName:       "Alice",
+				TimeZone:          "Asia/Tokyo",
+				Locale:            "ja-JP",
+				WeekStart:         1,
+				DefaultCalendarID: cal.ID,
+			},
+		},
+		{
+			name: "change only avatar",
+			body: map[string]interface{}{"avatar_url": "https://example.com/alice.png"},
+			code: http.StatusOK,
+			res: ProfileContent{
+				ID:                userID,
+				DisplayName:       "Alice",
+				AvatarURL:         "https://example.com/alice.png",
+				TimeZone:          "Asia/Tokyo",
+				Locale:            "ja-JP",
+				WeekStart:         1,
+				DefaultCalendarID: cal.ID,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.body)
+			req := httptest.NewRequest(http.MethodPatch, "/me", bytes.NewBuffer(body))
+			req.AddCookie(&cookie)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+
+			var actual ProfileContent
+			if len(rec.Body.Bytes()) > 0 {
+				if err := json.Unmarshal(rec.Body.Bytes(), &actual); err != nil {
+					t.Errorf("invalid response body: %v", rec.Body.String())
+				}
+			}
+
+			if d := cmp.Diff(tc.res, actual); d != "" {
+				t.Errorf("invalid response body: \n%v", d)
+			}
+		})
+	}
+
+	t.Run("display name in calendars", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/calendars", nil)
+		req.AddCookie(&cookie)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		var actual []CalendarContent
+		if err := json.Unmarshal(rec.Body.Bytes(), &actual); err != nil {
+			t.Fatalf("invalid response body: %v", rec.Body.String())
+		}
+		if len(actual) != 1 || actual[0].UserName != "Alice" || !cmp.Equal(actual[0].ShareNames, []string{"Alice"}) {
+			t.Errorf("display name is not used: %+v", actual)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package calendar_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/x-color/calendar/app/rest/testutils"
+	cs "github.com/x-color/calendar/calendar/service"
+)
+
+// countingRepo counts queries finding calendars, plans, users, profiles and groups one by one.
+type countingRepo struct {
+	cs.Repogitory
+	counter *queryCounter
+}
+
+type queryCounter struct {
+	m     sync.Mutex
+	count int
+}
+
+func (c *queryCounter) inc() {
+	c.m.Lock()
+	c.count++
+	c.m.Unlock()
+}
+
+func (c *queryCounter) reset() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	n := c.count
+	c.count = 0
+	return n
+}
+
+func (r countingRepo) Calendar() cs.CalendarRepogitory {
+	return countingCalendarRepo{r.Repogitory.Calendar(), r.counter}
+}
+
+func (r countingRepo) Plan() cs.PlanRepogitory {
+	return countingPlanRepo{r.Repogitory.Plan(), r.counter}
+}
+
+func (r countingRepo) User() cs.UserRepogitory {
+	return countingUserRepo{r.Repogitory.User(), r.counter}
+}
+
+func (r countingRepo) Profile() cs.ProfileRepogitory {
+	return countingProfileRepo{r.Repogitory.Profile(), r.counter}
+}
+
+func (r countingRepo) Group() cs.GroupRepogitory {
+	return countingGroupRepo{r.Repogitory.Group(), r.counter}
+}
+
+func (r countingRepo) Transaction(ctx context.Context, f func(cs.Repogitory) error) error {
+	return r.Repogitory.Transaction(ctx, func(repo cs.Repogitory) error {
+		return f(countingRepo{repo, r.counter})
+	})
+}
+
+type countingCalendarRepo struct {
+	cs.CalendarRepogitory
+	counter *queryCounter
+}
+
+func (r countingCalendarRepo) Find(ctx context.Context, id string) (cs.CalendarData, error) {
+	r.counter.inc()
+	return r.CalendarRepogitory.Find(ctx, id)
+}
+
+func (r countingCalendarRepo) FindByGroupID(ctx context.Context, groupID string) ([]cs.CalendarData, error) {
+	r.counter.inc()
+	return r.CalendarRepogitory.FindByGroupID(ctx, groupID)
+}
+
+func (r countingCalendarRepo) FindByOrgID(ctx context.Context, orgID string) ([]cs.CalendarData, error) {
+	r.counter.inc()
+	return r.CalendarRepogitory.FindByOrgID(ctx, orgID)
+}
+
+type countingPlanRepo struct {
+	cs.PlanRepogitory
+	counter *queryCounter
+}
+
+func (r countingPlanRepo) FindByCalendarID(ctx context.Context, calID string) ([]cs.PlanData, error) {
+	r.counter.inc()
+	return r.PlanRepogitory.FindByCalendarID(ctx, calID)
+}
+
+type countingUserRepo struct {
+	cs.UserRepogitory
+	counter *queryCounter
+}
+
+func (r countingUserRepo) Find(ctx context.Context, id string) (cs.UserData, error) {
+	r.counter.inc()
+	return r.UserRepogitory.Find(ctx, id)
+}
+
+type countingProfileRepo struct {
+	cs.ProfileRepogitory
+	counter *queryCounter
+}
+
+func (r countingProfileRepo) Find(ctx context.Context, userID string) (cs.ProfileData, error) {
+	r.counter.inc()
+	return r.ProfileRepogitory.Find(ctx, userID)
+}
+
+type countingGroupRepo struct {
+	cs.GroupRepogitory
+	counter *queryCounter
+}
+
+func (r countingGroupRepo) Find(ctx context.Context, id string) (cs.GroupData, error) {
+	r.counter.inc()
+	return r.GroupRepogitory.Find(ctx, id)
+}
+
+func TestService_BatchedQueries(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: ownerID})
+	shares := []string{}
+	for i := 0; i < 5; i++ {
+		id, _ := testutils.MakeSession(authRepo)
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+		shares = append(shares, id)
+	}
+	// Some users in shares have display names.
+	for _, id := range shares[:2] {
+		calRepo.Profile().Save(context.Background(), cs.ProfileData{UserID: id, DisplayName: "Bob"})
+	}
+	cals := []string{}
+	for i := 0; i < 5; i++ {
+		cal := makeCalendar(calRepo, ownerID, shares...)
+		makePlan(calRepo, ownerID, cal.ID)
+		cals = append(cals, cal.ID)
+	}
+	// A plan published from another calendar is found with the calendar.
+	makePlan(calRepo, ownerID, cals[0], cals[1:]...)
+	// Calendars of groups and organizations of the user are found together.
+	// Calendars of other users shared with the groups are accessed through them.
+	groupCals := []string{}
+	for i := 0; i < 3; i++ {
+		group := cs.GroupData{ID: uuid.New().String(), UserID: ownerID, Name: "group"}
+		calRepo.Group().Create(context.Background(), group)
+		for _, id := range append([]string{ownerID}, shares...) {
+			calRepo.Group().AddMember(context.Background(), group.ID, id)
+		}
+		cal := cs.CalendarData{
+			ID:          uuid.New().String(),
+			UserID:      shares[i],
+			Name:        "group calendar",
+			Color:       "blue",
+			Shares:      shares[i : i+2],
+			GroupShares: []string{group.ID},
+		}
+		calRepo.Calendar().Create(context.Background(), cal)
+		groupCals = append(groupCals, cal.ID)
+		org := cs.OrgData{ID: uuid.New().String(), Name: "org"}
+		calRepo.Org().Create(context.Background(), org)
+		calRepo.Member().Save(context.Background(), cs.MemberData{OrgID: org.ID, UserID: ownerID, Role: "member"})
+	}
+
+	counter := &queryCounter{}
+	r := testutils.NewCalendarRouter(authRepo, countingRepo{calRepo, counter})
+
+	t.Run("get calendars", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodGet, "/calendars", ownerSession, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+		}
+		// The user is found once to check registration. Display names of owners and users in
+		// shares are found at once.
+		if n := counter.reset(); n != 1 {
+			t.Errorf("calendars, plans or names are found one by one: %v queries", n)
+		}
+	})
+
+	t.Run("change calendar", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodPatch, "/calendars/"+cals[0], ownerSession, map[string]interface{}{
+			"name":   "renamed",
+			"color":  "red",
+			"shares": append([]string{ownerID}, shares...),
+		})
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		// The user and the changed calendar itself are found once.
+		if n := counter.reset(); n != 2 {
+			t.Errorf("users in shares are found one by one: %v queries", n)
+		}
+	})
+
+	t.Run("schedule", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodPost, "/plans", ownerSession, map[string]interface{}{
+			"calendar_id": cals[0],
+			"name":        "all day plan",
+			"color":       "red",
+			"shares":      append(cals, groupCals...),
+			"is_all_day":  true,
+			"begin":       time.Date(2020, 4, 1, 0, 0, 0, 0, time.Local).Unix(),
+			"end":         time.Date(2020, 4, 1, 0, 0, 0, 0, time.Local).Unix(),
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+		}
+		// The user and the calendar of the plan itself are found once.
+		// Groups sharing calendars in shares are found at once.
+		if n := counter.reset(); n != 2 {
+			t.Errorf("calendars or groups in shares are found one by one: %v queries", n)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/response"
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+)
+
+type RevisionContent struct {
+	ID          string          `json:"id"`
+	OperationID string          `json:"operation_id"`
+	Target      string          `json:"target"`
+	TargetID    string          `json:"target_id"`
+	CalendarID  string          `json:"calendar_id"`
+	UserID      string          `json:"user_id"`
+	UserName    string          `json:"user_name"`
+	Action      string          `json:"action"`
+	CreatedAt   int64           `json:"created_at"`
+	Changes     []ChangeContent `json:"changes"`
+}
+
+type ChangeContent struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// revisionsToContent converts revisions into contents with display names of the actors.
+func revisionsToContent(rl []model.Revision, names map[string]string) []RevisionContent {
+	revisions := make([]RevisionContent, len(rl))
+	for i, r := range rl {
+		changes := make([]ChangeContent, len(r.Changes))
+		for j, c := range r.Changes {
+			changes[j] = ChangeContent{
+				Field:  c.Field,
+				Before: c.Before,
+				After:  c.After,
+			}
+		}
+		revisions[i] = RevisionContent{
+			ID:          r.ID,
+			OperationID: r.OperationID,
+			Target:      string(r.Target),
+			TargetID:    r.TargetID,
+			CalendarID:  r.CalendarID,
+			UserID:      r.UserID,
+			UserName:    names[r.UserID],
+			Action:      string(r.Action),
+			CreatedAt:   r.CreatedAt.Unix(),
+			Changes:     changes,
+		}
+	}
+	return revisions
+}
+
+func revisionUserIDs(rl []model.Revision) []string {
+	ids := make([]string, len(rl))
+	for i, r := range rl {
+		ids[i] = r.UserID
+	}
+	return ids
+}
+
+func (e *planEndpoint) GetHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	rl, err := e.service.GetPlanHistory(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	names, err := e.service.GetDisplayNames(r.Context(), revisionUserIDs(rl))
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(revisionsToContent(rl, names))
+}
+
+func (e *calEndpoint) GetAuditHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	rl, err := e.service.GetCalendarAudit(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	names, err := e.service.GetDisplayNames(r.Context(), revisionUserIDs(rl))
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(revisionsToContent(rl, names))
+}
@@ -0,0 +1,200 @@
+package calendar_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	. "github.com/x-color/calendar/app/rest/calendar"
+	"github.com/x-color/calendar/app/rest/testutils"
+	cs "github.com/x-color/calendar/calendar/service"
+)
+
+func getRevisions(t *testing.T, r *mux.Router, path, sessionID string) []RevisionContent {
+	rec := testutils.Request(r, http.MethodGet, path, sessionID, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+	}
+	var revisions []RevisionContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &revisions); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+	return revisions
+}
+
+// changedFields returns the action and changed fields of each revision.
+func changedFields(revisions []RevisionContent) [][]string {
+	l := make([][]string, len(revisions))
+	for i, rev := range revisions {
+		l[i] = []string{rev.Action}
+		for _, c := range rev.Changes {
+			l[i] = append(l[i], c.Field)
+		}
+	}
+	return l
+}
+
+func TestNewPlanRouter_History(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	sharedID, sharedSession := testutils.MakeSession(authRepo)
+	otherID, otherSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, sharedID, otherID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	cal := makeCalendar(calRepo, ownerID, sharedID)
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	body := map[string]interface{}{
+		"calendar_id": cal.ID,
+		"name":        "meeting",
+		"color":       "red",
+		"private":     true,
+		"shares":      []interface{}{cal.ID},
+		"is_all_day":  true,
+		"begin":       time.Date(2020, 5, 1, 0, 0, 0, 0, time.Local).Unix(),
+		"end":         time.Date(2020, 5, 1, 0, 0, 0, 0, time.Local).Unix(),
+	}
+	rec := testutils.Request(r, http.MethodPost, "/plans", ownerSession, body)
+	var plan PlanContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+	body["name"] = "review"
+	body["begin"] = time.Date(2020, 5, 2, 0, 0, 0, 0, time.Local).Unix()
+	body["end"] = time.Date(2020, 5, 2, 0, 0, 0, 0, time.Local).Unix()
+	testutils.Request(r, http.MethodPatch, "/plans/"+plan.ID, ownerSession, body)
+
+	testcases := []struct {
+		name      string
+		sessionID string
+		planID    string
+		code      int
+	}{
+		{
+			name:      "plan does not exist",
+			sessionID: ownerSession,
+			planID:    uuid.New().String(),
+			code:      http.StatusNotFound,
+		},
+		{
+			name:      "user can not access plan",
+			sessionID: otherSession,
+			planID:    plan.ID,
+			code:      http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testutils.Request(r, http.MethodGet, "/plans/"+tc.planID+"/history", tc.sessionID, nil)
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+
+	t.Run("history for owner", func(t *testing.T) {
+		revisions := getRevisions(t, r, "/plans/"+plan.ID+"/history", ownerSession)
+		expected := [][]string{
+			{"create", "begin", "calendar_id", "color", "end", "is_all_day", "memo", "name", "private", "shares", "user_id"},
+			{"update", "begin", "end", "name"},
+		}
+		if d := cmp.Diff(expected, changedFields(revisions)); d != "" {
+			t.Fatalf("invalid revisions: \n%v", d)
+		}
+		if revisions[1].UserID != ownerID {
+			t.Errorf("actor: want %v but %v", ownerID, revisions[1].UserID)
+		}
+		for _, c := range revisions[1].Changes {
+			if c.Field == "name" && (c.Before != "meeting" || c.After != "review") {
+				t.Errorf("invalid change of name: %v", c)
+			}
+		}
+	})
+
+	t.Run("history of private plan for others", func(t *testing.T) {
+		revisions := getRevisions(t, r, "/plans/"+plan.ID+"/history", sharedSession)
+		expected := [][]string{
+			{"create", "begin", "calendar_id", "color", "end", "is_all_day", "memo", "name", "private", "shares", "user_id"},
+			{"update", "begin", "end"},
+		}
+		if d := cmp.Diff(expected, changedFields(revisions)); d != "" {
+			t.Errorf("invalid revisions: \n%v", d)
+		}
+	})
+
+	t.Run("history of plan in trash", func(t *testing.T) {
+		testutils.Request(r, http.MethodDelete, "/plans/"+plan.ID, ownerSession, map[string]interface{}{"calendar_id": cal.ID})
+		revisions := getRevisions(t, r, "/plans/"+plan.ID+"/history", ownerSession)
+		if len(revisions) != 3 || revisions[2].Action != "delete" {
+			t.Errorf("invalid revisions: %v", changedFields(revisions))
+		}
+	})
+}
+
+func TestNewCalendarRouter_Audit(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	sharedID, sharedSession := testutils.MakeSession(authRepo)
+	otherID, otherSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, sharedID, otherID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	rec := testutils.Request(r, http.MethodPost, "/calendars", ownerSession, map[string]interface{}{"name": "Team", "color": "red"})
+	var cal CalendarContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &cal); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+	testutils.Request(r, http.MethodPatch, "/calendars/"+cal.ID, ownerSession, map[string]interface{}{
+		"name":   "Team plans",
+		"color":  "red",
+		"shares": []interface{}{ownerID, sharedID},
+	})
+	testutils.Request(r, http.MethodPost, "/plans", sharedSession, map[string]interface{}{
+		"calendar_id": cal.ID,
+		"name":        "meeting",
+		"color":       "red",
+		"shares":      []interface{}{cal.ID},
+		"is_all_day":  true,
+		"begin":       time.Date(2020, 5, 1, 0, 0, 0, 0, time.Local).Unix(),
+		"end":         time.Date(2020, 5, 1, 0, 0, 0, 0, time.Local).Unix(),
+	})
+
+	rec = testutils.Request(r, http.MethodGet, "/calendars/"+cal.ID+"/audit", otherSession, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status code: want %v but %v", http.StatusForbidden, rec.Code)
+	}
+
+	revisions := getRevisions(t, r, "/calendars/"+cal.ID+"/audit", sharedSession)
+	expected := []struct {
+		target string
+		action string
+		userID string
+	}{
+		{"calendar", "create", ownerID},
+		{"calendar", "update", ownerID},
+		{"plan", "create", sharedID},
+	}
+	if len(revisions) != len(expected) {
+		t.Fatalf("invalid revisions: %v", changedFields(revisions))
+	}
+	for i, e := range expected {
+		rev := revisions[i]
+		if rev.Target != e.target || rev.Action != e.action || rev.UserID != e.userID || rev.CalendarID != cal.ID {
+			t.Errorf("revision %v: want %v but %v", i, e, rev)
+		}
+	}
+	if d := cmp.Diff([][]string{{"update", "name", "shares"}}, changedFields(revisions[1:2])); d != "" {
+		t.Errorf("invalid changes: \n%v", d)
+	}
+}
@@ -0,0 +1,85 @@
+package calendar_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/testutils"
+	cs "github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// slowRepo finds calendars until the request is cancelled.
+type slowRepo struct {
+	cs.Repogitory
+}
+
+func (r slowRepo) Calendar() cs.CalendarRepogitory {
+	return slowCalendarRepo{r.Repogitory.Calendar()}
+}
+
+func (r slowRepo) Transaction(ctx context.Context, f func(cs.Repogitory) error) error {
+	return r.Repogitory.Transaction(ctx, func(repo cs.Repogitory) error {
+		return f(slowRepo{repo})
+	})
+}
+
+type slowCalendarRepo struct {
+	cs.CalendarRepogitory
+}
+
+func (r slowCalendarRepo) Find(ctx context.Context, id string) (cs.CalendarData, error) {
+	<-ctx.Done()
+	return cs.CalendarData{}, cerror.NewQueryError(ctx, ctx.Err(), "failed to query")
+}
+
+func (r slowCalendarRepo) FindByUserID(ctx context.Context, userID string) ([]cs.CalendarData, error) {
+	<-ctx.Done()
+	return nil, cerror.NewQueryError(ctx, ctx.Err(), "failed to query")
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	userID, sessionID := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: userID})
+	cal := makeCalendar(calRepo, userID)
+
+	r := testutils.NewCalendarRouter(authRepo, slowRepo{calRepo})
+	r.Use(middlewares.TimeoutMiddleware(10 * time.Millisecond))
+
+	testcases := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+	}{
+		{
+			name:   "get calendars",
+			method: http.MethodGet,
+			path:   "/calendars",
+		},
+		{
+			name:   "change calendar",
+			method: http.MethodPatch,
+			path:   "/calendars/" + cal.ID,
+			body: map[string]interface{}{
+				"name":   "renamed",
+				"color":  "red",
+				"shares": []string{userID},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testutils.Request(r, tc.method, tc.path, sessionID, tc.body)
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Errorf("status code: want %v but %v", http.StatusServiceUnavailable, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package calendar_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/x-color/calendar/app/rest/testutils"
+	cs "github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// failingRepo fails to record revisions. Operations fail after their changes are saved.
+type failingRepo struct {
+	cs.Repogitory
+}
+
+func (r failingRepo) Revision() cs.RevisionRepogitory {
+	return failingRevisionRepo{r.Repogitory.Revision()}
+}
+
+func (r failingRepo) Transaction(ctx context.Context, f func(cs.Repogitory) error) error {
+	return r.Repogitory.Transaction(ctx, func(repo cs.Repogitory) error {
+		return f(failingRepo{repo})
+	})
+}
+
+type failingRevisionRepo struct {
+	cs.RevisionRepogitory
+}
+
+func (r failingRevisionRepo) Create(ctx context.Context, rev cs.RevisionData) error {
+	return cerror.NewInternalError(
+		nil,
+		"failed to save revision",
+	)
+}
+
+func TestService_Transaction(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	sharedID, _ := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, sharedID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	cal := makeCalendar(calRepo, ownerID, sharedID)
+	plan := makePlan(calRepo, ownerID, cal.ID)
+	r := testutils.NewCalendarRouter(authRepo, failingRepo{calRepo})
+
+	t.Run("change calendar", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodPatch, "/calendars/"+cal.ID, ownerSession, map[string]interface{}{
+			"name":   "renamed",
+			"color":  "red",
+			"shares": []interface{}{ownerID},
+		})
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("status code: want %v but %v", http.StatusInternalServerError, rec.Code)
+		}
+		c, _ := calRepo.Calendar().Find(context.Background(), cal.ID)
+		if c.Name != cal.Name || len(c.Shares) != 2 {
+			t.Errorf("calendar is changed: %v", c)
+		}
+	})
+
+	t.Run("remove calendar", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID, ownerSession, nil)
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("status code: want %v but %v", http.StatusInternalServerError, rec.Code)
+		}
+		if _, err := calRepo.Calendar().Find(context.Background(), cal.ID); err != nil {
+			t.Errorf("calendar is removed: %v", err)
+		}
+	})
+
+	t.Run("unschedule", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodDelete, "/plans/"+plan.ID, ownerSession, map[string]interface{}{"calendar_id": cal.ID})
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("status code: want %v but %v", http.StatusInternalServerError, rec.Code)
+		}
+		if _, err := calRepo.Plan().Find(context.Background(), plan.ID); err != nil {
+			t.Errorf("plan is removed: %v", err)
+		}
+	})
+
+	t.Run("make calendar", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodPost, "/calendars", ownerSession, map[string]interface{}{"name": "New", "color": "red"})
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("status code: want %v but %v", http.StatusInternalServerError, rec.Code)
+		}
+		if cals, _ := calRepo.Calendar().FindByUserID(context.Background(), ownerID); len(cals) != 1 {
+			t.Errorf("calendar is made: %v", cals)
+		}
+	})
+}
+
+func TestRepogitory_TransactionRollback(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	inTx, _ := testutils.MakeSession(authRepo)
+	outside, _ := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	ctx := context.Background()
+
+	// The user outside the transaction is created concurrently. It may wait until the
+	// transaction ends, so that it is waited for only a while in the transaction.
+	var outsideErr error
+	done := make(chan struct{})
+	rollback := errors.New("rollback")
+	err := calRepo.Transaction(ctx, func(repo cs.Repogitory) error {
+		if err := repo.User().Create(ctx, cs.UserData{ID: inTx}); err != nil {
+			return err
+		}
+		go func() {
+			outsideErr = calRepo.User().Create(ctx, cs.UserData{ID: outside})
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(100 * time.Millisecond):
+		}
+		return rollback
+	})
+	if err != rollback {
+		t.Fatalf("want %v, but got %v", rollback, err)
+	}
+	<-done
+
+	if outsideErr != nil {
+		t.Fatalf("failed to create user outside transaction: %v", outsideErr)
+	}
+	if _, err := calRepo.User().Find(ctx, outside); err != nil {
+		t.Errorf("user created outside transaction is lost: %v", err)
+	}
+	if _, err := calRepo.User().Find(ctx, inTx); !errors.Is(err, cerror.ErrNotFound) {
+		t.Errorf("user created in rolled back transaction: want %v, but got %v", cerror.ErrNotFound, err)
+	}
+}
@@ -0,0 +1,103 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/response"
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+)
+
+type TransferContent struct {
+	CalendarID    string `json:"calendar_id"`
+	FromUserID    string `json:"from_user_id"`
+	FromUserName  string `json:"from_user_name"`
+	ToUserID      string `json:"to_user_id"`
+	ToUserName    string `json:"to_user_name"`
+	TransferPlans bool   `json:"transfer_plans"`
+}
+
+func (e *calEndpoint) GetTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	tl, err := e.service.GetTransfers(r.Context(), userID)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	ids := []string{}
+	for _, t := range tl {
+		ids = append(ids, t.FromUserID, t.ToUserID)
+	}
+	names, err := e.service.GetDisplayNames(r.Context(), ids)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	transfers := make([]TransferContent, len(tl))
+	for i, t := range tl {
+		transfers[i] = TransferContent{
+			CalendarID:    t.CalendarID,
+			FromUserID:    t.FromUserID,
+			FromUserName:  names[t.FromUserID],
+			ToUserID:      t.ToUserID,
+			ToUserName:    names[t.ToUserID],
+			TransferPlans: t.TransferPlans,
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfers)
+}
+
+func (e *calEndpoint) RequestTransferHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	req := TransferContent{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	transfer := model.Transfer{
+		CalendarID:    vars["id"],
+		ToUserID:      req.ToUserID,
+		TransferPlans: req.TransferPlans,
+	}
+
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.RequestTransfer(r.Context(), userID, transfer)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *calEndpoint) AcceptTransferHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.AcceptTransfer(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setOperationID(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *calEndpoint) CancelTransferHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.CancelTransfer(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
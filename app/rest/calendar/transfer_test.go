@@ -0,0 +1,240 @@
+package calendar_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	. "github.com/x-color/calendar/app/rest/calendar"
+	"github.com/x-color/calendar/app/rest/testutils"
+	cs "github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+func TestNewCalendarRouter_RequestTransfer(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	sharedID, sharedSession := testutils.MakeSession(authRepo)
+	otherID, _ := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, sharedID, otherID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	cal := makeCalendar(calRepo, ownerID, sharedID)
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	testcases := []struct {
+		name      string
+		sessionID string
+		calID     string
+		toUserID  string
+		code      int
+	}{
+		{
+			name:      "calendar does not exist",
+			sessionID: ownerSession,
+			calID:     uuid.New().String(),
+			toUserID:  sharedID,
+			code:      http.StatusNotFound,
+		},
+		{
+			name:      "not owner",
+			sessionID: sharedSession,
+			calID:     cal.ID,
+			toUserID:  sharedID,
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "recipient is not in shares",
+			sessionID: ownerSession,
+			calID:     cal.ID,
+			toUserID:  otherID,
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "recipient is owner",
+			sessionID: ownerSession,
+			calID:     cal.ID,
+			toUserID:  ownerID,
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "request transfer",
+			sessionID: ownerSession,
+			calID:     cal.ID,
+			toUserID:  sharedID,
+			code:      http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := map[string]interface{}{"to_user_id": tc.toUserID}
+			rec := testutils.Request(r, http.MethodPost, "/calendars/"+tc.calID+"/transfer", tc.sessionID, body)
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+
+	t.Run("get transfers", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodGet, "/calendars/transfers", sharedSession, nil)
+		var transfers []TransferContent
+		if err := json.Unmarshal(rec.Body.Bytes(), &transfers); err != nil {
+			t.Fatalf("invalid response body: %v", rec.Body.String())
+		}
+		expected := []TransferContent{
+			{
+				CalendarID:   cal.ID,
+				FromUserID:   ownerID,
+				FromUserName: ownerID,
+				ToUserID:     sharedID,
+				ToUserName:   sharedID,
+			},
+		}
+		if d := cmp.Diff(expected, transfers); d != "" {
+			t.Errorf("invalid transfers: \n%v", d)
+		}
+	})
+
+	t.Run("decline transfer", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID+"/transfer", sharedSession, nil)
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		rec = testutils.Request(r, http.MethodPost, "/calendars/"+cal.ID+"/transfer/accept", sharedSession, nil)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("accept declined transfer: want %v but %v", http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestNewCalendarRouter_AcceptTransfer(t *testing.T) {
+	testcases := []struct {
+		name          string
+		transferPlans bool
+	}{
+		{
+			name:          "keep plans",
+			transferPlans: false,
+		},
+		{
+			name:          "transfer plans",
+			transferPlans: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			authRepo := testutils.NewAuthRepo()
+			ownerID, ownerSession := testutils.MakeSession(authRepo)
+			sharedID, sharedSession := testutils.MakeSession(authRepo)
+			otherID, otherSession := testutils.MakeSession(authRepo)
+			calRepo := testutils.NewCalRepo()
+			for _, id := range []string{ownerID, sharedID, otherID} {
+				calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+			}
+			cal := makeCalendar(calRepo, ownerID, sharedID)
+			ownerCal := makeCalendar(calRepo, ownerID)
+			plan := makePlan(calRepo, ownerID, cal.ID)
+			publishedPlan := makePlan(calRepo, ownerID, ownerCal.ID, cal.ID)
+			r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+			body := map[string]interface{}{"to_user_id": sharedID, "transfer_plans": tc.transferPlans}
+			testutils.Request(r, http.MethodPost, "/calendars/"+cal.ID+"/transfer", ownerSession, body)
+
+			rec := testutils.Request(r, http.MethodPost, "/calendars/"+cal.ID+"/transfer/accept", otherSession, nil)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("accept by other user: want %v but %v", http.StatusForbidden, rec.Code)
+			}
+
+			rec = testutils.Request(r, http.MethodPost, "/calendars/"+cal.ID+"/transfer/accept", sharedSession, nil)
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+			}
+
+			c, _ := calRepo.Calendar().Find(context.Background(), cal.ID)
+			if c.UserID != sharedID {
+				t.Errorf("owner is not changed: %v", c.UserID)
+			}
+
+			expectedPlanOwner := ownerID
+			if tc.transferPlans {
+				expectedPlanOwner = sharedID
+			}
+			p, _ := calRepo.Plan().Find(context.Background(), plan.ID)
+			if p.UserID != expectedPlanOwner {
+				t.Errorf("owner of plan: want %v but %v", expectedPlanOwner, p.UserID)
+			}
+			p, _ = calRepo.Plan().Find(context.Background(), publishedPlan.ID)
+			if p.UserID != ownerID {
+				t.Errorf("owner of plan published from other calendar is changed: %v", p.UserID)
+			}
+
+			// Old owner is no longer permitted to remove the calendar but can leave it.
+			rec = testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID, ownerSession, nil)
+			if rec.Code != http.StatusNoContent {
+				t.Errorf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+			}
+			if _, err := calRepo.Calendar().Find(context.Background(), cal.ID); err != nil {
+				t.Errorf("transferred calendar is removed by old owner")
+			}
+		})
+	}
+}
+
+func TestNewCalendarRouter_AcceptInvalidTransfer(t *testing.T) {
+	testcases := []struct {
+		name   string
+		change func(calRepo cs.Repogitory, cal cs.CalendarData)
+		code   int
+	}{
+		{
+			name: "recipient is removed from shares",
+			change: func(calRepo cs.Repogitory, cal cs.CalendarData) {
+				cal.Shares = []string{cal.UserID}
+				calRepo.Calendar().Update(context.Background(), cal)
+			},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "calendar is removed",
+			change: func(calRepo cs.Repogitory, cal cs.CalendarData) {
+				calRepo.Calendar().Delete(context.Background(), cal.ID)
+			},
+			code: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			authRepo := testutils.NewAuthRepo()
+			ownerID, ownerSession := testutils.MakeSession(authRepo)
+			sharedID, sharedSession := testutils.MakeSession(authRepo)
+			calRepo := testutils.NewCalRepo()
+			for _, id := range []string{ownerID, sharedID} {
+				calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+			}
+			cal := makeCalendar(calRepo, ownerID, sharedID)
+			r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+			body := map[string]interface{}{"to_user_id": sharedID, "transfer_plans": false}
+			testutils.Request(r, http.MethodPost, "/calendars/"+cal.ID+"/transfer", ownerSession, body)
+
+			calData, _ := calRepo.Calendar().Find(context.Background(), cal.ID)
+			tc.change(calRepo, calData)
+
+			rec := testutils.Request(r, http.MethodPost, "/calendars/"+cal.ID+"/transfer/accept", sharedSession, nil)
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+			if _, err := calRepo.Transfer().Find(context.Background(), cal.ID); !errors.Is(err, cerror.ErrNotFound) {
+				t.Errorf("invalid transfer is not deleted: %v", err)
+			}
+		})
+	}
+}
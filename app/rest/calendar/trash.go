@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
+	as "github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/calendar/service"
+	cs "github.com/x-color/calendar/calendar/service"
+	cctx "github.com/x-color/calendar/model/ctx"
+)
+
+type TrashedCalendarContent struct {
+	CalendarContent
+	DeletedAt int64 `json:"deleted_at"`
+}
+
+type TrashedPlanContent struct {
+	PlanContent
+	DeletedAt int64 `json:"deleted_at"`
+}
+
+type TrashContent struct {
+	Calendars []TrashedCalendarContent `json:"calendars"`
+	Plans     []TrashedPlanContent     `json:"plans"`
+}
+
+type trashEndpoint struct {
+	service service.Service
+}
+
+func (e *trashEndpoint) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	trash, err := e.service.GetTrash(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ids := calendarUserIDs(trash.Calendars)
+	for _, p := range trash.Plans {
+		ids = append(ids, p.UserID)
+	}
+	names, err := e.service.GetDisplayNames(r.Context(), ids)
+	if err != nil {
+		response.WriteServerError(w, err)
+		return
+	}
+
+	content := TrashContent{
+		Calendars: make([]TrashedCalendarContent, len(trash.Calendars)),
+		Plans:     make([]TrashedPlanContent, len(trash.Plans)),
+	}
+	for i, c := range trash.Calendars {
+		content.Calendars[i] = TrashedCalendarContent{
+			CalendarContent: calModelToContent(c, names),
+			DeletedAt:       c.DeletedAt.Unix(),
+		}
+	}
+	for i, p := range trash.Plans {
+		content.Plans[i] = TrashedPlanContent{
+			PlanContent: planModelToContent(p, names),
+			DeletedAt:   p.DeletedAt.Unix(),
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(content)
+}
+
+func (e *trashEndpoint) RestoreCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.RestoreCalendar(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setOperationID(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *trashEndpoint) RestorePlanHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.RestorePlan(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setOperationID(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func NewTrashRouter(r *mux.Router, calService cs.Service, authService as.Service) {
+	e := trashEndpoint{calService}
+	r.Use(middlewares.ResponseHeaderMiddleware)
+	r.Use(middlewares.AuthorizationMiddleware(authService))
+	r.Use(userCheckerMiddleware(calService))
+	r.HandleFunc("", e.GetTrashHandler).Methods(http.MethodGet)
+	r.HandleFunc("/calendars/{id}/restore", e.RestoreCalendarHandler).Methods(http.MethodPost)
+	r.HandleFunc("/plans/{id}/restore", e.RestorePlanHandler).Methods(http.MethodPost)
+}
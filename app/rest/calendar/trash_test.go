@@ -0,0 +1,183 @@
+package calendar_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	. "github.com/x-color/calendar/app/rest/calendar"
+	"github.com/x-color/calendar/app/rest/testutils"
+	cs "github.com/x-color/calendar/calendar/service"
+	cctx "github.com/x-color/calendar/model/ctx"
+)
+
+func getTrash(t *testing.T, r *mux.Router, sessionID string) TrashContent {
+	rec := testutils.Request(r, http.MethodGet, "/trash", sessionID, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code: want %v but %v", http.StatusOK, rec.Code)
+	}
+	var trash TrashContent
+	if err := json.Unmarshal(rec.Body.Bytes(), &trash); err != nil {
+		t.Fatalf("invalid response body: %v", rec.Body.String())
+	}
+	return trash
+}
+
+func TestNewTrashRouter_Calendar(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	sharedID, sharedSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, sharedID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	cal := makeCalendar(calRepo, ownerID, sharedID)
+	plan := makePlan(calRepo, ownerID, cal.ID)
+	sharedCal := makeCalendar(calRepo, sharedID)
+	publishedPlan := makePlan(calRepo, ownerID, cal.ID, sharedCal.ID)
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	// visiblePlans returns IDs of plans the shared user sees in calendars.
+	visiblePlans := func() map[string]bool {
+		rec := testutils.Request(r, http.MethodGet, "/calendars", sharedSession, nil)
+		var cals []CalendarContent
+		json.Unmarshal(rec.Body.Bytes(), &cals)
+		ids := map[string]bool{}
+		for _, c := range cals {
+			for _, p := range c.Plans {
+				ids[p.ID] = true
+			}
+		}
+		return ids
+	}
+
+	rec := testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID, ownerSession, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+	}
+	if plans := visiblePlans(); plans[plan.ID] || plans[publishedPlan.ID] {
+		t.Errorf("plans of calendar in trash are visible: %v", plans)
+	}
+
+	trash := getTrash(t, r, ownerSession)
+	if len(trash.Calendars) != 1 || trash.Calendars[0].ID != cal.ID || trash.Calendars[0].DeletedAt == 0 {
+		t.Errorf("invalid calendars in trash: %v", trash.Calendars)
+	}
+	if trash := getTrash(t, r, sharedSession); len(trash.Calendars) != 0 {
+		t.Errorf("calendar of others is in trash: %v", trash.Calendars)
+	}
+
+	testcases := []struct {
+		name      string
+		sessionID string
+		calID     string
+		code      int
+	}{
+		{
+			name:      "calendar is not in trash",
+			sessionID: ownerSession,
+			calID:     sharedCal.ID,
+			code:      http.StatusNotFound,
+		},
+		{
+			name:      "not owner",
+			sessionID: sharedSession,
+			calID:     cal.ID,
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "restore calendar",
+			sessionID: ownerSession,
+			calID:     cal.ID,
+			code:      http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testutils.Request(r, http.MethodPost, "/trash/calendars/"+tc.calID+"/restore", tc.sessionID, nil)
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+		})
+	}
+
+	if plans := visiblePlans(); !plans[plan.ID] || !plans[publishedPlan.ID] {
+		t.Errorf("plans of restored calendar are not visible: %v", plans)
+	}
+}
+
+func TestNewTrashRouter_Plan(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	sharedID, sharedSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, sharedID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	cal := makeCalendar(calRepo, ownerID, sharedID)
+	plan := makePlan(calRepo, ownerID, cal.ID)
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	rec := testutils.Request(r, http.MethodDelete, "/plans/"+plan.ID, ownerSession, map[string]interface{}{"calendar_id": cal.ID})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+	}
+	if _, err := calRepo.Plan().Find(context.Background(), plan.ID); err == nil {
+		t.Errorf("plan in trash is found")
+	}
+
+	trash := getTrash(t, r, ownerSession)
+	if len(trash.Plans) != 1 || trash.Plans[0].ID != plan.ID || trash.Plans[0].DeletedAt == 0 {
+		t.Errorf("invalid plans in trash: %v", trash.Plans)
+	}
+
+	rec = testutils.Request(r, http.MethodPost, "/trash/plans/"+plan.ID+"/restore", sharedSession, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("restore by other user: want %v but %v", http.StatusForbidden, rec.Code)
+	}
+
+	// The plan can not be restored while its calendar is in the trash.
+	testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID, ownerSession, nil)
+	rec = testutils.Request(r, http.MethodPost, "/trash/plans/"+plan.ID+"/restore", ownerSession, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("restore plan in calendar in trash: want %v but %v", http.StatusBadRequest, rec.Code)
+	}
+
+	testutils.Request(r, http.MethodPost, "/trash/calendars/"+cal.ID+"/restore", ownerSession, nil)
+	rec = testutils.Request(r, http.MethodPost, "/trash/plans/"+plan.ID+"/restore", ownerSession, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+	}
+	if _, err := calRepo.Plan().Find(context.Background(), plan.ID); err != nil {
+		t.Errorf("restored plan is not found: %v", err)
+	}
+}
+
+func TestService_PurgeTrash(t *testing.T) {
+	ownerID, _ := testutils.MakeSession(testutils.NewAuthRepo())
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: ownerID})
+	oldCal := makeCalendar(calRepo, ownerID)
+	newCal := makeCalendar(calRepo, ownerID)
+	now := time.Now()
+	calRepo.Calendar().Trash(context.Background(), oldCal.ID, now.Add(-48*time.Hour).Unix())
+	calRepo.Calendar().Trash(context.Background(), newCal.ID, now.Unix())
+
+	s := cs.NewService(calRepo, testutils.NewLogger())
+	ctx := context.WithValue(context.Background(), cctx.ReqIDKey, uuid.New().String())
+	if err := s.PurgeTrash(ctx, now.Add(-24*time.Hour)); err != nil {
+		t.Fatalf("failed to purge trash: %v", err)
+	}
+
+	if _, err := calRepo.Calendar().FindTrashed(context.Background(), oldCal.ID); err == nil {
+		t.Errorf("calendar older than retention is not purged")
+	}
+	if _, err := calRepo.Calendar().FindTrashed(context.Background(), newCal.ID); err != nil {
+		t.Errorf("calendar in retention is purged: %v", err)
+	}
+}
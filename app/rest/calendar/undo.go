@@ -0,0 +1,42 @@
+package calendar
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	as "github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/calendar/service"
+	cs "github.com/x-color/calendar/calendar/service"
+	cctx "github.com/x-color/calendar/model/ctx"
+)
+
+// setOperationID tells the client ID of the operation to undo the request. It is the request ID.
+func setOperationID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Operation-ID", r.Context().Value(cctx.ReqIDKey).(string))
+}
+
+type undoEndpoint struct {
+	service service.Service
+}
+
+func (e *undoEndpoint) UndoHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := r.Context().Value(cctx.UserIDKey).(string)
+	err := e.service.Undo(r.Context(), userID, vars["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setOperationID(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func NewUndoRouter(r *mux.Router, calService cs.Service, authService as.Service) {
+	e := undoEndpoint{calService}
+	r.Use(middlewares.ResponseHeaderMiddleware)
+	r.Use(middlewares.AuthorizationMiddleware(authService))
+	r.Use(userCheckerMiddleware(calService))
+	r.HandleFunc("/{id}", e.UndoHandler).Methods(http.MethodPost)
+}
@@ -0,0 +1,267 @@
+package calendar_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	. "github.com/x-color/calendar/app/rest/calendar"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/testutils"
+	as "github.com/x-color/calendar/auth/service"
+	cs "github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/clock"
+)
+
+func TestNewUndoRouter_Plan(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	sharedID, sharedSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, sharedID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	cal := makeCalendar(calRepo, ownerID, sharedID)
+	otherCal := makeCalendar(calRepo, ownerID)
+	plan := makePlan(calRepo, ownerID, cal.ID)
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	reschedule := func(name string) string {
+		rec := testutils.Request(r, http.MethodPatch, "/plans/"+plan.ID, ownerSession, map[string]interface{}{
+			"calendar_id": cal.ID,
+			"name":        name,
+			"color":       "red",
+			"shares":      []interface{}{cal.ID, otherCal.ID},
+			"is_all_day":  true,
+			"begin":       plan.Period.Begin.Unix(),
+			"end":         plan.Period.End.Unix(),
+		})
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		return rec.Header().Get("X-Operation-ID")
+	}
+	current := func() cs.PlanData {
+		p, err := calRepo.Plan().Find(context.Background(), plan.ID)
+		if err != nil {
+			t.Fatalf("plan is not found: %v", err)
+		}
+		return p
+	}
+
+	opID := reschedule("renamed")
+	if opID == "" {
+		t.Fatalf("operation id is not returned")
+	}
+
+	testcases := []struct {
+		name      string
+		sessionID string
+		opID      string
+		code      int
+	}{
+		{
+			name:      "operation does not exist",
+			sessionID: ownerSession,
+			opID:      uuid.New().String(),
+			code:      http.StatusNotFound,
+		},
+		{
+			name:      "not operator",
+			sessionID: sharedSession,
+			opID:      opID,
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "undo",
+			sessionID: ownerSession,
+			opID:      opID,
+			code:      http.StatusNoContent,
+		},
+		{
+			name:      "already undone",
+			sessionID: ownerSession,
+			opID:      opID,
+			code:      http.StatusBadRequest,
+		},
+	}
+
+	var undoOpID string
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testutils.Request(r, http.MethodPost, "/undo/"+tc.opID, tc.sessionID, nil)
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+			if rec.Code == http.StatusNoContent {
+				undoOpID = rec.Header().Get("X-Operation-ID")
+			}
+		})
+	}
+
+	p := current()
+	if p.Name != plan.Name || !cmp.Equal(p.Shares, []string{cal.ID}) {
+		t.Errorf("plan is not reverted: %v", p)
+	}
+
+	t.Run("undo undo", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodPost, "/undo/"+undoOpID, ownerSession, nil)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		if p := current(); p.Name != "renamed" {
+			t.Errorf("plan is not changed again: %v", p)
+		}
+	})
+
+	t.Run("plan is changed after operation", func(t *testing.T) {
+		opID := reschedule("first")
+		reschedule("second")
+		rec := testutils.Request(r, http.MethodPost, "/undo/"+opID, ownerSession, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status code: want %v but %v", http.StatusBadRequest, rec.Code)
+		}
+		if p := current(); p.Name != "second" {
+			t.Errorf("plan is changed: %v", p)
+		}
+	})
+
+	t.Run("undo unschedule", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodDelete, "/plans/"+plan.ID, ownerSession, map[string]interface{}{"calendar_id": cal.ID})
+		testutils.Request(r, http.MethodPost, "/undo/"+rec.Header().Get("X-Operation-ID"), ownerSession, nil)
+		if p := current(); p.Name != "second" {
+			t.Errorf("plan is not restored: %v", p)
+		}
+	})
+
+	t.Run("operation is too old", func(t *testing.T) {
+		opID := uuid.New().String()
+		calRepo.Revision().Create(context.Background(), cs.RevisionData{
+			ID:          uuid.New().String(),
+			OperationID: opID,
+			Target:      "plan",
+			TargetID:    plan.ID,
+			CalendarID:  cal.ID,
+			UserID:      ownerID,
+			Action:      "create",
+			CreatedAt:   time.Now().Add(-time.Hour).Unix(),
+			After:       `{}`,
+		})
+		rec := testutils.Request(r, http.MethodPost, "/undo/"+opID, ownerSession, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status code: want %v but %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestNewUndoRouter_Calendar(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	sharedID, _ := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	for _, id := range []string{ownerID, sharedID} {
+		calRepo.User().Create(context.Background(), cs.UserData{ID: id})
+	}
+	cal := makeCalendar(calRepo, ownerID, sharedID)
+	r := testutils.NewCalendarRouter(authRepo, calRepo)
+
+	t.Run("undo change", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodPatch, "/calendars/"+cal.ID, ownerSession, map[string]interface{}{
+			"name":   "renamed",
+			"color":  "red",
+			"shares": []interface{}{ownerID},
+		})
+		rec = testutils.Request(r, http.MethodPost, "/undo/"+rec.Header().Get("X-Operation-ID"), ownerSession, nil)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		c, _ := calRepo.Calendar().Find(context.Background(), cal.ID)
+		if c.Name != cal.Name || len(c.Shares) != 2 {
+			t.Errorf("calendar is not reverted: %v", c)
+		}
+	})
+
+	t.Run("undo remove", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID, ownerSession, nil)
+		rec = testutils.Request(r, http.MethodPost, "/undo/"+rec.Header().Get("X-Operation-ID"), ownerSession, nil)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		if _, err := calRepo.Calendar().Find(context.Background(), cal.ID); err != nil {
+			t.Errorf("calendar is not restored: %v", err)
+		}
+	})
+
+	t.Run("undo make", func(t *testing.T) {
+		rec := testutils.Request(r, http.MethodPost, "/calendars", ownerSession, map[string]interface{}{"name": "New", "color": "red"})
+		opID := rec.Header().Get("X-Operation-ID")
+		rec = testutils.Request(r, http.MethodPost, "/undo/"+opID, ownerSession, nil)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+		}
+		cals, _ := calRepo.Calendar().FindByUserID(context.Background(), ownerID)
+		if len(cals) != 1 {
+			t.Errorf("made calendar is not removed: %v", cals)
+		}
+	})
+}
+
+func TestNewUndoRouter_Window(t *testing.T) {
+	authRepo := testutils.NewAuthRepo()
+	ownerID, ownerSession := testutils.MakeSession(authRepo)
+	calRepo := testutils.NewCalRepo()
+	calRepo.User().Create(context.Background(), cs.UserData{ID: ownerID})
+	cal := makeCalendar(calRepo, ownerID)
+
+	now := clock.NewFake(time.Now())
+	l := testutils.NewLogger()
+	authService := as.NewService(authRepo, l)
+	calendarService := cs.NewService(calRepo, l)
+	calendarService.SetClock(now)
+	r := mux.NewRouter()
+	r.Use(middlewares.ReqIDMiddleware)
+	NewUndoRouter(r.PathPrefix("/undo").Subrouter(), calendarService, authService)
+	NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calendarService, authService)
+
+	testcases := []struct {
+		name    string
+		elapsed time.Duration
+		code    int
+	}{
+		{
+			name:    "within window",
+			elapsed: 4 * time.Minute,
+			code:    http.StatusNoContent,
+		},
+		{
+			name:    "after window",
+			elapsed: 6 * time.Minute,
+			code:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testutils.Request(r, http.MethodDelete, "/calendars/"+cal.ID, ownerSession, nil)
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("status code: want %v but %v", http.StatusNoContent, rec.Code)
+			}
+			trashed, err := calRepo.Calendar().FindTrashed(context.Background(), cal.ID)
+			if err != nil || trashed.DeletedAt != now.Now().Unix() {
+				t.Errorf("calendar is not trashed at %v: %v(%v)", now.Now().Unix(), trashed.DeletedAt, err)
+			}
+
+			now.Advance(tc.elapsed)
+			rec = testutils.Request(r, http.MethodPost, "/undo/"+rec.Header().Get("X-Operation-ID"), ownerSession, nil)
+			if rec.Code != tc.code {
+				t.Errorf("status code: want %v but %v", tc.code, rec.Code)
+			}
+			// The calendar is restored for the next case even if it can not be undone.
+			calRepo.Calendar().Restore(context.Background(), cal.ID)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/app/rest/response"
 	as "github.com/x-color/calendar/auth/service"
 	"github.com/x-color/calendar/calendar/service"
 	cs "github.com/x-color/calendar/calendar/service"
@@ -19,7 +20,7 @@ func (e *userEndpoint) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(cctx.UserIDKey).(string)
 	_, err := e.service.RegisterUser(r.Context(), userID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		response.WriteServerError(w, err)
 		return
 	}
 
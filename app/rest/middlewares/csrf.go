@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+const (
+	CSRFCookieName = "XSRF-TOKEN"
+	CSRFHeaderName = "X-XSRF-TOKEN"
+)
+
+// CSRF protects cookie-authenticated endpoints from cross-site request forgery.
+// Tokens are HMAC of session IDs. So attackers able to set cookies, such as other
+// subdomains, can not make valid tokens for sessions of victims.
+type CSRF struct {
+	key    []byte
+	secure bool
+}
+
+func NewCSRF(key []byte, secure bool) CSRF {
+	return CSRF{
+		key:    key,
+		secure: secure,
+	}
+}
+
+func (c CSRF) Token(sessionID string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetCookie sets token for the session into the cookie. The cookie is readable by
+// scripts to send it back in the header.
+func (c CSRF) SetCookie(w http.ResponseWriter, sessionID string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    c.Token(sessionID),
+		Expires:  expires,
+		Path:     "/",
+		Secure:   c.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// Middleware requires the token in the header for state-changing requests with
+// the session cookie. The cookie of the token is reissued when it is not valid for
+// the session, such as after the key is changed. So users are not locked out and
+// the next request succeeds.
+func (c CSRF) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session_id")
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		want := c.Token(cookie.Value)
+		if token, err := r.Cookie(CSRFCookieName); err != nil || !hmac.Equal([]byte(token.Value), []byte(want)) {
+			// Expiry of the session is unknown here. So the cookie lasts until the browser is closed.
+			c.SetCookie(w, cookie.Value, time.Time{})
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := r.Header.Get(CSRFHeaderName)
+		if !hmac.Equal([]byte(token), []byte(want)) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,13 +2,16 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/response"
 	as "github.com/x-color/calendar/auth/service"
 	"github.com/x-color/calendar/logging"
 	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
 )
 
 func ReqIDMiddleware(next http.Handler) http.Handler {
@@ -46,7 +49,10 @@ func AuthorizationMiddleware(service as.Service) mux.MiddlewareFunc {
 			}
 
 			userID, err := service.Authorize(r.Context(), cookie.Value)
-			if err != nil {
+			if errors.Is(err, cerror.ErrTimeout) {
+				response.WriteServerError(w, err)
+				return
+			} else if err != nil {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
@@ -56,3 +62,21 @@ func AuthorizationMiddleware(service as.Service) mux.MiddlewareFunc {
 		})
 	}
 }
+
+// AdminMiddleware allows only admins. It must be used after AuthorizationMiddleware.
+func AdminMiddleware(service as.Service) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Context().Value(cctx.UserIDKey).(string)
+			admin, err := service.IsAdmin(r.Context(), userID)
+			if errors.Is(err, cerror.ErrNotFound) || (err == nil && !admin) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			} else if err != nil {
+				response.WriteServerError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
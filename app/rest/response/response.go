@@ -0,0 +1,19 @@
+// Package response writes responses shared by endpoints of APIs.
+package response
+
+import (
+	"errors"
+	"net/http"
+
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// WriteServerError writes the status code for the error the endpoint does not handle.
+// Requests whose deadlines are exceeded are service unavailable, and others are internal server errors.
+func WriteServerError(w http.ResponseWriter, err error) {
+	if errors.Is(err, cerror.ErrTimeout) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
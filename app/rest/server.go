@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/x-color/calendar/app/rest/admin"
 	ase "github.com/x-color/calendar/app/rest/auth"
 	cse "github.com/x-color/calendar/app/rest/calendar"
 	"github.com/x-color/calendar/app/rest/middlewares"
@@ -14,21 +16,31 @@ import (
 	"github.com/x-color/calendar/logging"
 )
 
-func StartServer(authService as.Service, calService cs.Service, l logging.Logger, port string) {
-	r := newRouter(authService, calService, l)
-	http.ListenAndServe(":"+port, r)
+// Options are settings of the server.
+type Options struct {
+	Port string
+	// RequestTimeout is the deadline of API requests. Zero means no deadline.
+	RequestTimeout time.Duration
+	Auth           ase.Options
 }
 
-func newRouter(authService as.Service, calService cs.Service, l logging.Logger) *mux.Router {
+func StartServer(authService as.Service, calService cs.Service, csrf middlewares.CSRF, l logging.Logger, opts Options) error {
+	r := newRouter(authService, calService, csrf, l, opts)
+	return http.ListenAndServe(":"+opts.Port, r)
+}
+
+func newRouter(authService as.Service, calService cs.Service, csrf middlewares.CSRF, l logging.Logger, opts Options) *mux.Router {
 	r := mux.NewRouter()
 	r.NotFoundHandler = http.NotFoundHandler()
 	r.Use(middlewares.ReqIDMiddleware)
 	r.Use(middlewares.LoggingMiddleware(l))
 
 	apiRouter := r.PathPrefix("/api").Subrouter()
+	apiRouter.Use(middlewares.TimeoutMiddleware(opts.RequestTimeout))
+	apiRouter.Use(csrf.Middleware)
 
 	ar := apiRouter.PathPrefix("/auth").Subrouter()
-	ase.NewRouter(ar, authService)
+	ase.NewRouter(ar, authService, csrf, opts.Auth)
 
 	ur := apiRouter.PathPrefix("/register").Subrouter()
 	cse.NewUserRouter(ur, calService, authService)
@@ -39,6 +51,24 @@ func newRouter(authService as.Service, calService cs.Service, l logging.Logger)
 	pr := apiRouter.PathPrefix("/plans").Subrouter()
 	cse.NewPlanRouter(pr, calService, authService)
 
+	mr := apiRouter.PathPrefix("/me").Subrouter()
+	cse.NewProfileRouter(mr, calService, authService)
+
+	or := apiRouter.PathPrefix("/orgs").Subrouter()
+	cse.NewOrgRouter(or, calService, authService)
+
+	gr := apiRouter.PathPrefix("/groups").Subrouter()
+	cse.NewGroupRouter(gr, calService, authService)
+
+	tr := apiRouter.PathPrefix("/trash").Subrouter()
+	cse.NewTrashRouter(tr, calService, authService)
+
+	udr := apiRouter.PathPrefix("/undo").Subrouter()
+	cse.NewUndoRouter(udr, calService, authService)
+
+	adr := apiRouter.PathPrefix("/admin").Subrouter()
+	admin.NewRouter(adr, authService, calService)
+
 	spa := spaHandler{staticPath: "web/calendar/dist", indexPath: "index.html"}
 	r.PathPrefix("/").Handler(spa)
 
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -12,18 +14,27 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/x-color/calendar/app/rest/middlewares"
 	ar "github.com/x-color/calendar/auth/repogitory/store"
 	as "github.com/x-color/calendar/auth/service"
 	cr "github.com/x-color/calendar/calendar/repogitory/store"
 	cs "github.com/x-color/calendar/calendar/service"
 	"github.com/x-color/calendar/logging"
+	"github.com/x-color/calendar/migration"
 )
 
 var (
 	pdb *sql.DB       = nil
 	rdb *redis.Client = nil
+	sdb *sql.DB       = nil
 )
 
+// useSQLite tells whether tests run on SQLite instead of PostgreSQL and Redis. It is enabled with TEST_DB=sqlite.
+func useSQLite() bool {
+	return os.Getenv("TEST_DB") == "sqlite"
+}
+
 func IgnoreKey(key string) cmp.Option {
 	return cmpopts.IgnoreMapEntries(func(k string, t interface{}) bool {
 		return k == key
@@ -57,17 +68,79 @@ func connectDB() (*sql.DB, *redis.Client) {
 	return pdb, rdb
 }
 
+// connectSQLite opens a database in a temporary directory with the same options as the server.
+func connectSQLite() *sql.DB {
+	if sdb == nil {
+		dir, err := ioutil.TempDir("", "calendar")
+		if err != nil {
+			panic(err)
+		}
+		path := filepath.Join(dir, "calendar.db")
+		sdb, err = sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate")
+		if err != nil {
+			panic(err)
+		}
+		m, err := migration.NewMigrator(sdb, "sqlite3")
+		if err != nil {
+			panic(err)
+		}
+		if _, err := m.Up(context.Background()); err != nil {
+			panic(err)
+		}
+	}
+	return sdb
+}
+
 func NewAuthRepo() as.Repogitory {
+	if useSQLite() {
+		db := connectSQLite()
+		// Data of calendars are deleted by cascade.
+		for _, table := range []string{"auth_users", "auth_sessions", "auth_attempts", "auth_tokens", "auth_revocations"} {
+			if _, err := db.Exec("DELETE FROM " + table); err != nil {
+				panic(err)
+			}
+		}
+		r := ar.NewSQLiteRepogitory(db)
+		return &r
+	}
+
 	pdb, rdb := connectDB()
 	_, err := pdb.Exec("DELETE FROM auth.users")
 	if err != nil {
 		panic(err)
 	}
+	if err := rdb.FlushDB(context.Background()).Err(); err != nil {
+		panic(err)
+	}
 	r := ar.NewRepogitory(pdb, rdb)
 	return &r
 }
 
 func NewCalRepo() cs.Repogitory {
+	if useSQLite() {
+		db := connectSQLite()
+		for _, table := range []string{
+			"calendar_plan_shares",
+			"calendar_plans",
+			"calendar_profiles",
+			"calendar_revisions",
+			"calendar_calendar_transfers",
+			"calendar_calendar_shares",
+			"calendar_calendars",
+			"calendar_group_members",
+			"calendar_groups",
+			"calendar_org_members",
+			"calendar_orgs",
+			"calendar_users",
+		} {
+			if _, err := db.Exec("DELETE FROM " + table); err != nil {
+				panic(err)
+			}
+		}
+		r := cr.NewSQLiteRepogitory(db)
+		return &r
+	}
+
 	db, _ := connectDB()
 
 	_, err := pdb.Exec("DELETE FROM calendar.plan_shares")
@@ -78,6 +151,18 @@ func NewCalRepo() cs.Repogitory {
 	if err != nil {
 		panic(err)
 	}
+	_, err = pdb.Exec("DELETE FROM calendar.profiles")
+	if err != nil {
+		panic(err)
+	}
+	_, err = pdb.Exec("DELETE FROM calendar.revisions")
+	if err != nil {
+		panic(err)
+	}
+	_, err = pdb.Exec("DELETE FROM calendar.calendar_transfers")
+	if err != nil {
+		panic(err)
+	}
 	_, err = pdb.Exec("DELETE FROM calendar.calendar_shares")
 	if err != nil {
 		panic(err)
@@ -86,6 +171,22 @@ func NewCalRepo() cs.Repogitory {
 	if err != nil {
 		panic(err)
 	}
+	_, err = pdb.Exec("DELETE FROM calendar.group_members")
+	if err != nil {
+		panic(err)
+	}
+	_, err = pdb.Exec("DELETE FROM calendar.groups")
+	if err != nil {
+		panic(err)
+	}
+	_, err = pdb.Exec("DELETE FROM calendar.org_members")
+	if err != nil {
+		panic(err)
+	}
+	_, err = pdb.Exec("DELETE FROM calendar.orgs")
+	if err != nil {
+		panic(err)
+	}
 	_, err = pdb.Exec("DELETE FROM calendar.users")
 	if err != nil {
 		panic(err)
@@ -100,6 +201,10 @@ func NewLogger() logging.Logger {
 	return &l
 }
 
+func NewCSRF() middlewares.CSRF {
+	return middlewares.NewCSRF([]byte("csrf-key"), false)
+}
+
 func DummyCalService() cs.Service {
 	return cs.Service{}
 }
@@ -0,0 +1,184 @@
+package testutils
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCProvider is a stand-in OpenID Connect provider.
+// It authenticates every authorization request as Subject without user interaction.
+type OIDCProvider struct {
+	Server   *httptest.Server
+	ClientID string
+	Subject  string
+	Name     string
+	// KeyID is kid of ID tokens. Tokens are not verified if it is changed from "test".
+	KeyID string
+
+	key   *rsa.PrivateKey
+	m     sync.Mutex
+	codes map[string]authRequest
+	jwks  int
+}
+
+type authRequest struct {
+	redirectURI string
+	nonce       string
+	challenge   string
+}
+
+func NewOIDCProvider(clientID string) *OIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	p := &OIDCProvider{
+		ClientID: clientID,
+		Subject:  uuid.New().String(),
+		Name:     "Carol",
+		KeyID:    "test",
+		key:      key,
+		codes:    map[string]authRequest{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discoveryHandler)
+	mux.HandleFunc("/authorize", p.authorizeHandler)
+	mux.HandleFunc("/token", p.tokenHandler)
+	mux.HandleFunc("/jwks", p.jwksHandler)
+	p.Server = httptest.NewServer(mux)
+
+	return p
+}
+
+func (p *OIDCProvider) Close() {
+	p.Server.Close()
+}
+
+func (p *OIDCProvider) discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                           p.Server.URL,
+		"authorization_endpoint":           p.Server.URL + "/authorize",
+		"token_endpoint":                   p.Server.URL + "/token",
+		"jwks_uri":                         p.Server.URL + "/jwks",
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+func (p *OIDCProvider) authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != p.ClientID || q.Get("response_type") != "code" ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	code := uuid.New().String()
+	p.m.Lock()
+	p.codes[code] = authRequest{
+		redirectURI: q.Get("redirect_uri"),
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+	}
+	p.m.Unlock()
+
+	u, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	v := u.Query()
+	v.Set("code", code)
+	v.Set("state", q.Get("state"))
+	u.RawQuery = v.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func (p *OIDCProvider) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.m.Lock()
+	req, ok := p.codes[code]
+	delete(p.codes, code)
+	p.m.Unlock()
+
+	h := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != req.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(h[:]) != req.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": uuid.New().String(),
+		"token_type":   "Bearer",
+		"id_token":     p.idToken(req.nonce),
+	})
+}
+
+// JWKSRequests returns the number of requests fetching keys.
+func (p *OIDCProvider) JWKSRequests() int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.jwks
+}
+
+func (p *OIDCProvider) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	p.m.Lock()
+	p.jwks++
+	p.m.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "test",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func (p *OIDCProvider) idToken(nonce string) string {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": p.KeyID, "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":                p.Server.URL,
+		"sub":                p.Subject,
+		"aud":                p.ClientID,
+		"exp":                now.Add(time.Hour).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              nonce,
+		"preferred_username": p.Name,
+	})
+
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
@@ -0,0 +1,46 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	cse "github.com/x-color/calendar/app/rest/calendar"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	as "github.com/x-color/calendar/auth/service"
+	cs "github.com/x-color/calendar/calendar/service"
+)
+
+// NewCalendarRouter returns the router of calendar APIs served with the repogitories.
+// Paths are the same as the server's without "/api".
+func NewCalendarRouter(authRepo as.Repogitory, calRepo cs.Repogitory) *mux.Router {
+	l := NewLogger()
+	authService := as.NewService(authRepo, l)
+	calService := cs.NewService(calRepo, l)
+
+	r := mux.NewRouter()
+	r.Use(middlewares.ReqIDMiddleware)
+	cse.NewCalendarRouter(r.PathPrefix("/calendars").Subrouter(), calService, authService)
+	cse.NewPlanRouter(r.PathPrefix("/plans").Subrouter(), calService, authService)
+	cse.NewProfileRouter(r.PathPrefix("/me").Subrouter(), calService, authService)
+	cse.NewOrgRouter(r.PathPrefix("/orgs").Subrouter(), calService, authService)
+	cse.NewGroupRouter(r.PathPrefix("/groups").Subrouter(), calService, authService)
+	cse.NewTrashRouter(r.PathPrefix("/trash").Subrouter(), calService, authService)
+	cse.NewUndoRouter(r.PathPrefix("/undo").Subrouter(), calService, authService)
+	return r
+}
+
+// Request sends the request of the session to r. body is sent as JSON unless it is nil.
+func Request(r http.Handler, method, path, sessionID string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
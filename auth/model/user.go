@@ -6,12 +6,20 @@ type User struct {
 	ID       string
 	Name     string
 	Password string
+	Email    string
+	// Verified is true if the user proved ownership of the email.
+	Verified bool
+	// Admin is true if the user is allowed to manage other users.
+	Admin bool
+	// Disabled is true if an admin blocked the user.
+	Disabled bool
 }
 
-func NewUser(name string, password string) User {
+func NewUser(name, password, email string) User {
 	return User{
 		ID:       uuid.New().String(),
 		Name:     name,
 		Password: password,
+		Email:    email,
 	}
 }
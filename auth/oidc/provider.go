@@ -0,0 +1,224 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// Provider is a relying-party client for an OpenID Connect provider.
+// It uses the authorization code flow with PKCE.
+type Provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+
+	authEndpoint  string
+	tokenEndpoint string
+	jwksURI       string
+
+	client *http.Client
+	clock  clock.Clock
+
+	m    sync.RWMutex
+	keys map[string]interface{}
+	// fetchedAt is when keys were fetched last. Keys are fetched at most once per keysRefetchInterval.
+	fetchedAt time.Time
+}
+
+type discovery struct {
+	Issuer        string `json:"issuer"`
+	AuthEndpoint  string `json:"authorization_endpoint"`
+	TokenEndpoint string `json:"token_endpoint"`
+	JWKSURI       string `json:"jwks_uri"`
+}
+
+// NewProvider fetches configuration of the provider by OpenID Connect Discovery.
+func NewProvider(ctx context.Context, issuer, clientID, clientSecret, redirectURL string) (*Provider, error) {
+	p := &Provider{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       http.DefaultClient,
+		clock:        clock.Real,
+		keys:         map[string]interface{}{},
+	}
+
+	d := discovery{}
+	if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != p.issuer {
+		return nil, cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("issuer(%v) in discovery document does not match", d.Issuer),
+		)
+	}
+	if d.AuthEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, cerror.NewInvalidContentError(
+			nil,
+			"discovery document lacks endpoints",
+		)
+	}
+	p.authEndpoint = d.AuthEndpoint
+	p.tokenEndpoint = d.TokenEndpoint
+	p.jwksURI = d.JWKSURI
+
+	return p, nil
+}
+
+// SetClock sets the clock which ID tokens are verified and keys are refetched with.
+func (p *Provider) SetClock(c clock.Clock) {
+	p.clock = c
+}
+
+// AuthCodeURL returns URL of the provider to redirect the user to.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.clientID)
+	v.Set("redirect_uri", p.redirectURL)
+	v.Set("scope", "openid profile email")
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", codeChallenge(verifier))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.authEndpoint, "?") {
+		sep = "&"
+	}
+	return p.authEndpoint + sep + v.Encode()
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange redeems the authorization code and returns the identity in the verified ID token.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (service.Identity, error) {
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", p.redirectURL)
+	v.Set("code_verifier", verifier)
+	v.Set("client_id", p.clientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenEndpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return service.Identity{}, cerror.NewInternalError(
+			err,
+			"failed to build token request",
+		)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return service.Identity{}, cerror.NewInternalError(
+			err,
+			"failed to request token",
+		)
+	}
+	defer res.Body.Close()
+
+	token := tokenResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return service.Identity{}, cerror.NewInternalError(
+			err,
+			"failed to decode token response",
+		)
+	}
+	if res.StatusCode != http.StatusOK {
+		return service.Identity{}, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("token request is rejected: %v %v", token.Error, token.ErrorDescription),
+		)
+	}
+	if token.IDToken == "" {
+		return service.Identity{}, cerror.NewAuthorizationError(
+			nil,
+			"token response has no id token",
+		)
+	}
+
+	c, err := p.verify(ctx, token.IDToken, nonce)
+	if err != nil {
+		return service.Identity{}, err
+	}
+
+	name := c.PreferredUsername
+	if name == "" {
+		name = c.Email
+	}
+	if name == "" {
+		name = c.Subject
+	}
+
+	identity := service.Identity{
+		Issuer:  c.Issuer,
+		Subject: c.Subject,
+		Name:    name,
+	}
+	if c.EmailVerified {
+		identity.Email = c.Email
+	}
+	return identity, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return cerror.NewInternalError(
+			err,
+			"failed to build request",
+		)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return cerror.NewInternalError(
+			err,
+			fmt.Sprintf("failed to request %v", u),
+		)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return cerror.NewInternalError(
+			nil,
+			fmt.Sprintf("%v responds %v", u, res.Status),
+		)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return cerror.NewInternalError(
+			err,
+			fmt.Sprintf("failed to decode response of %v", u),
+		)
+	}
+	return nil
+}
+
+func codeChallenge(verifier string) string {
+	h := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
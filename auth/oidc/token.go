@@ -0,0 +1,247 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// allowedSkew is tolerance for clock difference between the provider and this server.
+const allowedSkew = time.Minute
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*a = l
+	return nil
+}
+
+type claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp"`
+	Expires           int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	PreferredUsername string   `json:"preferred_username"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+}
+
+func (p *Provider) verify(ctx context.Context, token, nonce string) (claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims{}, invalidToken("malformed id token")
+	}
+
+	h := header{}
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return claims{}, invalidToken("malformed id token header")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims{}, invalidToken("malformed id token signature")
+	}
+
+	key, err := p.key(ctx, h.Kid)
+	if err != nil {
+		return claims{}, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verifySignature(h.Alg, key, digest[:], sig) {
+		return claims{}, invalidToken(fmt.Sprintf("invalid signature(alg: %v)", h.Alg))
+	}
+
+	c := claims{}
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return claims{}, invalidToken("malformed id token claims")
+	}
+
+	now := p.clock.Now()
+	switch {
+	case strings.TrimSuffix(c.Issuer, "/") != p.issuer:
+		return claims{}, invalidToken(fmt.Sprintf("unexpected issuer(%v)", c.Issuer))
+	case !c.Audience.contains(p.clientID):
+		return claims{}, invalidToken("client is not in audience")
+	case len(c.Audience) > 1 && c.AuthorizedParty != p.clientID:
+		return claims{}, invalidToken("client is not authorized party")
+	case !now.Before(time.Unix(c.Expires, 0).Add(allowedSkew)):
+		return claims{}, invalidToken("id token is expired")
+	case now.Add(allowedSkew).Before(time.Unix(c.IssuedAt, 0)):
+		return claims{}, invalidToken("id token is issued in the future")
+	case subtle.ConstantTimeCompare([]byte(c.Nonce), []byte(nonce)) != 1:
+		return claims{}, invalidToken("nonce does not match")
+	case c.Subject == "":
+		return claims{}, invalidToken("subject is empty")
+	}
+
+	return c, nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func verifySignature(alg string, key interface{}, digest, sig []byte) bool {
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, sig) == nil
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	// Other algorithms including "none" and HMAC are not accepted.
+	return false
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// keysRefetchInterval limits fetching keys for unknown kids, so that anyone can not make
+// the server request the provider with tokens having random kids.
+const keysRefetchInterval = time.Minute
+
+// key returns public key having the kid. It fetches keys again
+// if the kid is unknown because the provider may rotate keys.
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.m.Lock()
+	k, ok := p.keys[kid]
+	if ok {
+		p.m.Unlock()
+		return k, nil
+	}
+	if p.clock.Now().Sub(p.fetchedAt) < keysRefetchInterval {
+		p.m.Unlock()
+		return nil, invalidToken(fmt.Sprintf("unknown key id(%v)", kid))
+	}
+	// It is set before fetching so that concurrent requests do not fetch keys too.
+	p.fetchedAt = p.clock.Now()
+	p.m.Unlock()
+
+	set := jwks{}
+	if err := p.getJSON(ctx, p.jwksURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		pub, err := j.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[j.Kid] = pub
+	}
+
+	p.m.Lock()
+	p.keys = keys
+	p.m.Unlock()
+
+	k, ok = keys[kid]
+	if !ok {
+		return nil, invalidToken(fmt.Sprintf("unknown key id(%v)", kid))
+	}
+	return k, nil
+}
+
+func (j jwk) publicKey() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve(%v)", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type(%v)", j.Kty)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func invalidToken(message string) error {
+	return cerror.NewAuthorizationError(nil, message)
+}
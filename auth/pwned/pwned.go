@@ -0,0 +1,48 @@
+package pwned
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RangeDir checks passwords against a local copy of breached password hashes.
+// The directory has a file for each 5 characters prefix of SHA-1 hashes, such as
+// "21BD1.txt", as the range API of Have I Been Pwned returns. Each line of the file
+// is "SUFFIX:COUNT". So only a small file is read to check a password.
+type RangeDir struct {
+	dir string
+}
+
+func NewRangeDir(dir string) RangeDir {
+	return RangeDir{dir: dir}
+}
+
+func (d RangeDir) Breached(password string) (bool, error) {
+	h := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(h[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := os.Open(filepath.Join(d.dir, prefix+".txt"))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	return false, sc.Err()
+}
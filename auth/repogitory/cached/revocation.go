@@ -0,0 +1,92 @@
+package cached
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+)
+
+// revocationOverlap is how long before the last load revocations are reloaded from, so that
+// revocations saved late or with skewed clocks by other servers are not missed.
+const revocationOverlap = time.Minute
+
+// revocationRepo keeps the revocation list in memory, because it is checked on every request
+// with session tokens. Revocations saved through it take effect at once, but ones saved by other
+// servers take effect after the list is reloaded every interval.
+type revocationRepo struct {
+	service.RevocationRepogitory
+	interval time.Duration
+	clock    clock.Clock
+
+	m           sync.Mutex
+	revocations map[string]service.RevocationData
+	// loaded is when the list is loaded last. It is zero until the list is loaded.
+	loaded time.Time
+}
+
+func NewRevocationRepogitory(repo service.RevocationRepogitory, interval time.Duration, c clock.Clock) revocationRepo {
+	return revocationRepo{
+		RevocationRepogitory: repo,
+		interval:             interval,
+		clock:                c,
+		revocations:          map[string]service.RevocationData{},
+	}
+}
+
+// Find returns an error if the list can not be reloaded, so that revoked tokens are not accepted.
+func (r *revocationRepo) Find(ctx context.Context, ids []string) ([]service.RevocationData, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	now := r.clock.Now()
+	if r.loaded.IsZero() || now.Sub(r.loaded) >= r.interval {
+		if err := r.load(ctx, now); err != nil {
+			return nil, err
+		}
+	}
+
+	revocations := []service.RevocationData{}
+	for _, id := range ids {
+		if rv, ok := r.revocations[id]; ok && now.Unix() < rv.Expires {
+			revocations = append(revocations, rv)
+		}
+	}
+	return revocations, nil
+}
+
+// load adds revocations saved since the last load and drops expired ones.
+func (r *revocationRepo) load(ctx context.Context, now time.Time) error {
+	var since int64
+	if !r.loaded.IsZero() {
+		since = r.loaded.Add(-revocationOverlap).Unix()
+	}
+	l, err := r.RevocationRepogitory.FindSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	for _, rv := range l {
+		r.revocations[rv.ID] = rv
+	}
+	for id, rv := range r.revocations {
+		if rv.Expires <= now.Unix() {
+			delete(r.revocations, id)
+		}
+	}
+	r.loaded = now
+	return nil
+}
+
+func (r *revocationRepo) Save(ctx context.Context, revocation service.RevocationData) error {
+	if err := r.RevocationRepogitory.Save(ctx, revocation); err != nil {
+		return err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.revocations[revocation.ID] = revocation
+	return nil
+}
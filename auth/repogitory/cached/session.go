@@ -0,0 +1,61 @@
+package cached
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/cache"
+)
+
+const (
+	sessionKeyPrefix = "session:"
+	// userTagPrefix is prefix of tags of sessions of each user.
+	userTagPrefix = "user:"
+)
+
+// sessionRepo reads sessions through the cache. Deleted sessions are invalidated, but a session
+// found concurrently with its deletion may be kept until the TTL of the cache passes.
+type sessionRepo struct {
+	repo  service.SessionRepogitory
+	cache cache.Cache
+}
+
+func NewSessionRepogitory(repo service.SessionRepogitory, c cache.Cache) sessionRepo {
+	return sessionRepo{
+		repo:  repo,
+		cache: c,
+	}
+}
+
+func (r *sessionRepo) Find(ctx context.Context, id string) (service.SessionData, error) {
+	session := service.SessionData{}
+	if b, ok := r.cache.Get(ctx, sessionKeyPrefix+id); ok && json.Unmarshal(b, &session) == nil {
+		return session, nil
+	}
+
+	session, err := r.repo.Find(ctx, id)
+	if err != nil {
+		return service.SessionData{}, err
+	}
+	if b, err := json.Marshal(session); err == nil {
+		r.cache.Set(ctx, sessionKeyPrefix+id, b, userTagPrefix+session.UserID)
+	}
+	return session, nil
+}
+
+func (r *sessionRepo) Create(ctx context.Context, session service.SessionData) error {
+	return r.repo.Create(ctx, session)
+}
+
+// Delete and DeleteByUserID invalidate sessions without the request context because
+// deleted sessions would be used until the TTL passes if it was cancelled.
+func (r *sessionRepo) Delete(ctx context.Context, id string) error {
+	defer r.cache.Delete(context.Background(), sessionKeyPrefix+id)
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *sessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	defer r.cache.DeleteTag(context.Background(), userTagPrefix+userID)
+	return r.repo.DeleteByUserID(ctx, userID)
+}
@@ -0,0 +1,48 @@
+package cached
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/cache"
+)
+
+const userKeyPrefix = "user:"
+
+// userRepo reads users found by ID through the cache, which sessions are authorized with.
+// Updated users are invalidated, so that disabled users are rejected at once. But a user
+// found concurrently with the update may be kept until the TTL of the cache passes.
+type userRepo struct {
+	service.UserRepogitory
+	cache cache.Cache
+}
+
+func NewUserRepogitory(repo service.UserRepogitory, c cache.Cache) userRepo {
+	return userRepo{
+		UserRepogitory: repo,
+		cache:          c,
+	}
+}
+
+func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error) {
+	user := service.UserData{}
+	if b, ok := r.cache.Get(ctx, userKeyPrefix+id); ok && json.Unmarshal(b, &user) == nil {
+		return user, nil
+	}
+
+	user, err := r.UserRepogitory.Find(ctx, id)
+	if err != nil {
+		return service.UserData{}, err
+	}
+	if b, err := json.Marshal(user); err == nil {
+		r.cache.Set(ctx, userKeyPrefix+id, b)
+	}
+	return user, nil
+}
+
+// Update invalidates the user without the request context as sessions are.
+func (r *userRepo) Update(ctx context.Context, user service.UserData) error {
+	defer r.cache.Delete(context.Background(), userKeyPrefix+user.ID)
+	return r.UserRepogitory.Update(ctx, user)
+}
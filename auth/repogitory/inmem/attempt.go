@@ -0,0 +1,87 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type attemptRepo struct {
+	m rwLocker
+	*tables
+	clock clock.Clock
+}
+
+func (r *attemptRepo) Find(ctx context.Context, key string) (service.AttemptData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	now := r.clock.Now().Unix()
+	for _, a := range r.attempts {
+		if key == a.Key && now < a.Expires {
+			return a, nil
+		}
+	}
+
+	return service.AttemptData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found attempts(%v)", key),
+	)
+}
+
+func (r *attemptRepo) Increment(ctx context.Context, key string, expires int64) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	now := r.clock.Now().Unix()
+	for i, a := range r.attempts {
+		if key == a.Key {
+			if now >= a.Expires {
+				a = service.AttemptData{Key: key}
+			}
+			a.Failures++
+			a.Expires = expires
+			r.attempts[i] = a
+			return a.Failures, nil
+		}
+	}
+	r.attempts = append(r.attempts, service.AttemptData{Key: key, Failures: 1, Expires: expires})
+	return 1, nil
+}
+
+func (r *attemptRepo) Lock(ctx context.Context, key string, lockedUntil int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	now := r.clock.Now().Unix()
+	for i, a := range r.attempts {
+		if key == a.Key && now < a.Expires {
+			if lockedUntil > a.LockedUntil {
+				r.attempts[i].LockedUntil = lockedUntil
+			}
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found attempts(%v)", key),
+	)
+}
+
+func (r *attemptRepo) Delete(ctx context.Context, key string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, a := range r.attempts {
+		if key == a.Key {
+			r.attempts = append(r.attempts[:i], r.attempts[i+1:]...)
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found attempts(%v)", key),
+	)
+}
@@ -0,0 +1,48 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x-color/calendar/auth/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type identityRepo struct {
+	m rwLocker
+	*tables
+}
+
+func (r *identityRepo) Find(ctx context.Context, issuer, subject string) (service.IdentityData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	for _, i := range r.identities {
+		if issuer == i.Issuer && subject == i.Subject {
+			return i, nil
+		}
+	}
+
+	return service.IdentityData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found identity(%v, %v)", issuer, subject),
+	)
+}
+
+func (r *identityRepo) Create(ctx context.Context, identity service.IdentityData) error {
+	r.m.RLock()
+	for _, i := range r.identities {
+		if identity.Issuer == i.Issuer && identity.Subject == i.Subject {
+			r.m.RUnlock()
+			return cerror.NewDuplicationError(
+				nil,
+				fmt.Sprintf("same key(%v, %v)", identity.Issuer, identity.Subject),
+			)
+		}
+	}
+	r.m.RUnlock()
+	r.m.Lock()
+	r.identities = append(r.identities, identity)
+	r.m.Unlock()
+	return nil
+}
@@ -4,11 +4,43 @@ import (
 	"sync"
 
 	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
 )
 
+// tables is all data in the repogitory. It is shared by the repogitory and ones in transactions.
+type tables struct {
+	users       []service.UserData
+	sessions    []service.SessionData
+	attempts    []service.AttemptData
+	identities  []service.IdentityData
+	tokens      []service.TokenData
+	revocations []service.RevocationData
+}
+
+// rwLocker is the lock of a repogitory. Repogitories in a transaction use noLock
+// because the transaction holds the locks of all repogitories.
+type rwLocker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+type noLock struct{}
+
+func (noLock) Lock()    {}
+func (noLock) Unlock()  {}
+func (noLock) RLock()   {}
+func (noLock) RUnlock() {}
+
 type inmem struct {
-	userRepo    userRepo
-	sessionRepo sessionRepo
+	tables         *tables
+	userRepo       userRepo
+	sessionRepo    sessionRepo
+	attemptRepo    attemptRepo
+	identityRepo   identityRepo
+	tokenRepo      tokenRepo
+	revocationRepo revocationRepo
 }
 
 func (m *inmem) User() service.UserRepogitory {
@@ -19,17 +51,46 @@ func (m *inmem) Session() service.SessionRepogitory {
 	return &m.sessionRepo
 }
 
-func NewRepogitory() inmem {
-	u := userRepo{
-		m:     sync.RWMutex{},
-		users: []service.UserData{},
-	}
-	s := sessionRepo{
-		m:        sync.RWMutex{},
-		sessions: []service.SessionData{},
+func (m *inmem) Attempt() service.AttemptRepogitory {
+	return &m.attemptRepo
+}
+
+func (m *inmem) Identity() service.IdentityRepogitory {
+	return &m.identityRepo
+}
+
+func (m *inmem) Token() service.TokenRepogitory {
+	return &m.tokenRepo
+}
+
+func (m *inmem) Revocation() service.RevocationRepogitory {
+	return &m.revocationRepo
+}
+
+// SetClock sets the clock which expiry of data is checked with.
+func (m *inmem) SetClock(c clock.Clock) {
+	m.sessionRepo.clock = c
+	m.attemptRepo.clock = c
+	m.tokenRepo.clock = c
+	m.revocationRepo.clock = c
+}
+
+func NewRepogitory() *inmem {
+	t := &tables{
+		users:       []service.UserData{},
+		sessions:    []service.SessionData{},
+		attempts:    []service.AttemptData{},
+		identities:  []service.IdentityData{},
+		tokens:      []service.TokenData{},
+		revocations: []service.RevocationData{},
 	}
-	return inmem{
-		userRepo:    u,
-		sessionRepo: s,
+	return &inmem{
+		tables:         t,
+		userRepo:       userRepo{m: &sync.RWMutex{}, tables: t},
+		sessionRepo:    sessionRepo{m: &sync.RWMutex{}, tables: t, clock: clock.Real},
+		attemptRepo:    attemptRepo{m: &sync.RWMutex{}, tables: t, clock: clock.Real},
+		identityRepo:   identityRepo{m: &sync.RWMutex{}, tables: t},
+		tokenRepo:      tokenRepo{m: &sync.RWMutex{}, tables: t, clock: clock.Real},
+		revocationRepo: revocationRepo{m: &sync.RWMutex{}, tables: t, clock: clock.Real},
 	}
 }
@@ -0,0 +1,20 @@
+package inmem_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/x-color/calendar/auth/repogitory/inmem"
+	"github.com/x-color/calendar/auth/repogitory/repotest"
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+)
+
+func TestRepogitory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (service.Repogitory, repotest.Clock) {
+		c := clock.NewFake(time.Now())
+		r := inmem.NewRepogitory()
+		r.SetClock(c)
+		return r, c
+	})
+}
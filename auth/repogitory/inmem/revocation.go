@@ -0,0 +1,56 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	"github.com/x-color/slice/strs"
+)
+
+type revocationRepo struct {
+	m rwLocker
+	*tables
+	clock clock.Clock
+}
+
+func (r *revocationRepo) Find(ctx context.Context, ids []string) ([]service.RevocationData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	now := r.clock.Now().Unix()
+	revocations := []service.RevocationData{}
+	for _, rv := range r.revocations {
+		if strs.Contains(ids, rv.ID) && now < rv.Expires {
+			revocations = append(revocations, rv)
+		}
+	}
+	return revocations, nil
+}
+
+func (r *revocationRepo) FindSince(ctx context.Context, revokedAt int64) ([]service.RevocationData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	now := r.clock.Now().Unix()
+	revocations := []service.RevocationData{}
+	for _, rv := range r.revocations {
+		if revokedAt <= rv.RevokedAt && now < rv.Expires {
+			revocations = append(revocations, rv)
+		}
+	}
+	return revocations, nil
+}
+
+func (r *revocationRepo) Save(ctx context.Context, revocation service.RevocationData) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, rv := range r.revocations {
+		if revocation.ID == rv.ID {
+			r.revocations[i] = revocation
+			return nil
+		}
+	}
+	r.revocations = append(r.revocations, revocation)
+	return nil
+}
@@ -3,23 +3,25 @@ package inmem
 import (
 	"context"
 	"fmt"
-	"sync"
 
 	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
 	cerror "github.com/x-color/calendar/model/error"
 )
 
 type sessionRepo struct {
-	m        sync.RWMutex
-	sessions []service.SessionData
+	m rwLocker
+	*tables
+	clock clock.Clock
 }
 
 func (r *sessionRepo) Find(ctx context.Context, id string) (service.SessionData, error) {
 	r.m.RLock()
 	defer r.m.RUnlock()
 
+	now := r.clock.Now().Unix()
 	for _, s := range r.sessions {
-		if id == s.ID {
+		if id == s.ID && now < s.Expires {
 			return s, nil
 		}
 	}
@@ -51,8 +53,9 @@ func (r *sessionRepo) Create(ctx context.Context, session service.SessionData) e
 func (r *sessionRepo) Delete(ctx context.Context, id string) error {
 	r.m.Lock()
 	defer r.m.Unlock()
+	now := r.clock.Now().Unix()
 	for i, s := range r.sessions {
-		if id == s.ID {
+		if id == s.ID && now < s.Expires {
 			r.sessions = append(r.sessions[:i], r.sessions[i+1:]...)
 			return nil
 		}
@@ -62,3 +65,16 @@ func (r *sessionRepo) Delete(ctx context.Context, id string) error {
 		fmt.Sprintf("not found session(%v)", id),
 	)
 }
+
+func (r *sessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	sessions := []service.SessionData{}
+	for _, s := range r.sessions {
+		if userID != s.UserID {
+			sessions = append(sessions, s)
+		}
+	}
+	r.sessions = sessions
+	return nil
+}
@@ -0,0 +1,66 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type tokenRepo struct {
+	m rwLocker
+	*tables
+	clock clock.Clock
+}
+
+func (r *tokenRepo) Find(ctx context.Context, id string) (service.TokenData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	now := r.clock.Now().Unix()
+	for _, t := range r.tokens {
+		if id == t.ID && now < t.Expires {
+			return t, nil
+		}
+	}
+
+	return service.TokenData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found token(%v)", id),
+	)
+}
+
+func (r *tokenRepo) Create(ctx context.Context, token service.TokenData) error {
+	r.m.RLock()
+	for _, t := range r.tokens {
+		if token.ID == t.ID {
+			r.m.RUnlock()
+			return cerror.NewDuplicationError(
+				nil,
+				fmt.Sprintf("same key(%v)", token.ID),
+			)
+		}
+	}
+	r.m.RUnlock()
+	r.m.Lock()
+	r.tokens = append(r.tokens, token)
+	r.m.Unlock()
+	return nil
+}
+
+func (r *tokenRepo) Delete(ctx context.Context, id string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, t := range r.tokens {
+		if id == t.ID {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found token(%v)", id),
+	)
+}
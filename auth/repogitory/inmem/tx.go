@@ -0,0 +1,76 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/x-color/calendar/auth/service"
+)
+
+// txRepo is the repogitory used in a transaction. Transactions in it join the running one.
+type txRepo struct {
+	*inmem
+}
+
+func (r txRepo) Transaction(ctx context.Context, f func(service.Repogitory) error) error {
+	return f(r)
+}
+
+// Transaction runs f exclusively with other operations. It holds the locks of all repogitories
+// while f runs, so that changes made in f are discarded without losing concurrent ones if f returns an error.
+func (m *inmem) Transaction(ctx context.Context, f func(service.Repogitory) error) error {
+	locks := m.locks()
+	for _, l := range locks {
+		l.Lock()
+	}
+	defer func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}()
+
+	s := m.tables.copy()
+	if err := f(txRepo{m.unlocked()}); err != nil {
+		*m.tables = s
+		return err
+	}
+	return nil
+}
+
+// copy returns a copy of all data. Copying slices is enough because the repogitories
+// replace items instead of changing them.
+func (t *tables) copy() tables {
+	return tables{
+		users:       append([]service.UserData{}, t.users...),
+		sessions:    append([]service.SessionData{}, t.sessions...),
+		attempts:    append([]service.AttemptData{}, t.attempts...),
+		identities:  append([]service.IdentityData{}, t.identities...),
+		tokens:      append([]service.TokenData{}, t.tokens...),
+		revocations: append([]service.RevocationData{}, t.revocations...),
+	}
+}
+
+// locks returns the locks of all repogitories. They are always locked in the order.
+func (m *inmem) locks() []rwLocker {
+	return []rwLocker{
+		m.userRepo.m,
+		m.sessionRepo.m,
+		m.attemptRepo.m,
+		m.identityRepo.m,
+		m.tokenRepo.m,
+		m.revocationRepo.m,
+	}
+}
+
+// unlocked returns the repogitory sharing data and clocks with m without locking it.
+func (m *inmem) unlocked() *inmem {
+	l := noLock{}
+	return &inmem{
+		tables:         m.tables,
+		userRepo:       userRepo{m: l, tables: m.tables},
+		sessionRepo:    sessionRepo{m: l, tables: m.tables, clock: m.sessionRepo.clock},
+		attemptRepo:    attemptRepo{m: l, tables: m.tables, clock: m.attemptRepo.clock},
+		identityRepo:   identityRepo{m: l, tables: m.tables},
+		tokenRepo:      tokenRepo{m: l, tables: m.tables, clock: m.tokenRepo.clock},
+		revocationRepo: revocationRepo{m: l, tables: m.tables, clock: m.revocationRepo.clock},
+	}
+}
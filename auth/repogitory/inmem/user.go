@@ -3,15 +3,47 @@ package inmem
 import (
 	"context"
 	"fmt"
-	"sync"
+	"sort"
+	"strings"
 
 	"github.com/x-color/calendar/auth/service"
 	cerror "github.com/x-color/calendar/model/error"
+	"github.com/x-color/slice/strs"
 )
 
 type userRepo struct {
-	m     sync.RWMutex
-	users []service.UserData
+	m rwLocker
+	*tables
+}
+
+func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	for _, u := range r.users {
+		if id == u.ID {
+			return u, nil
+		}
+	}
+
+	return service.UserData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found user(%v)", id),
+	)
+}
+
+func (r *userRepo) FindUsers(ctx context.Context, ids []string) ([]service.UserData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	users := []service.UserData{}
+	for _, u := range r.users {
+		if strs.Contains(ids, u.ID) {
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
 }
 
 func (r *userRepo) FindByName(ctx context.Context, name string) (service.UserData, error) {
@@ -30,6 +62,47 @@ func (r *userRepo) FindByName(ctx context.Context, name string) (service.UserDat
 	)
 }
 
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (service.UserData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	for _, u := range r.users {
+		if email != "" && email == u.Email {
+			return u, nil
+		}
+	}
+
+	return service.UserData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found email(%v)", email),
+	)
+}
+
+func (r *userRepo) Search(ctx context.Context, query string, offset, limit int) ([]service.UserData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	query = strings.ToLower(query)
+	users := []service.UserData{}
+	for _, u := range r.users {
+		if strings.Contains(strings.ToLower(u.Name), query) || strings.Contains(strings.ToLower(u.Email), query) {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+
+	if offset > len(users) {
+		offset = len(users)
+	}
+	users = users[offset:]
+	if limit < len(users) {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
 func (r *userRepo) Create(ctx context.Context, user service.UserData) error {
 	r.m.RLock()
 	for _, u := range r.users {
@@ -47,3 +120,18 @@ func (r *userRepo) Create(ctx context.Context, user service.UserData) error {
 	r.m.Unlock()
 	return nil
 }
+
+func (r *userRepo) Update(ctx context.Context, user service.UserData) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, u := range r.users {
+		if user.ID == u.ID {
+			r.users[i] = user
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found user(%v)", user.ID),
+	)
+}
@@ -0,0 +1,232 @@
+// Package repotest is the conformance suite of repogitories of authentication.
+// All backends run it, so that services work the same on any of them.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// opts ignores the order of results, because backends return them in any order.
+var opts = []cmp.Option{
+	cmpopts.EquateEmpty(),
+	cmpopts.SortSlices(func(a, b service.UserData) bool { return a.ID < b.ID }),
+	cmpopts.SortSlices(func(a, b service.RevocationData) bool { return a.ID < b.ID }),
+}
+
+// Clock is the time of repogitories under the suite. The suite advances it to expire data
+// instead of waiting for them to expire.
+type Clock interface {
+	clock.Clock
+	Advance(d time.Duration)
+}
+
+// Run runs the suite on repogitories made by newRepo, which must be empty.
+// The repogitories must read the time from the clock returned with them.
+func Run(t *testing.T, newRepo func(t *testing.T) (service.Repogitory, Clock)) {
+	t.Run("User", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		testUser(t, repo)
+	})
+	t.Run("Session", func(t *testing.T) {
+		repo, c := newRepo(t)
+		testSession(t, repo, c)
+	})
+	t.Run("Revocation", func(t *testing.T) {
+		repo, c := newRepo(t)
+		testRevocation(t, repo, c)
+	})
+}
+
+func newUser(t *testing.T, repo service.Repogitory, name string) string {
+	id := uuid.New().String()
+	must(t, repo.User().Create(context.Background(), service.UserData{ID: id, Name: name, Password: "password"}))
+	return id
+}
+
+func testUser(t *testing.T, repo service.Repogitory) {
+	ctx := context.Background()
+	users := repo.User()
+
+	alice := service.UserData{ID: uuid.New().String(), Name: "alice", Password: "password", Email: "alice@example.com", Verified: true}
+	bob := service.UserData{ID: uuid.New().String(), Name: "bob", Password: "password", Admin: true}
+	carol := service.UserData{ID: uuid.New().String(), Name: "carol_1", Password: "password", Email: "carol@example.com"}
+	for _, user := range []service.UserData{alice, bob, carol} {
+		must(t, users.Create(ctx, user))
+	}
+
+	checkErr(t, "create same user", users.Create(ctx, alice), cerror.ErrDuplication)
+
+	user, err := users.Find(ctx, alice.ID)
+	must(t, err)
+	check(t, "user found by ID", alice, user)
+	_, err = users.Find(ctx, uuid.New().String())
+	checkErr(t, "find unknown user", err, cerror.ErrNotFound)
+	l, err := users.FindUsers(ctx, []string{alice.ID, bob.ID, uuid.New().String()})
+	must(t, err)
+	check(t, "users found by IDs", []service.UserData{alice, bob}, l)
+	l, err = users.FindUsers(ctx, []string{})
+	must(t, err)
+	check(t, "users found by no IDs", []service.UserData{}, l)
+	user, err = users.FindByName(ctx, "bob")
+	must(t, err)
+	check(t, "user found by name", bob, user)
+	_, err = users.FindByName(ctx, "dave")
+	checkErr(t, "find unknown name", err, cerror.ErrNotFound)
+	user, err = users.FindByEmail(ctx, "carol@example.com")
+	must(t, err)
+	check(t, "user found by email", carol, user)
+	_, err = users.FindByEmail(ctx, "")
+	checkErr(t, "find empty email", err, cerror.ErrNotFound)
+
+	l, err = users.Search(ctx, "EXAMPLE", 0, 10)
+	must(t, err)
+	check(t, "users searched by email", []service.UserData{alice, carol}, l)
+	l, err = users.Search(ctx, "", 1, 1)
+	must(t, err)
+	check(t, "page of all users", []service.UserData{bob}, l)
+	// Wildcards of LIKE match themselves only.
+	l, err = users.Search(ctx, "_", 0, 10)
+	must(t, err)
+	check(t, "users searched by wildcard", []service.UserData{carol}, l)
+
+	bob.Email = "bob@example.com"
+	bob.Disabled = true
+	must(t, users.Update(ctx, bob))
+	user, err = users.Find(ctx, bob.ID)
+	must(t, err)
+	check(t, "updated user", bob, user)
+	checkErr(t, "update unknown user", users.Update(ctx, service.UserData{ID: uuid.New().String(), Name: "dave"}), cerror.ErrNotFound)
+
+	dave := service.UserData{ID: uuid.New().String(), Name: "dave", Password: "password"}
+	errRollback := errors.New("rollback")
+	err = repo.Transaction(ctx, func(tx service.Repogitory) error {
+		must(t, tx.User().Create(ctx, dave))
+		return errRollback
+	})
+	checkErr(t, "failed transaction", err, errRollback)
+	_, err = users.Find(ctx, dave.ID)
+	checkErr(t, "find user created in rolled back transaction", err, cerror.ErrNotFound)
+	must(t, repo.Transaction(ctx, func(tx service.Repogitory) error {
+		return tx.User().Create(ctx, dave)
+	}))
+	_, err = users.Find(ctx, dave.ID)
+	must(t, err)
+}
+
+func testSession(t *testing.T, repo service.Repogitory, c Clock) {
+	ctx := context.Background()
+	sessions := repo.Session()
+
+	alice := newUser(t, repo, "alice")
+	bob := newUser(t, repo, "bob")
+	now := c.Now()
+	first := service.SessionData{ID: uuid.New().String(), UserID: alice, Expires: now.Add(time.Hour).Unix()}
+	second := service.SessionData{ID: uuid.New().String(), UserID: alice, Expires: now.Add(2 * time.Hour).Unix()}
+	other := service.SessionData{ID: uuid.New().String(), UserID: bob, Expires: now.Add(time.Hour).Unix()}
+	for _, session := range []service.SessionData{first, second, other} {
+		must(t, sessions.Create(ctx, session))
+	}
+
+	checkErr(t, "create same session", sessions.Create(ctx, first), cerror.ErrDuplication)
+
+	session, err := sessions.Find(ctx, first.ID)
+	must(t, err)
+	check(t, "found session", first, session)
+	_, err = sessions.Find(ctx, uuid.New().String())
+	checkErr(t, "find unknown session", err, cerror.ErrNotFound)
+
+	must(t, sessions.Delete(ctx, first.ID))
+	_, err = sessions.Find(ctx, first.ID)
+	checkErr(t, "find deleted session", err, cerror.ErrNotFound)
+	checkErr(t, "delete deleted session", sessions.Delete(ctx, first.ID), cerror.ErrNotFound)
+
+	must(t, sessions.DeleteByUserID(ctx, alice))
+	_, err = sessions.Find(ctx, second.ID)
+	checkErr(t, "find session of user whose sessions are deleted", err, cerror.ErrNotFound)
+	session, err = sessions.Find(ctx, other.ID)
+	must(t, err)
+	check(t, "session of other user", other, session)
+
+	// The session expiring sooner is created later, so that it must not shorten
+	// how long sessions of the user are kept.
+	long := service.SessionData{ID: uuid.New().String(), UserID: bob, Expires: now.Add(time.Hour).Unix()}
+	short := service.SessionData{ID: uuid.New().String(), UserID: bob, Expires: now.Add(2 * time.Second).Unix()}
+	must(t, sessions.Create(ctx, long))
+	must(t, sessions.Create(ctx, short))
+	c.Advance(3 * time.Second)
+
+	_, err = sessions.Find(ctx, short.ID)
+	checkErr(t, "find expired session", err, cerror.ErrNotFound)
+	checkErr(t, "delete expired session", sessions.Delete(ctx, short.ID), cerror.ErrNotFound)
+	must(t, sessions.DeleteByUserID(ctx, bob))
+	_, err = sessions.Find(ctx, long.ID)
+	checkErr(t, "find session created before expired one of user whose sessions are deleted", err, cerror.ErrNotFound)
+}
+
+func testRevocation(t *testing.T, repo service.Repogitory, c Clock) {
+	ctx := context.Background()
+	revocations := repo.Revocation()
+
+	now := c.Now()
+	session := service.RevocationData{ID: uuid.New().String(), RevokedAt: now.Unix(), Expires: now.Add(time.Hour).Unix()}
+	user := service.RevocationData{ID: "user:" + uuid.New().String(), RevokedAt: now.Unix(), Expires: now.Add(time.Hour).Unix()}
+	expired := service.RevocationData{ID: uuid.New().String(), RevokedAt: now.Add(-time.Hour).Unix(), Expires: now.Add(-time.Minute).Unix()}
+	for _, rv := range []service.RevocationData{session, user, expired} {
+		must(t, revocations.Save(ctx, rv))
+	}
+
+	l, err := revocations.Find(ctx, []string{session.ID, user.ID, expired.ID, uuid.New().String()})
+	must(t, err)
+	check(t, "revocations found by IDs", []service.RevocationData{session, user}, l)
+	l, err = revocations.Find(ctx, []string{})
+	must(t, err)
+	check(t, "revocations found by no IDs", []service.RevocationData{}, l)
+
+	l, err = revocations.FindSince(ctx, 0)
+	must(t, err)
+	check(t, "all revocations", []service.RevocationData{session, user}, l)
+	l, err = revocations.FindSince(ctx, now.Add(time.Second).Unix())
+	must(t, err)
+	check(t, "revocations since later time", []service.RevocationData{}, l)
+
+	user.RevokedAt = now.Add(time.Minute).Unix()
+	user.Expires = now.Add(2 * time.Hour).Unix()
+	must(t, revocations.Save(ctx, user))
+	l, err = revocations.Find(ctx, []string{user.ID})
+	must(t, err)
+	check(t, "replaced revocation", []service.RevocationData{user}, l)
+	l, err = revocations.FindSince(ctx, now.Add(time.Second).Unix())
+	must(t, err)
+	check(t, "revocations since later time after replacement", []service.RevocationData{user}, l)
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("want no error, but got %v", err)
+	}
+}
+
+func check(t *testing.T, name string, want, got interface{}) {
+	t.Helper()
+	if d := cmp.Diff(want, got, opts...); d != "" {
+		t.Errorf("unexpected %v: %v", name, d)
+	}
+}
+
+func checkErr(t *testing.T, name string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%v: want %v, but got %v", name, target, err)
+	}
+}
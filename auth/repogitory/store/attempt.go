@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/x-color/calendar/auth/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+const attemptKeyPrefix = "attempt:"
+
+type attemptRepo struct {
+	rdb *redis.Client
+}
+
+func (r *attemptRepo) Find(ctx context.Context, key string) (service.AttemptData, error) {
+	m, err := r.rdb.HGetAll(ctx, attemptKeyPrefix+key).Result()
+	switch {
+	case err != nil:
+		return service.AttemptData{}, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get attempts",
+		)
+	case len(m) == 0:
+		return service.AttemptData{}, cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found attempts(%v)", key),
+		)
+	}
+
+	attempt := service.AttemptData{Key: key}
+	attempt.Failures, err = strconv.Atoi(m["failures"])
+	if err != nil {
+		return service.AttemptData{}, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to parse failures",
+		)
+	}
+	attempt.LockedUntil, err = strconv.ParseInt(m["locked_until"], 10, 64)
+	if err != nil {
+		return service.AttemptData{}, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to parse locked_until",
+		)
+	}
+	attempt.Expires, err = strconv.ParseInt(m["expires"], 10, 64)
+	if err != nil {
+		return service.AttemptData{}, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to parse expires",
+		)
+	}
+
+	return attempt, nil
+}
+
+func (r *attemptRepo) Increment(ctx context.Context, key string, expires int64) (int, error) {
+	key = attemptKeyPrefix + key
+	var failures *redis.IntCmd
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		failures = pipe.HIncrBy(ctx, key, "failures", 1)
+		pipe.HSetNX(ctx, key, "locked_until", 0)
+		pipe.HSet(ctx, key, "expires", expires)
+		pipe.ExpireAt(ctx, key, time.Unix(expires, 0))
+		return nil
+	})
+	if err != nil {
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to increment attempts",
+		)
+	}
+	return int(failures.Val()), nil
+}
+
+// lockScript extends locked_until of the attempts if they exist and are locked out shorter.
+var lockScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+if tonumber(redis.call("HGET", KEYS[1], "locked_until") or "0") < tonumber(ARGV[1]) then
+	redis.call("HSET", KEYS[1], "locked_until", ARGV[1])
+end
+return 1
+`)
+
+func (r *attemptRepo) Lock(ctx context.Context, key string, lockedUntil int64) error {
+	n, err := lockScript.Run(ctx, r.rdb, []string{attemptKeyPrefix + key}, lockedUntil).Int()
+	switch {
+	case err != nil:
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to lock out",
+		)
+	case n == 0:
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found attempts(%v)", key),
+		)
+	}
+	return nil
+}
+
+func (r *attemptRepo) Delete(ctx context.Context, key string) error {
+	n, err := r.rdb.Del(ctx, attemptKeyPrefix+key).Result()
+	switch {
+	case err != nil:
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete attempts",
+		)
+	case n == 0:
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found attempts(%v)", key),
+		)
+	}
+	return nil
+}
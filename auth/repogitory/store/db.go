@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	"github.com/x-color/calendar/dialect"
+)
+
+// dbStore keeps all data in the database, which is used without Redis.
+// Expired sessions are removed by DeleteExpired of the sessions, which must be called periodically.
+type dbStore struct {
+	userRepo       userRepo
+	sessionRepo    dbSessionRepo
+	attemptRepo    dbAttemptRepo
+	identityRepo   identityRepo
+	tokenRepo      dbTokenRepo
+	revocationRepo dbRevocationRepo
+}
+
+func (m *dbStore) User() service.UserRepogitory {
+	return &m.userRepo
+}
+
+func (m *dbStore) Session() service.SessionRepogitory {
+	return &m.sessionRepo
+}
+
+func (m *dbStore) Attempt() service.AttemptRepogitory {
+	return &m.attemptRepo
+}
+
+func (m *dbStore) Identity() service.IdentityRepogitory {
+	return &m.identityRepo
+}
+
+func (m *dbStore) Token() service.TokenRepogitory {
+	return &m.tokenRepo
+}
+
+func (m *dbStore) Revocation() service.RevocationRepogitory {
+	return &m.revocationRepo
+}
+
+// Transaction runs f with the repogitory in a database transaction.
+func (m *dbStore) Transaction(ctx context.Context, f func(service.Repogitory) error) error {
+	return m.userRepo.db.transaction(ctx, func(q conn) error {
+		t := *m
+		t.userRepo.db = q
+		t.sessionRepo.db = q
+		t.attemptRepo.db = q
+		t.identityRepo.db = q
+		t.tokenRepo.db = q
+		t.revocationRepo.db = q
+		return f(&t)
+	})
+}
+
+// SetClock sets the clock which expiry of data is checked with.
+func (m *dbStore) SetClock(c clock.Clock) {
+	m.sessionRepo.clock = c
+	m.attemptRepo.clock = c
+	m.tokenRepo.clock = c
+	m.revocationRepo.clock = c
+}
+
+// NewDatabaseRepogitory returns the repogitory keeping all data in PostgreSQL, which is used
+// with the database session store.
+func NewDatabaseRepogitory(db *sql.DB) dbStore {
+	return newDBStore(conn{db: db, d: dialect.PostgreSQL})
+}
+
+// NewSQLiteRepogitory returns the repogitory in SQLite. It runs the same queries as PostgreSQL.
+func NewSQLiteRepogitory(db *sql.DB) dbStore {
+	return newDBStore(conn{db: db, d: dialect.SQLite})
+}
+
+func newDBStore(c conn) dbStore {
+	return dbStore{
+		userRepo:       userRepo{db: c},
+		sessionRepo:    dbSessionRepo{db: c, clock: clock.Real},
+		attemptRepo:    dbAttemptRepo{db: c, clock: clock.Real},
+		identityRepo:   identityRepo{db: c},
+		tokenRepo:      dbTokenRepo{db: c, clock: clock.Real},
+		revocationRepo: dbRevocationRepo{db: c, clock: clock.Real},
+	}
+}
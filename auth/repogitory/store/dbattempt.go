@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// dbAttemptRepo keeps attempts in the database instead of Redis. Expired attempts are not found,
+// and they are removed when attempts are incremented.
+type dbAttemptRepo struct {
+	db    conn
+	clock clock.Clock
+}
+
+func (r *dbAttemptRepo) Find(ctx context.Context, key string) (service.AttemptData, error) {
+	const query = "SELECT key, failures, locked_until, expires FROM auth.attempts WHERE key = $1 AND expires > $2"
+
+	attempt := service.AttemptData{}
+	err := r.db.QueryRowContext(ctx, query, key, r.clock.Now().Unix()).Scan(&attempt.Key, &attempt.Failures, &attempt.LockedUntil, &attempt.Expires)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return attempt, cerror.NewNotFoundError(
+			err,
+			fmt.Sprintf("not found attempts(%v)", key),
+		)
+	case err != nil:
+		return attempt, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get attempts",
+		)
+	}
+
+	return attempt, nil
+}
+
+func (r *dbAttemptRepo) Increment(ctx context.Context, key string, expires int64) (int, error) {
+	if err := deleteExpired(ctx, r.db, "auth.attempts", r.clock.Now()); err != nil {
+		return 0, err
+	}
+
+	// The row is written before it is read, so that the transaction holds the lock of the database.
+	const incQuery = `
+		INSERT INTO auth.attempts (key, failures, locked_until, expires)
+		VALUES ($1, 1, 0, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = auth.attempts.failures + 1,
+			expires = excluded.expires
+	`
+	const query = "SELECT failures FROM auth.attempts WHERE key = $1"
+
+	var failures int
+	err := r.db.transaction(ctx, func(q conn) error {
+		if _, err := q.ExecContext(ctx, incQuery, key, expires); err != nil {
+			return err
+		}
+		return q.QueryRowContext(ctx, query, key).Scan(&failures)
+	})
+	if err != nil {
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to increment attempts",
+		)
+	}
+	return failures, nil
+}
+
+func (r *dbAttemptRepo) Lock(ctx context.Context, key string, lockedUntil int64) error {
+	const query = `
+		UPDATE auth.attempts
+		SET locked_until = CASE WHEN locked_until < $1 THEN $1 ELSE locked_until END
+		WHERE key = $2 AND expires > $3
+	`
+
+	res, err := r.db.ExecContext(ctx, query, lockedUntil, key, r.clock.Now().Unix())
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to lock out",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found attempts(%v)", key),
+		)
+	}
+	return nil
+}
+
+func (r *dbAttemptRepo) Delete(ctx context.Context, key string) error {
+	const query = "DELETE FROM auth.attempts WHERE key = $1 AND expires > $2"
+
+	res, err := r.db.ExecContext(ctx, query, key, r.clock.Now().Unix())
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete attempts",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found attempts(%v)", key),
+		)
+	}
+	return nil
+}
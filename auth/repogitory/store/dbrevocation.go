@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	"github.com/x-color/calendar/dialect"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// dbRevocationRepo keeps revocations in the database instead of Redis. Expired revocations are
+// not found, and they are removed when revocations are saved.
+type dbRevocationRepo struct {
+	db    conn
+	clock clock.Clock
+}
+
+func (r *dbRevocationRepo) Find(ctx context.Context, ids []string) ([]service.RevocationData, error) {
+	if len(ids) == 0 {
+		return []service.RevocationData{}, nil
+	}
+
+	list, args := dialect.InList(2, ids)
+	query := fmt.Sprintf("SELECT id, revoked_at, expires FROM auth.revocations WHERE expires > $1 AND id IN (%s)", list)
+	return r.query(ctx, query, append([]interface{}{r.clock.Now().Unix()}, args...)...)
+}
+
+func (r *dbRevocationRepo) FindSince(ctx context.Context, revokedAt int64) ([]service.RevocationData, error) {
+	const query = "SELECT id, revoked_at, expires FROM auth.revocations WHERE expires > $1 AND revoked_at >= $2"
+	return r.query(ctx, query, r.clock.Now().Unix(), revokedAt)
+}
+
+func (r *dbRevocationRepo) query(ctx context.Context, query string, args ...interface{}) ([]service.RevocationData, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get revocations",
+		)
+	}
+	defer rows.Close()
+
+	revocations := []service.RevocationData{}
+	for rows.Next() {
+		revocation := service.RevocationData{}
+		if err := rows.Scan(&revocation.ID, &revocation.RevokedAt, &revocation.Expires); err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+		revocations = append(revocations, revocation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+	return revocations, nil
+}
+
+func (r *dbRevocationRepo) Save(ctx context.Context, revocation service.RevocationData) error {
+	if err := deleteExpired(ctx, r.db, "auth.revocations", r.clock.Now()); err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO auth.revocations (id, revoked_at, expires)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET
+			revoked_at = excluded.revoked_at,
+			expires = excluded.expires
+	`
+	_, err := r.db.ExecContext(ctx, query, revocation.ID, revocation.RevokedAt, revocation.Expires)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to save revocation",
+		)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// dbSessionRepo keeps sessions in the database instead of Redis. Expired sessions are not found,
+// and they are removed by DeleteExpired which must be called periodically.
+type dbSessionRepo struct {
+	db    conn
+	clock clock.Clock
+}
+
+func (r *dbSessionRepo) Find(ctx context.Context, id string) (service.SessionData, error) {
+	const query = "SELECT id, userid, expires FROM auth.sessions WHERE id = $1 AND expires > $2"
+
+	session := service.SessionData{}
+	err := r.db.QueryRowContext(ctx, query, id, r.clock.Now().Unix()).Scan(&session.ID, &session.UserID, &session.Expires)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return session, cerror.NewNotFoundError(
+			err,
+			fmt.Sprintf("not found a session(%v)", id),
+		)
+	case err != nil:
+		return session, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get session",
+		)
+	}
+
+	return session, nil
+}
+
+func (r *dbSessionRepo) Create(ctx context.Context, session service.SessionData) error {
+	const query = "INSERT INTO auth.sessions (id, userid, expires) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING"
+
+	res, err := r.db.ExecContext(ctx, query, session.ID, session.UserID, session.Expires)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to create session",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewDuplicationError(
+			nil,
+			fmt.Sprintf("same key(%v)", session.ID),
+		)
+	}
+	return nil
+}
+
+func (r *dbSessionRepo) Delete(ctx context.Context, id string) error {
+	const query = "DELETE FROM auth.sessions WHERE id = $1 AND expires > $2"
+
+	res, err := r.db.ExecContext(ctx, query, id, r.clock.Now().Unix())
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete session",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found session(%v)", id),
+		)
+	}
+	return nil
+}
+
+func (r *dbSessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	const query = "DELETE FROM auth.sessions WHERE userid = $1"
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete sessions",
+		)
+	}
+	return nil
+}
+
+// DeleteExpired removes expired sessions and returns the number of them.
+func (r *dbSessionRepo) DeleteExpired(ctx context.Context) (int, error) {
+	const query = "DELETE FROM auth.sessions WHERE expires <= $1"
+
+	res, err := r.db.ExecContext(ctx, query, r.clock.Now().Unix())
+	if err != nil {
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete expired sessions",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	return int(n), nil
+}
+
+// deleteExpired deletes rows in the table expired at now. table must not be given by users.
+func deleteExpired(ctx context.Context, db conn, table string, now time.Time) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM "+table+" WHERE expires <= $1", now.Unix())
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete expired rows",
+		)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// dbTokenRepo keeps tokens in the database instead of Redis. Expired tokens are not found,
+// and they are removed when tokens are created.
+type dbTokenRepo struct {
+	db    conn
+	clock clock.Clock
+}
+
+func (r *dbTokenRepo) Find(ctx context.Context, id string) (service.TokenData, error) {
+	const query = "SELECT id, userid, purpose, expires FROM auth.tokens WHERE id = $1 AND expires > $2"
+
+	token := service.TokenData{}
+	err := r.db.QueryRowContext(ctx, query, id, r.clock.Now().Unix()).Scan(&token.ID, &token.UserID, &token.Purpose, &token.Expires)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return token, cerror.NewNotFoundError(
+			err,
+			fmt.Sprintf("not found token(%v)", id),
+		)
+	case err != nil:
+		return token, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get token",
+		)
+	}
+
+	return token, nil
+}
+
+func (r *dbTokenRepo) Create(ctx context.Context, token service.TokenData) error {
+	if err := deleteExpired(ctx, r.db, "auth.tokens", r.clock.Now()); err != nil {
+		return err
+	}
+
+	const query = "INSERT INTO auth.tokens (id, userid, purpose, expires) VALUES ($1, $2, $3, $4)"
+	_, err := r.db.ExecContext(ctx, query, token.ID, token.UserID, token.Purpose, token.Expires)
+	if r.db.isDuplication(err) {
+		return cerror.NewDuplicationError(
+			err,
+			fmt.Sprintf("same key(%v)", token.ID),
+		)
+	} else if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to create token",
+		)
+	}
+	return nil
+}
+
+func (r *dbTokenRepo) Delete(ctx context.Context, id string) error {
+	const query = "DELETE FROM auth.tokens WHERE id = $1 AND expires > $2"
+
+	res, err := r.db.ExecContext(ctx, query, id, r.clock.Now().Unix())
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete token",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found token(%v)", id),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/x-color/calendar/auth/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type identityRepo struct {
+	db conn
+}
+
+func (r *identityRepo) Find(ctx context.Context, issuer, subject string) (service.IdentityData, error) {
+	stmt, err := r.db.PrepareContext(ctx, "SELECT issuer, subject, userid FROM auth.identities WHERE issuer = $1 AND subject = $2")
+	if err != nil {
+		return service.IdentityData{}, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to build prepare statement",
+		)
+	}
+	defer stmt.Close()
+
+	identity := service.IdentityData{}
+
+	err = stmt.QueryRowContext(ctx, issuer, subject).Scan(&identity.Issuer, &identity.Subject, &identity.UserID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return identity, cerror.NewNotFoundError(
+			err,
+			fmt.Sprintf("not found identity(%v, %v)", issuer, subject),
+		)
+	case err != nil:
+		return identity, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return identity, nil
+}
+
+func (r *identityRepo) Create(ctx context.Context, identity service.IdentityData) error {
+	stmt, err := r.db.PrepareContext(ctx, "INSERT INTO auth.identities (issuer, subject, userid) VALUES ($1, $2, $3)")
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to build prepare statement",
+		)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, identity.Issuer, identity.Subject, identity.UserID)
+	if r.db.isDuplication(err) {
+		return cerror.NewDuplicationError(
+			err,
+			fmt.Sprintf("same key(%v, %v)", identity.Issuer, identity.Subject),
+		)
+	} else if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+const (
+	revocationKeyPrefix = "revocation:"
+	// revocationsKey is the sorted set of IDs of revocations scored by their expiry.
+	// Expired IDs are removed when revocations are listed.
+	revocationsKey = "revocations"
+)
+
+type revocationRepo struct {
+	rdb   *redis.Client
+	clock clock.Clock
+}
+
+func (r *revocationRepo) Find(ctx context.Context, ids []string) ([]service.RevocationData, error) {
+	cmds := make([]*redis.StringStringMapCmd, len(ids))
+	_, err := r.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, revocationKeyPrefix+id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get revocations",
+		)
+	}
+
+	revocations := []service.RevocationData{}
+	for i, cmd := range cmds {
+		m := cmd.Val()
+		if len(m) == 0 {
+			continue
+		}
+		revocation := service.RevocationData{ID: ids[i]}
+		revocation.RevokedAt, err = strconv.ParseInt(m["revoked_at"], 10, 64)
+		if err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to parse revoked_at",
+			)
+		}
+		revocation.Expires, err = strconv.ParseInt(m["expires"], 10, 64)
+		if err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to parse expires",
+			)
+		}
+		revocations = append(revocations, revocation)
+	}
+	return revocations, nil
+}
+
+func (r *revocationRepo) FindSince(ctx context.Context, revokedAt int64) ([]service.RevocationData, error) {
+	now := strconv.FormatInt(r.clock.Now().Unix(), 10)
+	var cmd *redis.StringSliceCmd
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, revocationsKey, "-inf", now)
+		cmd = pipe.ZRangeByScore(ctx, revocationsKey, &redis.ZRangeBy{Min: "(" + now, Max: "+inf"})
+		return nil
+	})
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get IDs of revocations",
+		)
+	}
+
+	l, err := r.Find(ctx, cmd.Val())
+	if err != nil {
+		return nil, err
+	}
+	revocations := []service.RevocationData{}
+	for _, revocation := range l {
+		if revokedAt <= revocation.RevokedAt {
+			revocations = append(revocations, revocation)
+		}
+	}
+	return revocations, nil
+}
+
+func (r *revocationRepo) Save(ctx context.Context, revocation service.RevocationData) error {
+	key := revocationKeyPrefix + revocation.ID
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"revoked_at", revocation.RevokedAt,
+			"expires", revocation.Expires,
+		)
+		pipe.ExpireAt(ctx, key, time.Unix(revocation.Expires, 0))
+		pipe.ZAdd(ctx, revocationsKey, &redis.Z{Score: float64(revocation.Expires), Member: revocation.ID})
+		return nil
+	})
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to save revocation",
+		)
+	}
+	return nil
+}
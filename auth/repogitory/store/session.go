@@ -9,15 +9,27 @@ import (
 	"github.com/go-redis/redis/v8"
 
 	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
 	cerror "github.com/x-color/calendar/model/error"
 )
 
+// userSessionsKeyPrefix is prefix of keys of sets holding session IDs of each user.
+// The sets may have IDs of expired sessions until the latest session expires.
+const userSessionsKeyPrefix = "user_sessions:"
+
 type sessionRepo struct {
-	rdb *redis.Client
+	rdb   *redis.Client
+	clock clock.Clock
 }
 
 func (r *sessionRepo) Find(ctx context.Context, id string) (service.SessionData, error) {
-	userID, err := r.rdb.Get(ctx, id).Result()
+	var get *redis.StringCmd
+	var ttl *redis.DurationCmd
+	_, err := r.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, id)
+		ttl = pipe.PTTL(ctx, id)
+		return nil
+	})
 	switch {
 	case errors.Is(err, redis.Nil):
 		return service.SessionData{}, cerror.NewNotFoundError(
@@ -25,21 +37,25 @@ func (r *sessionRepo) Find(ctx context.Context, id string) (service.SessionData,
 			fmt.Sprintf("not found a session(%v)", id),
 		)
 	case err != nil:
-		return service.SessionData{}, cerror.NewInternalError(
+		return service.SessionData{}, cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to get session",
 		)
 	}
 
-	return service.SessionData{id, userID, time.Now().Add(time.Hour).Unix()}, nil
+	// Sessions expire at the second when the key expires.
+	expires := r.clock.Now().Add(ttl.Val()).Round(time.Second).Unix()
+	return service.SessionData{ID: id, UserID: get.Val(), Expires: expires}, nil
 }
 
 func (r *sessionRepo) Create(ctx context.Context, session service.SessionData) error {
-	duration := time.Unix(session.Expires, 0).Sub(time.Now())
+	duration := time.Unix(session.Expires, 0).Sub(r.clock.Now())
 	set, err := r.rdb.SetNX(ctx, session.ID, session.UserID, duration).Result()
 	switch {
 	case err != nil:
-		return cerror.NewInternalError(
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to check same session already exists",
 		)
@@ -50,10 +66,53 @@ func (r *sessionRepo) Create(ctx context.Context, session service.SessionData) e
 		)
 	}
 
+	key := userSessionsKeyPrefix + session.UserID
+	ttl, err := r.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get expiry of sessions of user",
+		)
+	}
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, session.ID)
+		// The set lives until the latest session expires, even if the session expires earlier.
+		if duration > ttl {
+			pipe.Expire(ctx, key, duration)
+		}
+		return nil
+	})
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to index session",
+		)
+	}
+
 	return nil
 }
 
 func (r *sessionRepo) Delete(ctx context.Context, id string) error {
+	userID, err := r.rdb.Get(ctx, id).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get session",
+		)
+	}
+	if userID != "" {
+		if err := r.rdb.SRem(ctx, userSessionsKeyPrefix+userID, id).Err(); err != nil {
+			return cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to unindex session",
+			)
+		}
+	}
+
 	n, err := r.rdb.Del(ctx, id).Result()
 	switch {
 	case n == 0:
@@ -62,10 +121,39 @@ func (r *sessionRepo) Delete(ctx context.Context, id string) error {
 			fmt.Sprintf("not found session(%v)", id),
 		)
 	case err != nil:
-		return cerror.NewInternalError(
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to delete session",
 		)
 	}
 	return nil
 }
+
+func (r *sessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	key := userSessionsKeyPrefix + userID
+	ids, err := r.rdb.SMembers(ctx, key).Result()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get sessions",
+		)
+	}
+
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(ids) != 0 {
+			pipe.Del(ctx, ids...)
+		}
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete sessions",
+		)
+	}
+	return nil
+}
@@ -1,15 +1,110 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	"github.com/x-color/calendar/dialect"
+	cerror "github.com/x-color/calendar/model/error"
 )
 
+// querier runs queries on the database or in a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn runs queries on the database, or in the transaction if it is in one.
+// Queries are written for PostgreSQL and rewritten for the dialect of the database.
+type conn struct {
+	db *sql.DB
+	tx *sql.Tx
+	d  dialect.Dialect
+}
+
+func (c conn) querier() querier {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.querier().ExecContext(ctx, c.d.Rebind(query), args...)
+}
+
+func (c conn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.querier().PrepareContext(ctx, c.d.Rebind(query))
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.querier().QueryContext(ctx, c.d.Rebind(query), args...)
+}
+
+func (c conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.querier().QueryRowContext(ctx, c.d.Rebind(query), args...)
+}
+
+// isDuplication tells whether err is caused by a primary key or an unique constraint.
+func (c conn) isDuplication(err error) bool {
+	return c.d.IsDuplication(err)
+}
+
+// transaction runs f in a transaction committed if f returns no error.
+// It joins the transaction if c is already in one.
+func (c conn) transaction(ctx context.Context, f func(q conn) error) error {
+	if c.tx != nil {
+		return f(c)
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to begin transaction",
+		)
+	}
+
+	if err := f(conn{db: c.db, tx: tx, d: c.d}); err != nil {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			return cerror.NewInternalError(
+				rerr,
+				"failed to rollback transaction",
+			)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			return cerror.NewInternalError(
+				rerr,
+				"failed to commit and rollback",
+			)
+		}
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to commit transaction",
+		)
+	}
+	return nil
+}
+
 type rds struct {
-	userRepo    userRepo
-	sessionRepo sessionRepo
+	userRepo       userRepo
+	sessionRepo    sessionRepo
+	attemptRepo    attemptRepo
+	identityRepo   identityRepo
+	tokenRepo      tokenRepo
+	revocationRepo revocationRepo
 }
 
 func (m *rds) User() service.UserRepogitory {
@@ -20,15 +115,66 @@ func (m *rds) Session() service.SessionRepogitory {
 	return &m.sessionRepo
 }
 
+func (m *rds) Attempt() service.AttemptRepogitory {
+	return &m.attemptRepo
+}
+
+func (m *rds) Identity() service.IdentityRepogitory {
+	return &m.identityRepo
+}
+
+func (m *rds) Token() service.TokenRepogitory {
+	return &m.tokenRepo
+}
+
+func (m *rds) Revocation() service.RevocationRepogitory {
+	return &m.revocationRepo
+}
+
+// Transaction runs users and identities in a database transaction. Data in Redis are not in it.
+func (m *rds) Transaction(ctx context.Context, f func(service.Repogitory) error) error {
+	return m.userRepo.db.transaction(ctx, func(q conn) error {
+		t := *m
+		t.userRepo = userRepo{db: q}
+		t.identityRepo = identityRepo{db: q}
+		return f(&t)
+	})
+}
+
+// SetClock sets the clock which expiry of data is checked with.
+func (m *rds) SetClock(c clock.Clock) {
+	m.sessionRepo.clock = c
+	m.revocationRepo.clock = c
+}
+
 func NewRepogitory(pdb *sql.DB, rdb *redis.Client) rds {
+	db := conn{db: pdb, d: dialect.PostgreSQL}
 	u := userRepo{
-		db: pdb,
+		db: db,
 	}
 	s := sessionRepo{
+		rdb:   rdb,
+		clock: clock.Real,
+	}
+	a := attemptRepo{
 		rdb: rdb,
 	}
+	i := identityRepo{
+		db: db,
+	}
+	t := tokenRepo{
+		rdb: rdb,
+	}
+	rv := revocationRepo{
+		rdb:   rdb,
+		clock: clock.Real,
+	}
 	return rds{
-		userRepo:    u,
-		sessionRepo: s,
+		userRepo:       u,
+		sessionRepo:    s,
+		attemptRepo:    a,
+		identityRepo:   i,
+		tokenRepo:      t,
+		revocationRepo: rv,
 	}
 }
@@ -0,0 +1,149 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/x-color/calendar/auth/repogitory/repotest"
+	"github.com/x-color/calendar/auth/repogitory/store"
+	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/clock"
+	"github.com/x-color/calendar/migration"
+)
+
+// redisClock advances the time of Redis as well by shortening expiry of all keys,
+// because Redis expires keys by itself.
+type redisClock struct {
+	*clock.Fake
+	rdb *redis.Client
+	t   *testing.T
+}
+
+func (c redisClock) Advance(d time.Duration) {
+	c.Fake.Advance(d)
+
+	ctx := context.Background()
+	keys, err := c.rdb.Keys(ctx, "*").Result()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for _, key := range keys {
+		ttl, err := c.rdb.PTTL(ctx, key).Result()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		switch {
+		case ttl < 0:
+			// The key has no expiry or is already expired.
+		case ttl <= d:
+			err = c.rdb.Del(ctx, key).Err()
+		default:
+			err = c.rdb.PExpire(ctx, key, ttl-d).Err()
+		}
+		if err != nil {
+			c.t.Fatal(err)
+		}
+	}
+}
+
+func TestRepogitory(t *testing.T) {
+	if os.Getenv("TEST_DB") == "sqlite" {
+		t.Skip("tests run on SQLite")
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=testuser password=password dbname=calendar sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	m, err := migration.NewMigrator(db, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	clean := func(t *testing.T) {
+		for _, table := range []string{"auth.users", "auth.attempts", "auth.tokens", "auth.revocations"} {
+			if _, err := db.Exec("DELETE FROM " + table); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("Redis", func(t *testing.T) {
+		rdb := redis.NewClient(&redis.Options{
+			Addr: "localhost:6379",
+		})
+		defer rdb.Close()
+		if err := rdb.Ping(context.Background()).Err(); err != nil {
+			t.Skipf("Redis is not available: %v", err)
+		}
+
+		repotest.Run(t, func(t *testing.T) (service.Repogitory, repotest.Clock) {
+			clean(t)
+			if err := rdb.FlushDB(context.Background()).Err(); err != nil {
+				t.Fatal(err)
+			}
+			c := redisClock{clock.NewFake(time.Now()), rdb, t}
+			r := store.NewRepogitory(db, rdb)
+			r.SetClock(c)
+			return &r, c
+		})
+	})
+	// All data are kept in PostgreSQL without Redis with the database session store.
+	t.Run("Database", func(t *testing.T) {
+		repotest.Run(t, func(t *testing.T) (service.Repogitory, repotest.Clock) {
+			clean(t)
+			c := clock.NewFake(time.Now())
+			r := store.NewDatabaseRepogitory(db)
+			r.SetClock(c)
+			return &r, c
+		})
+	})
+}
+
+func TestSQLiteRepogitory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (service.Repogitory, repotest.Clock) {
+		c := clock.NewFake(time.Now())
+		r := store.NewSQLiteRepogitory(openSQLite(t))
+		r.SetClock(c)
+		return &r, c
+	})
+}
+
+// openSQLite opens a migrated database in a temporary directory with the same options as the server.
+func openSQLite(t *testing.T) *sql.DB {
+	dir, err := ioutil.TempDir("", "calendar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(dir, "calendar.db")+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.RemoveAll(dir)
+	})
+
+	m, err := migration.NewMigrator(db, "sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
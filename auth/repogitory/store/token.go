@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/x-color/calendar/auth/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+const tokenKeyPrefix = "token:"
+
+type tokenRepo struct {
+	rdb *redis.Client
+}
+
+func (r *tokenRepo) Find(ctx context.Context, id string) (service.TokenData, error) {
+	m, err := r.rdb.HGetAll(ctx, tokenKeyPrefix+id).Result()
+	switch {
+	case err != nil:
+		return service.TokenData{}, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get token",
+		)
+	case len(m) == 0:
+		return service.TokenData{}, cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found token(%v)", id),
+		)
+	}
+
+	expires, err := strconv.ParseInt(m["expires"], 10, 64)
+	if err != nil {
+		return service.TokenData{}, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to parse expires",
+		)
+	}
+
+	return service.TokenData{
+		ID:      id,
+		UserID:  m["userid"],
+		Purpose: m["purpose"],
+		Expires: expires,
+	}, nil
+}
+
+func (r *tokenRepo) Create(ctx context.Context, token service.TokenData) error {
+	key := tokenKeyPrefix + token.ID
+	n, err := r.rdb.Exists(ctx, key).Result()
+	switch {
+	case err != nil:
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to check same token already exists",
+		)
+	case n > 0:
+		return cerror.NewDuplicationError(
+			nil,
+			fmt.Sprintf("same key(%v)", token.ID),
+		)
+	}
+
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"userid", token.UserID,
+			"purpose", token.Purpose,
+			"expires", token.Expires,
+		)
+		pipe.ExpireAt(ctx, key, time.Unix(token.Expires, 0))
+		return nil
+	})
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to create token",
+		)
+	}
+	return nil
+}
+
+func (r *tokenRepo) Delete(ctx context.Context, id string) error {
+	n, err := r.rdb.Del(ctx, tokenKeyPrefix+id).Result()
+	switch {
+	case err != nil:
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to delete token",
+		)
+	case n == 0:
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found token(%v)", id),
+		)
+	}
+	return nil
+}
@@ -5,19 +5,70 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/dialect"
 	cerror "github.com/x-color/calendar/model/error"
 )
 
 type userRepo struct {
-	db *sql.DB
+	db conn
+}
+
+func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error) {
+	return r.findBy(ctx, "id", id)
+}
+
+func (r *userRepo) FindUsers(ctx context.Context, ids []string) ([]service.UserData, error) {
+	if len(ids) == 0 {
+		return []service.UserData{}, nil
+	}
+
+	list, args := dialect.InList(1, ids)
+	stmt, err := r.db.PrepareContext(ctx, "SELECT id, name, password, email, verified, admin, disabled FROM auth.users WHERE id IN ("+list+") ORDER BY id")
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to build prepare statement",
+		)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	return scanUsers(ctx, rows)
 }
 
 func (r *userRepo) FindByName(ctx context.Context, name string) (service.UserData, error) {
-	stmt, err := r.db.Prepare("SELECT id, name, password FROM auth.users WHERE name = $1")
+	return r.findBy(ctx, "name", name)
+}
+
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (service.UserData, error) {
+	if email == "" {
+		return service.UserData{}, cerror.NewNotFoundError(
+			nil,
+			"email is empty",
+		)
+	}
+	return r.findBy(ctx, "email", email)
+}
+
+// findBy finds a user by the column. column must not be given by users.
+func (r *userRepo) findBy(ctx context.Context, column, value string) (service.UserData, error) {
+	stmt, err := r.db.PrepareContext(ctx, "SELECT id, name, password, email, verified, admin, disabled FROM auth.users WHERE "+column+" = $1")
 	if err != nil {
-		return service.UserData{}, cerror.NewInternalError(
+		return service.UserData{}, cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to build prepare statement",
 		)
@@ -26,15 +77,16 @@ func (r *userRepo) FindByName(ctx context.Context, name string) (service.UserDat
 
 	user := service.UserData{}
 
-	err = stmt.QueryRow(name).Scan(&user.ID, &user.Name, &user.Password)
+	err = stmt.QueryRowContext(ctx, value).Scan(&user.ID, &user.Name, &user.Password, &user.Email, &user.Verified, &user.Admin, &user.Disabled)
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
 		return user, cerror.NewNotFoundError(
 			err,
-			fmt.Sprintf("not found a user has the name(%v)", name),
+			fmt.Sprintf("not found a user has the %v(%v)", column, value),
 		)
 	case err != nil:
-		return user, cerror.NewInternalError(
+		return user, cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to scan query result",
 		)
@@ -43,22 +95,126 @@ func (r *userRepo) FindByName(ctx context.Context, name string) (service.UserDat
 	return user, nil
 }
 
+func (r *userRepo) Search(ctx context.Context, query string, offset, limit int) ([]service.UserData, error) {
+	// Both sides are lowered because LIKE of PostgreSQL does not ignore cases as SQLite does.
+	stmt, err := r.db.PrepareContext(ctx, `
+		SELECT id, name, password, email, verified, admin, disabled
+		FROM auth.users
+		WHERE LOWER(name) LIKE LOWER($1) ESCAPE '\' OR LOWER(email) LIKE LOWER($1) ESCAPE '\'
+		ORDER BY name
+		LIMIT $3 OFFSET $2
+	`)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to build prepare statement",
+		)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, "%"+likeEscaper.Replace(query)+"%", offset, limit)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	return scanUsers(ctx, rows)
+}
+
+// scanUsers returns users selected by rows.
+func scanUsers(ctx context.Context, rows *sql.Rows) ([]service.UserData, error) {
+	users := []service.UserData{}
+	for rows.Next() {
+		user := service.UserData{}
+		err := rows.Scan(&user.ID, &user.Name, &user.Password, &user.Email, &user.Verified, &user.Admin, &user.Disabled)
+		if err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return users, nil
+}
+
+// likeEscaper escapes wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (r *userRepo) Create(ctx context.Context, user service.UserData) error {
-	stmt, err := r.db.Prepare("INSERT INTO auth.users (id, name, password) VALUES ($1, $2, $3)")
+	stmt, err := r.db.PrepareContext(ctx, "INSERT INTO auth.users (id, name, password, email, verified, admin, disabled) VALUES ($1, $2, $3, $4, $5, $6, $7)")
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to build prepare statement",
+		)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, user.ID, user.Name, user.Password, user.Email, user.Verified, user.Admin, user.Disabled)
+	if r.db.isDuplication(err) {
+		return cerror.NewDuplicationError(
+			err,
+			fmt.Sprintf("same key(%v)", user.ID),
+		)
+	} else if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	return nil
+}
+
+func (r *userRepo) Update(ctx context.Context, user service.UserData) error {
+	stmt, err := r.db.PrepareContext(ctx, "UPDATE auth.users SET name = $1, password = $2, email = $3, verified = $4, admin = $5, disabled = $6 WHERE id = $7")
 	if err != nil {
-		return cerror.NewInternalError(
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to build prepare statement",
 		)
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(user.ID, user.Name, user.Password)
+	res, err := stmt.ExecContext(ctx, user.Name, user.Password, user.Email, user.Verified, user.Admin, user.Disabled, user.ID)
 	if err != nil {
-		return cerror.NewInternalError(
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to query",
 		)
 	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found user(%v)", user.ID),
+		)
+	}
 	return nil
 }
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/x-color/calendar/auth/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// maxSearchLimit is the maximum number of users returned by a search.
+const maxSearchLimit = 100
+
+func (s *Service) IsAdmin(ctx context.Context, userID string) (bool, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	admin, err := s.isAdmin(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to check admin: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Check user(%v) is admin: %v", userID, admin))
+	}
+
+	return admin, err
+}
+
+func (s *Service) isAdmin(ctx context.Context, userID string) (bool, error) {
+	user, err := s.repo.User().Find(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.Admin && !user.Disabled, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, userID string) (model.User, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	user, err := s.getUser(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get user: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get user(%v)", userID))
+	}
+
+	return user, err
+}
+
+func (s *Service) getUser(ctx context.Context, userID string) (model.User, error) {
+	user, err := s.repo.User().Find(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	return user.model(), nil
+}
+
+func (s *Service) SearchUsers(ctx context.Context, query string, offset, limit int) ([]model.User, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	users, err := s.searchUsers(ctx, query, offset, limit)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to search users: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Search users(%q): %v found", query, len(users)))
+	}
+
+	return users, err
+}
+
+func (s *Service) searchUsers(ctx context.Context, query string, offset, limit int) ([]model.User, error) {
+	if offset < 0 {
+		return nil, cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("invalid offset(%v)", offset),
+		)
+	}
+	if limit < 1 || maxSearchLimit < limit {
+		return nil, cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("limit(%v) must be between 1 and %v", limit, maxSearchLimit),
+		)
+	}
+
+	ul, err := s.repo.User().Search(ctx, query, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]model.User, len(ul))
+	for i, u := range ul {
+		users[i] = u.model()
+	}
+	return users, nil
+}
+
+// DisableUser disables or enables the user. Sessions of disabled users are deleted.
+func (s *Service) DisableUser(ctx context.Context, adminID, userID string, disabled bool) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.disableUser(ctx, adminID, userID, disabled)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to change user disabled: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Admin(%v) changes user(%v) disabled: %v", adminID, userID, disabled))
+	}
+
+	return err
+}
+
+func (s *Service) disableUser(ctx context.Context, adminID, userID string, disabled bool) error {
+	if adminID == userID {
+		return cerror.NewInvalidContentError(
+			nil,
+			"admin can not disable itself",
+		)
+	}
+
+	user, err := s.repo.User().Find(ctx, userID)
+	if err != nil {
+		return err
+	}
+	user.Disabled = disabled
+	if err := s.repo.User().Update(ctx, user); err != nil {
+		return err
+	}
+
+	if disabled {
+		return s.deleteSessions(ctx, userID)
+	}
+	return nil
+}
+
+// SignoutUser deletes all sessions of the user.
+func (s *Service) SignoutUser(ctx context.Context, adminID, userID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.signoutUser(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to sign out user: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Admin(%v) signs out user(%v)", adminID, userID))
+	}
+
+	return err
+}
+
+func (s *Service) signoutUser(ctx context.Context, userID string) error {
+	if _, err := s.repo.User().Find(ctx, userID); err != nil {
+		return err
+	}
+	return s.deleteSessions(ctx, userID)
+}
+
+// ResetUserPassword invalidates the password of the user and sends a link to set new one.
+func (s *Service) ResetUserPassword(ctx context.Context, adminID, userID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.resetUserPassword(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to reset password of user: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Admin(%v) resets password of user(%v)", adminID, userID))
+	}
+
+	return err
+}
+
+func (s *Service) resetUserPassword(ctx context.Context, userID string) error {
+	if s.mailer == nil {
+		return cerror.NewInternalError(
+			nil,
+			"mailer is not configured",
+		)
+	}
+
+	user, err := s.repo.User().Find(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("user(%v) has no email", userID),
+		)
+	}
+
+	// An empty password matches no hashers. So nobody can sign in with the old password.
+	user.Password = ""
+	if err := s.repo.User().Update(ctx, user); err != nil {
+		return err
+	}
+	if err := s.deleteSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.sendPasswordReset(ctx, user)
+}
@@ -8,28 +8,63 @@ import (
 	"time"
 
 	"github.com/x-color/calendar/auth/model"
+	"github.com/x-color/calendar/clock"
 	"github.com/x-color/calendar/logging"
+	"github.com/x-color/calendar/mail"
 	cctx "github.com/x-color/calendar/model/ctx"
 	cerror "github.com/x-color/calendar/model/error"
 )
 
 type Service struct {
-	repo Repogitory
-	log  logging.Logger
+	repo      Repogitory
+	log       logging.Logger
+	hasher    Hasher
+	policy    PasswordPolicy
+	breached  BreachedPasswords
+	idp       IdentityProvider
+	registrar UserRegistrar
+	mailer    mail.Mailer
+	baseURL   string
+	lifetime  time.Duration
+	tokens    SessionTokens
+	clock     clock.Clock
 }
 
+// DefaultSessionLifetime is how long sessions are valid after sign in.
+const DefaultSessionLifetime = 30 * 24 * time.Hour
+
 func NewService(repo Repogitory, log logging.Logger) Service {
 	return Service{
-		repo: repo,
-		log:  log,
+		repo:     repo,
+		log:      log,
+		hasher:   NewArgon2idHasher(DefaultArgon2idParams),
+		policy:   DefaultPasswordPolicy,
+		lifetime: DefaultSessionLifetime,
+		clock:    clock.Real,
 	}
 }
 
-func (s *Service) Signup(ctx context.Context, name, password string) (model.User, error) {
+// SetPasswordHasher sets hasher for new passwords. Hashes made by other hashers are
+// still verified and replaced on next sign in.
+func (s *Service) SetPasswordHasher(hasher Hasher) {
+	s.hasher = hasher
+}
+
+// SetClock sets the clock telling the time of sessions, tokens and lockouts.
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetSessionLifetime sets how long sessions made after it are valid.
+func (s *Service) SetSessionLifetime(lifetime time.Duration) {
+	s.lifetime = lifetime
+}
+
+func (s *Service) Signup(ctx context.Context, name, password, email string) (model.User, error) {
 	reqID := ctx.Value(cctx.ReqIDKey).(string)
 	s.log = s.log.Uniq(reqID)
 
-	user, err := s.signup(ctx, name, password)
+	user, err := s.signup(ctx, name, password, email)
 
 	if err != nil {
 		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
@@ -45,12 +80,26 @@ func (s *Service) Signup(ctx context.Context, name, password string) (model.User
 	return user, err
 }
 
-func (s *Service) signup(ctx context.Context, name, password string) (model.User, error) {
-	if err := validateSigninInfo(name, password); err != nil {
+func (s *Service) signup(ctx context.Context, name, password, email string) (model.User, error) {
+	if err := s.validateSigninInfo(name, password); err != nil {
+		return model.User{}, err
+	}
+
+	if err := validateEmail(email); err != nil {
+		return model.User{}, err
+	}
+	_, err := s.repo.User().FindByEmail(ctx, email)
+	if err == nil {
+		return model.User{}, cerror.NewDuplicationError(
+			nil,
+			"email is already used",
+		)
+	}
+	if !errors.Is(err, cerror.ErrNotFound) {
 		return model.User{}, err
 	}
 
-	_, err := s.repo.User().FindByName(ctx, name)
+	_, err = s.repo.User().FindByName(ctx, name)
 	if err == nil {
 		return model.User{}, cerror.NewDuplicationError(
 			nil,
@@ -61,27 +110,32 @@ func (s *Service) signup(ctx context.Context, name, password string) (model.User
 		return model.User{}, err
 	}
 
-	hash, err := passwordHash(password)
+	hash, err := s.hashPassword(password)
 	if err != nil {
-		return model.User{}, cerror.NewInternalError(
-			err,
-			"failed to hash password",
-		)
+		return model.User{}, err
 	}
 
-	user := model.NewUser(name, hash)
+	user := model.NewUser(name, hash, email)
 	err = s.repo.User().Create(ctx, newUserData(user))
 	if err != nil {
 		return model.User{}, err
 	}
+
+	// The account is already created. So failure to send the mail is not returned.
+	// The user can request the mail again.
+	if err := s.sendVerification(ctx, user); err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		s.log.Error(fmt.Sprintf("Failed to send verification mail: %v", msg))
+	}
+
 	return user, nil
 }
 
-func (s *Service) Signin(ctx context.Context, name, password string) (model.Session, error) {
+func (s *Service) Signin(ctx context.Context, name, password, ip string) (model.Session, error) {
 	reqID := ctx.Value(cctx.ReqIDKey).(string)
 	s.log = s.log.Uniq(reqID)
 
-	session, err := s.signin(ctx, name, password)
+	session, err := s.signin(ctx, name, password, ip)
 
 	if err != nil {
 		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
@@ -97,13 +151,21 @@ func (s *Service) Signin(ctx context.Context, name, password string) (model.Sess
 	return session, err
 }
 
-func (s *Service) signin(ctx context.Context, name, password string) (model.Session, error) {
-	if err := validateSigninInfo(name, password); err != nil {
+func (s *Service) signin(ctx context.Context, name, password, ip string) (model.Session, error) {
+	if err := validateCredentials(name, password); err != nil {
+		return model.Session{}, err
+	}
+
+	keys := signinAttemptKeys(name, ip)
+	if err := s.checkLockout(ctx, keys); err != nil {
 		return model.Session{}, err
 	}
 
 	user, err := s.repo.User().FindByName(ctx, name)
 	if errors.Is(err, cerror.ErrNotFound) {
+		if err := s.recordFailure(ctx, keys); err != nil {
+			return model.Session{}, err
+		}
 		return model.Session{}, cerror.NewAuthorizationError(
 			err,
 			"user not found",
@@ -113,13 +175,58 @@ func (s *Service) signin(ctx context.Context, name, password string) (model.Sess
 	}
 
 	if err := verifyPassword(user.Password, password); err != nil {
+		if err := s.recordFailure(ctx, keys); err != nil {
+			return model.Session{}, err
+		}
 		return model.Session{}, cerror.NewAuthorizationError(
 			err,
 			"password is not correct",
 		)
 	}
 
-	session := model.NewSession(user.ID, time.Now().AddDate(0, 1, 0))
+	// Failures from the client IP are kept. Otherwise, signing in to an own account
+	// would reset the counter for guessing passwords of other accounts.
+	if err := s.resetFailures(ctx, keys[:1]); err != nil {
+		return model.Session{}, err
+	}
+
+	// The plain password is available only here. So outdated hashes are replaced now.
+	// Signing in does not fail even if it fails because the old hash is still valid.
+	if s.hasher.NeedsRehash(user.Password) {
+		if err := s.rehashPassword(ctx, user, password); err != nil {
+			msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+			s.log.Error(fmt.Sprintf("Failed to rehash password: %v", msg))
+		}
+	}
+
+	return s.newSession(ctx, user.ID)
+}
+
+func (s *Service) rehashPassword(ctx context.Context, user UserData, password string) error {
+	hash, err := s.hashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+	return s.repo.User().Update(ctx, user)
+}
+
+func (s *Service) newSession(ctx context.Context, userID string) (model.Session, error) {
+	user, err := s.repo.User().Find(ctx, userID)
+	if err != nil {
+		return model.Session{}, err
+	}
+	if user.Disabled {
+		return model.Session{}, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) is disabled", userID),
+		)
+	}
+
+	session := model.NewSession(userID, s.clock.Now().Add(s.lifetime))
+	if s.tokens != nil {
+		return s.sealSession(session)
+	}
 	err = s.repo.Session().Create(ctx, newSessionData(session))
 	if err != nil {
 		return model.Session{}, err
@@ -149,6 +256,10 @@ func (s *Service) Signout(ctx context.Context, id string) error {
 }
 
 func (s *Service) signout(ctx context.Context, sessionID string) error {
+	if s.tokens != nil {
+		return s.revokeSession(ctx, sessionID)
+	}
+
 	err := s.repo.Session().Delete(ctx, sessionID)
 	if errors.Is(err, cerror.ErrNotFound) {
 		return cerror.NewAuthorizationError(
@@ -183,7 +294,7 @@ func (s *Service) Authorize(ctx context.Context, id string) (string, error) {
 }
 
 func (s *Service) authorize(ctx context.Context, sessionID string) (string, error) {
-	session, err := s.repo.Session().Find(ctx, sessionID)
+	session, err := s.findSession(ctx, sessionID)
 	if errors.Is(err, cerror.ErrNotFound) {
 		return "", cerror.NewAuthorizationError(
 			err,
@@ -193,12 +304,83 @@ func (s *Service) authorize(ctx context.Context, sessionID string) (string, erro
 		return "", err
 	}
 
-	if time.Now().After(session.model().Expires) {
+	if s.clock.Now().After(session.model().Expires) {
 		return "", cerror.NewAuthorizationError(
 			nil,
 			fmt.Sprintf("session(%v) is already expired", session.ID),
 		)
 	}
 
+	// Disabling the user revokes all session tokens of the user. So the user is not looked up
+	// with session tokens, which are meant not to query storages on every request.
+	if s.tokens != nil {
+		return session.UserID, nil
+	}
+
+	// Sessions made before the user is disabled are rejected too.
+	user, err := s.repo.User().Find(ctx, session.UserID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return "", cerror.NewAuthorizationError(
+			err,
+			fmt.Sprintf("user(%v) of session(%v) is not found", session.UserID, session.ID),
+		)
+	} else if err != nil {
+		return "", err
+	}
+	if user.Disabled {
+		return "", cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) is disabled", user.ID),
+		)
+	}
+
 	return session.UserID, nil
 }
+
+// findSession finds the session in the session store, or opens it from the token with session tokens.
+func (s *Service) findSession(ctx context.Context, sessionID string) (SessionData, error) {
+	if s.tokens == nil {
+		return s.repo.Session().Find(ctx, sessionID)
+	}
+	claims, err := s.openSession(ctx, sessionID)
+	if err != nil {
+		return SessionData{}, err
+	}
+	return SessionData{
+		ID:      claims.ID,
+		UserID:  claims.UserID,
+		Expires: claims.Expires,
+	}, nil
+}
+
+// UserNames returns names of accounts of the users. Unknown users are not included.
+func (s *Service) UserNames(ctx context.Context, userIDs []string) (map[string]string, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	names, err := s.userNames(ctx, userIDs)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get user names: %v", msg))
+		}
+	}
+
+	return names, err
+}
+
+func (s *Service) userNames(ctx context.Context, userIDs []string) (map[string]string, error) {
+	users, err := s.repo.User().FindUsers(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	for _, user := range users {
+		names[user.ID] = user.Name
+	}
+	return names, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type lockoutPolicy struct {
+	// threshold is the number of failures allowed before locking out.
+	threshold int
+	// baseDelay is lockout duration at the threshold. It doubles with each further failure.
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	// window is how long failures are remembered after the last failure.
+	window time.Duration
+}
+
+var (
+	userLockout = lockoutPolicy{
+		threshold: 5,
+		baseDelay: time.Minute,
+		maxDelay:  time.Hour,
+		window:    24 * time.Hour,
+	}
+	ipLockout = lockoutPolicy{
+		threshold: 20,
+		baseDelay: time.Minute,
+		maxDelay:  time.Hour,
+		window:    24 * time.Hour,
+	}
+)
+
+func (p lockoutPolicy) delay(failures int) time.Duration {
+	n := failures - p.threshold
+	if n < 0 {
+		return 0
+	}
+	if n > 30 {
+		return p.maxDelay
+	}
+	d := p.baseDelay << uint(n)
+	if d > p.maxDelay || d <= 0 {
+		return p.maxDelay
+	}
+	return d
+}
+
+type attemptKey struct {
+	key    string
+	policy lockoutPolicy
+}
+
+func signinAttemptKeys(name, ip string) []attemptKey {
+	keys := []attemptKey{{key: "user:" + name, policy: userLockout}}
+	if ip != "" {
+		keys = append(keys, attemptKey{key: "ip:" + ip, policy: ipLockout})
+	}
+	return keys
+}
+
+func (s *Service) checkLockout(ctx context.Context, keys []attemptKey) error {
+	now := s.clock.Now()
+	var retryAfter time.Duration
+	var locked []string
+	for _, k := range keys {
+		a, err := s.repo.Attempt().Find(ctx, k.key)
+		if errors.Is(err, cerror.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		until := time.Unix(a.LockedUntil, 0)
+		if now.Before(until) {
+			locked = append(locked, k.key)
+			if d := until.Sub(now); d > retryAfter {
+				retryAfter = d
+			}
+		}
+	}
+
+	if len(locked) > 0 {
+		return cerror.NewTooManyAttemptsError(
+			nil,
+			fmt.Sprintf("%v locked out", locked),
+			retryAfter,
+		)
+	}
+	return nil
+}
+
+func (s *Service) recordFailure(ctx context.Context, keys []attemptKey) error {
+	now := s.clock.Now()
+	for _, k := range keys {
+		// Failures are counted atomically so that concurrent guesses are not lost.
+		failures, err := s.repo.Attempt().Increment(ctx, k.key, now.Add(k.policy.window).Unix())
+		if err != nil {
+			return err
+		}
+
+		if d := k.policy.delay(failures); d > 0 {
+			s.log.Info(fmt.Sprintf("Lock out %v for %v after %v failed attempts", k.key, d, failures))
+			if err := s.repo.Attempt().Lock(ctx, k.key, now.Add(d).Unix()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) resetFailures(ctx context.Context, keys []attemptKey) error {
+	for _, k := range keys {
+		err := s.repo.Attempt().Delete(ctx, k.key)
+		if err != nil && !errors.Is(err, cerror.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
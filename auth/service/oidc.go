@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/x-color/calendar/auth/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// IdentityProvider is an OpenID Connect provider authenticating users instead of passwords.
+type IdentityProvider interface {
+	AuthCodeURL(state, nonce, verifier string) string
+	Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error)
+}
+
+// Identity is a user authenticated by IdentityProvider.
+type Identity struct {
+	Issuer  string
+	Subject string
+	Name    string
+	// Email is empty if the provider does not verify it.
+	Email string
+}
+
+// UserRegistrar registers users made by identity providers in other services,
+// as the client does after signup with passwords.
+type UserRegistrar interface {
+	RegisterNewUser(ctx context.Context, userID string) error
+}
+
+// OIDCFlow is values the client keeps between OIDC login and its callback.
+type OIDCFlow struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+func (s *Service) SetIdentityProvider(idp IdentityProvider) {
+	s.idp = idp
+}
+
+// SetUserRegistrar sets registrar called when a user signs in with the provider for the first time.
+func (s *Service) SetUserRegistrar(registrar UserRegistrar) {
+	s.registrar = registrar
+}
+
+func (s *Service) OIDCLogin(ctx context.Context) (string, OIDCFlow, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	u, flow, err := s.oidcLogin(ctx)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to start OIDC login: %v", msg))
+		}
+	} else {
+		s.log.Info("Start OIDC login")
+	}
+
+	return u, flow, err
+}
+
+func (s *Service) oidcLogin(ctx context.Context) (string, OIDCFlow, error) {
+	if s.idp == nil {
+		return "", OIDCFlow{}, cerror.NewNotFoundError(
+			nil,
+			"identity provider is not configured",
+		)
+	}
+
+	flow := OIDCFlow{}
+	for _, v := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		r, err := randomString()
+		if err != nil {
+			return "", OIDCFlow{}, cerror.NewInternalError(
+				err,
+				"failed to generate random string",
+			)
+		}
+		*v = r
+	}
+
+	return s.idp.AuthCodeURL(flow.State, flow.Nonce, flow.Verifier), flow, nil
+}
+
+func (s *Service) OIDCCallback(ctx context.Context, flow OIDCFlow, state, code string) (model.Session, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	session, err := s.oidcCallback(ctx, flow, state, code)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to sign in with OIDC: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Sign in user(%v) with OIDC", session.UserID))
+	}
+
+	return session, err
+}
+
+func (s *Service) oidcCallback(ctx context.Context, flow OIDCFlow, state, code string) (model.Session, error) {
+	if s.idp == nil {
+		return model.Session{}, cerror.NewNotFoundError(
+			nil,
+			"identity provider is not configured",
+		)
+	}
+
+	if flow.State == "" || subtle.ConstantTimeCompare([]byte(flow.State), []byte(state)) != 1 {
+		return model.Session{}, cerror.NewAuthorizationError(
+			nil,
+			"state does not match",
+		)
+	}
+	if code == "" {
+		return model.Session{}, cerror.NewInvalidContentError(
+			nil,
+			"code is empty",
+		)
+	}
+
+	identity, err := s.idp.Exchange(ctx, code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	userID, err := s.linkedUser(ctx, identity)
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	return s.newSession(ctx, userID)
+}
+
+// linkedUser returns ID of the user linked to the identity.
+// A new user is created for an identity seen for the first time.
+func (s *Service) linkedUser(ctx context.Context, identity Identity) (string, error) {
+	i, err := s.repo.Identity().Find(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return i.UserID, nil
+	}
+	if !errors.Is(err, cerror.ErrNotFound) {
+		return "", err
+	}
+
+	// Existing users are not linked by name automatically. It would let
+	// the provider take over an account having same name.
+	_, err = s.repo.User().FindByName(ctx, identity.Name)
+	if err == nil {
+		return "", cerror.NewDuplicationError(
+			nil,
+			fmt.Sprintf("user(%v) already exists", identity.Name),
+		)
+	}
+	if !errors.Is(err, cerror.ErrNotFound) {
+		return "", err
+	}
+
+	email := identity.Email
+	if email != "" {
+		_, err := s.repo.User().FindByEmail(ctx, email)
+		if err == nil {
+			email = ""
+		} else if !errors.Is(err, cerror.ErrNotFound) {
+			return "", err
+		}
+	}
+
+	// The user has no password. So the user can sign in only with the provider.
+	// The user is trusted as verified because the provider authenticated the user.
+	user := model.NewUser(identity.Name, "", email)
+	user.Verified = true
+	// The user is not made without the identity, because the user could not sign in.
+	err = s.repo.Transaction(ctx, func(repo Repogitory) error {
+		if err := repo.User().Create(ctx, newUserData(user)); err != nil {
+			return err
+		}
+		return repo.Identity().Create(ctx, IdentityData{
+			Issuer:  identity.Issuer,
+			Subject: identity.Subject,
+			UserID:  user.ID,
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if s.registrar != nil {
+		if err := s.registrar.RegisterNewUser(ctx, user.ID); err != nil {
+			return "", err
+		}
+	}
+
+	return user.ID, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
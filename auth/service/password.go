@@ -1,60 +1,65 @@
 package service
 
 import (
-	"unicode"
+	"errors"
+	"net/mail"
 
 	cerror "github.com/x-color/calendar/model/error"
-	"golang.org/x/crypto/bcrypt"
 )
 
-func validateSigninInfo(name, password string) error {
+// maxPasswordLen bounds the cost of hashing. Hashers may limit it further.
+const maxPasswordLen = 1024
+
+// validateSigninInfo validates name and password of new users.
+func (s *Service) validateSigninInfo(name, password string) error {
+	if name == "" {
+		return cerror.NewInvalidContentError(
+			nil,
+			"name is empty",
+		)
+	}
+	return s.validatePassword(name, password)
+}
+
+// validateCredentials validates name and password to sign in. Passwords are not
+// checked by the policy because the policy may become stricter after sign up.
+func validateCredentials(name, password string) error {
 	if name == "" {
 		return cerror.NewInvalidContentError(
 			nil,
 			"name is empty",
 		)
 	}
-	if !isValidPassword(password) {
+	if password == "" {
 		return cerror.NewInvalidContentError(
 			nil,
-			"invalid password",
+			"password is empty",
 		)
 	}
 	return nil
 }
 
-func isValidPassword(password string) bool {
-	hasMinLen := false
-	hasUpper := false
-	hasLower := false
-	hasNumber := false
-	hasSpecial := false
-	if 7 < len(password) && len(password) < 73 {
-		hasMinLen = true
-	}
-	for _, char := range password {
-		switch {
-		case unicode.IsUpper(char):
-			hasUpper = true
-		case unicode.IsLower(char):
-			hasLower = true
-		case unicode.IsNumber(char):
-			hasNumber = true
-		case unicode.IsPunct(char) || unicode.IsSymbol(char):
-			hasSpecial = true
-		}
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return cerror.NewInvalidContentError(
+			err,
+			"invalid email",
+		)
 	}
-	return hasMinLen && hasUpper && hasLower && hasNumber && hasSpecial
+	return nil
 }
 
-func passwordHash(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+// hashPassword hashes password with the current hasher of the service.
+func (s *Service) hashPassword(password string) (string, error) {
+	hash, err := s.hasher.Hash(password)
+	if errors.Is(err, cerror.ErrInvalidContent) {
 		return "", err
+	} else if err != nil {
+		return "", cerror.NewInternalError(
+			err,
+			"failed to hash password",
+		)
 	}
-	return string(hash), err
-}
-
-func verifyPassword(hash, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return hash, nil
 }
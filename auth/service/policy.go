@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// PasswordPolicy is rules new passwords must satisfy.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireNumber bool
+	RequireSymbol bool
+	// MaxRepeated is the maximum number of the same character in a row. 0 means no limit.
+	MaxRepeated int
+	// DisallowName rejects passwords containing the user name.
+	DisallowName bool
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:     8,
+	RequireUpper:  true,
+	RequireLower:  true,
+	RequireNumber: true,
+	RequireSymbol: true,
+	MaxRepeated:   3,
+	DisallowName:  true,
+}
+
+// BreachedPasswords reports whether password is found in known data breaches.
+type BreachedPasswords interface {
+	Breached(password string) (bool, error)
+}
+
+func (s *Service) SetPasswordPolicy(policy PasswordPolicy) {
+	s.policy = policy
+}
+
+func (s *Service) SetBreachedPasswords(breached BreachedPasswords) {
+	s.breached = breached
+}
+
+// validatePassword returns InvalidContentError telling which rule password breaks.
+func (s *Service) validatePassword(name, password string) error {
+	if password == "" {
+		return cerror.NewInvalidContentError(
+			nil,
+			"password is empty",
+		)
+	}
+	if utf8.RuneCountInString(password) < s.policy.MinLength {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("password must be at least %v characters", s.policy.MinLength),
+		)
+	}
+	if len(password) > maxPasswordLen {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("password must be at most %v bytes", maxPasswordLen),
+		)
+	}
+
+	hasUpper := false
+	hasLower := false
+	hasNumber := false
+	hasSymbol := false
+	repeated := 0
+	var prev rune
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsNumber(char):
+			hasNumber = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSymbol = true
+		}
+
+		if char == prev {
+			repeated++
+		} else {
+			repeated = 1
+		}
+		prev = char
+		if s.policy.MaxRepeated > 0 && repeated > s.policy.MaxRepeated {
+			return cerror.NewInvalidContentError(
+				nil,
+				fmt.Sprintf("password must not repeat the same character more than %v times", s.policy.MaxRepeated),
+			)
+		}
+	}
+
+	if s.policy.RequireUpper && !hasUpper {
+		return cerror.NewInvalidContentError(
+			nil,
+			"password must contain an uppercase letter",
+		)
+	}
+	if s.policy.RequireLower && !hasLower {
+		return cerror.NewInvalidContentError(
+			nil,
+			"password must contain a lowercase letter",
+		)
+	}
+	if s.policy.RequireNumber && !hasNumber {
+		return cerror.NewInvalidContentError(
+			nil,
+			"password must contain a number",
+		)
+	}
+	if s.policy.RequireSymbol && !hasSymbol {
+		return cerror.NewInvalidContentError(
+			nil,
+			"password must contain a symbol",
+		)
+	}
+
+	if s.policy.DisallowName && name != "" && strings.Contains(strings.ToLower(password), strings.ToLower(name)) {
+		return cerror.NewInvalidContentError(
+			nil,
+			"password must not contain the user name",
+		)
+	}
+
+	if s.breached != nil {
+		breached, err := s.breached.Breached(password)
+		if err != nil {
+			return cerror.NewInternalError(
+				err,
+				"failed to check breached passwords",
+			)
+		}
+		if breached {
+			return cerror.NewInvalidContentError(
+				nil,
+				"password is found in data breaches",
+			)
+		}
+	}
+
+	return nil
+}
@@ -10,23 +10,73 @@ import (
 type Repogitory interface {
 	User() UserRepogitory
 	Session() SessionRepogitory
+	Attempt() AttemptRepogitory
+	Identity() IdentityRepogitory
+	Token() TokenRepogitory
+	Revocation() RevocationRepogitory
+	// Transaction runs f with the repogitory in a transaction. Changes made in f are discarded if f returns an error.
+	Transaction(ctx context.Context, f func(Repogitory) error) error
 }
 
 type UserRepogitory interface {
+	Find(ctx context.Context, id string) (UserData, error)
+	// FindUsers returns users with the IDs in a query. Users not found are omitted.
+	FindUsers(ctx context.Context, ids []string) ([]UserData, error)
 	FindByName(ctx context.Context, name string) (UserData, error)
+	FindByEmail(ctx context.Context, email string) (UserData, error)
+	// Search returns users whose name or email contains query, ordered by name.
+	Search(ctx context.Context, query string, offset, limit int) ([]UserData, error)
 	Create(ctx context.Context, user UserData) error
+	Update(ctx context.Context, user UserData) error
 }
 
 type SessionRepogitory interface {
 	Find(ctx context.Context, id string) (SessionData, error)
 	Create(ctx context.Context, session SessionData) error
 	Delete(ctx context.Context, id string) error
+	// DeleteByUserID deletes all sessions of the user.
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type AttemptRepogitory interface {
+	Find(ctx context.Context, key string) (AttemptData, error)
+	// Increment adds a failure to the attempts of the key atomically and returns the number of failures.
+	// The attempts are made if not found, and they are discarded after expires.
+	Increment(ctx context.Context, key string, expires int64) (int, error)
+	// Lock locks out the key until lockedUntil unless it is already locked out longer.
+	Lock(ctx context.Context, key string, lockedUntil int64) error
+	Delete(ctx context.Context, key string) error
+}
+
+type IdentityRepogitory interface {
+	Find(ctx context.Context, issuer, subject string) (IdentityData, error)
+	Create(ctx context.Context, identity IdentityData) error
+}
+
+type TokenRepogitory interface {
+	Find(ctx context.Context, id string) (TokenData, error)
+	Create(ctx context.Context, token TokenData) error
+	Delete(ctx context.Context, id string) error
+}
+
+// RevocationRepogitory is the list of revoked session tokens. Revocations are not found after they expire.
+type RevocationRepogitory interface {
+	// Find returns revocations with the IDs in a query. Revocations not found are omitted.
+	Find(ctx context.Context, ids []string) ([]RevocationData, error)
+	// FindSince returns revocations revoked at or after the time. revokedAt is unix time.
+	FindSince(ctx context.Context, revokedAt int64) ([]RevocationData, error)
+	// Save creates the revocation or replaces the one with the same ID.
+	Save(ctx context.Context, revocation RevocationData) error
 }
 
 type UserData struct {
 	ID       string
 	Name     string
 	Password string
+	Email    string
+	Verified bool
+	Admin    bool
+	Disabled bool
 }
 
 func newUserData(user model.User) UserData {
@@ -34,6 +84,10 @@ func newUserData(user model.User) UserData {
 		ID:       user.ID,
 		Name:     user.Name,
 		Password: user.Password,
+		Email:    user.Email,
+		Verified: user.Verified,
+		Admin:    user.Admin,
+		Disabled: user.Disabled,
 	}
 }
 
@@ -42,6 +96,10 @@ func (u *UserData) model() model.User {
 		ID:       u.ID,
 		Name:     u.Name,
 		Password: u.Password,
+		Email:    u.Email,
+		Verified: u.Verified,
+		Admin:    u.Admin,
+		Disabled: u.Disabled,
 	}
 }
 
@@ -66,3 +124,37 @@ func (s *SessionData) model() model.Session {
 		Expires: time.Unix(s.Expires, 0),
 	}
 }
+
+// AttemptData is failed sign-in attempts for a key such as user name or client IP.
+// It is discarded after Expires.
+type AttemptData struct {
+	Key         string
+	Failures    int
+	LockedUntil int64
+	Expires     int64
+}
+
+// IdentityData links an identity in an external provider to a user.
+type IdentityData struct {
+	Issuer  string
+	Subject string
+	UserID  string
+}
+
+// TokenData is a single-use token sent to a user by mail.
+// ID is hash of the token so that stored data can not be used as the token.
+type TokenData struct {
+	ID      string
+	UserID  string
+	Purpose string
+	Expires int64
+}
+
+// RevocationData revokes the session token whose session ID is ID, or all session tokens of
+// a user issued until RevokedAt if ID is "user:" and the user ID.
+// It is discarded after Expires when the revoked tokens have expired.
+type RevocationData struct {
+	ID        string
+	RevokedAt int64
+	Expires   int64
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/x-color/calendar/mail"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+const (
+	resetPurpose  = "reset"
+	resetLifetime = time.Hour
+)
+
+// SetMailer sets mailer to send links to users.
+// baseURL is URL of the web application the links point to.
+func (s *Service) SetMailer(mailer mail.Mailer, baseURL string) {
+	s.mailer = mailer
+	s.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.requestPasswordReset(ctx, email)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to request password reset: %v", msg))
+		}
+	} else {
+		// The email is not logged because it is personal information.
+		s.log.Info("Send password reset link")
+	}
+
+	return err
+}
+
+func (s *Service) requestPasswordReset(ctx context.Context, email string) error {
+	if err := validateEmail(email); err != nil {
+		return err
+	}
+
+	if s.mailer == nil {
+		return cerror.NewInternalError(
+			nil,
+			"mailer is not configured",
+		)
+	}
+
+	user, err := s.repo.User().FindByEmail(ctx, email)
+	if errors.Is(err, cerror.ErrNotFound) {
+		// The error of the repogitory contains the email. So it is replaced.
+		return cerror.NewNotFoundError(
+			nil,
+			"not found user of the email",
+		)
+	} else if err != nil {
+		return err
+	}
+
+	return s.sendPasswordReset(ctx, user)
+}
+
+// sendPasswordReset sends the user a link to reset the password.
+func (s *Service) sendPasswordReset(ctx context.Context, user UserData) error {
+	token, err := s.issueToken(ctx, user.ID, resetPurpose, resetLifetime)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%v/reset-password?token=%v", s.baseURL, url.QueryEscape(token))
+	body := fmt.Sprintf(
+		"Hello %v,\n\nOpen the link below to reset your password. It expires in %v.\n\n%v\n\nIf you did not request it, ignore this mail.\n",
+		user.Name,
+		resetLifetime,
+		link,
+	)
+	if err := s.mailer.Send(ctx, user.Email, "Reset your password", body); err != nil {
+		return cerror.NewInternalError(
+			err,
+			"failed to send mail",
+		)
+	}
+
+	return nil
+}
+
+func (s *Service) ResetPassword(ctx context.Context, token, password string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	userID, err := s.resetPassword(ctx, token, password)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to reset password: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Reset password of user(%v)", userID))
+	}
+
+	return err
+}
+
+func (s *Service) resetPassword(ctx context.Context, token, password string) (string, error) {
+	t, err := s.findToken(ctx, token, resetPurpose)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.repo.User().Find(ctx, t.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	// The password is validated before the token is consumed. So the user can retry
+	// with another password.
+	if err := s.validatePassword(user.Name, password); err != nil {
+		return "", err
+	}
+
+	if _, err := s.consumeToken(ctx, token, resetPurpose); err != nil {
+		return "", err
+	}
+
+	hash, err := s.hashPassword(password)
+	if err != nil {
+		return "", err
+	}
+	user.Password = hash
+	// The user received the token by the email. So the email is verified too.
+	user.Verified = true
+
+	if err := s.repo.User().Update(ctx, user); err != nil {
+		return "", err
+	}
+
+	// Sessions may be held by someone who knew the old password.
+	if err := s.deleteSessions(ctx, user.ID); err != nil {
+		return "", err
+	}
+
+	// The user proved ownership of the account. So past failures do not matter.
+	if err := s.resetFailures(ctx, signinAttemptKeys(user.Name, "")); err != nil {
+		return "", err
+	}
+
+	return user.ID, nil
+}
+
+// issueToken returns a new single-use token for the user.
+// Only hash of the token is stored.
+func (s *Service) issueToken(ctx context.Context, userID, purpose string, lifetime time.Duration) (string, error) {
+	token, err := randomString()
+	if err != nil {
+		return "", cerror.NewInternalError(
+			err,
+			"failed to generate token",
+		)
+	}
+
+	err = s.repo.Token().Create(ctx, TokenData{
+		ID:      tokenHash(token),
+		UserID:  userID,
+		Purpose: purpose,
+		Expires: s.clock.Now().Add(lifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// findToken returns the valid token without invalidating it.
+func (s *Service) findToken(ctx context.Context, token, purpose string) (TokenData, error) {
+	if token == "" {
+		return TokenData{}, cerror.NewInvalidContentError(
+			nil,
+			"token is empty",
+		)
+	}
+
+	t, err := s.repo.Token().Find(ctx, tokenHash(token))
+	if errors.Is(err, cerror.ErrNotFound) {
+		return TokenData{}, cerror.NewAuthorizationError(
+			err,
+			"invalid token",
+		)
+	} else if err != nil {
+		return TokenData{}, err
+	}
+
+	if t.Purpose != purpose {
+		return TokenData{}, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("token is not for %v", purpose),
+		)
+	}
+
+	if !s.clock.Now().Before(time.Unix(t.Expires, 0)) {
+		return TokenData{}, cerror.NewAuthorizationError(
+			nil,
+			"token is already expired",
+		)
+	}
+
+	return t, nil
+}
+
+// consumeToken returns ID of the user the token is issued for and invalidates it.
+func (s *Service) consumeToken(ctx context.Context, token, purpose string) (string, error) {
+	t, err := s.findToken(ctx, token, purpose)
+	if err != nil {
+		return "", err
+	}
+
+	// Deleting the token succeeds only once even if it is used concurrently.
+	err = s.repo.Token().Delete(ctx, t.ID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return "", cerror.NewAuthorizationError(
+			err,
+			"token is already used",
+		)
+	} else if err != nil {
+		return "", err
+	}
+
+	return t.UserID, nil
+}
+
+func tokenHash(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/x-color/calendar/auth/model"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// SessionTokens seals sessions into tokens and opens them. Tokens can not be read nor forged without keys.
+type SessionTokens interface {
+	Seal(claims SessionClaims) (string, error)
+	// Open returns claims in the token. It is an authorization error if the token is broken or forged.
+	Open(token string) (SessionClaims, error)
+}
+
+// SessionClaims are contents of session tokens. Times are unix time.
+type SessionClaims struct {
+	ID       string
+	UserID   string
+	IssuedAt int64
+	Expires  int64
+}
+
+// userRevocationPrefix is prefix of IDs of revocations revoking all session tokens of a user.
+const userRevocationPrefix = "user:"
+
+// SetSessionTokens makes sessions stateless. Sessions are issued as tokens sealed by tokens
+// instead of being kept in the session store, and signed out ones are kept in the revocation
+// list until they expire.
+func (s *Service) SetSessionTokens(tokens SessionTokens) {
+	s.tokens = tokens
+}
+
+// sealSession returns the session whose ID is the token carrying it.
+func (s *Service) sealSession(session model.Session) (model.Session, error) {
+	token, err := s.tokens.Seal(SessionClaims{
+		ID:       session.ID,
+		UserID:   session.UserID,
+		IssuedAt: s.clock.Now().Unix(),
+		Expires:  session.Expires.Unix(),
+	})
+	if err != nil {
+		return model.Session{}, err
+	}
+	session.ID = token
+	return session, nil
+}
+
+// openSession returns the claims of the token unless it is expired or revoked.
+func (s *Service) openSession(ctx context.Context, token string) (SessionClaims, error) {
+	claims, err := s.tokens.Open(token)
+	if err != nil {
+		return SessionClaims{}, err
+	}
+	if s.clock.Now().After(time.Unix(claims.Expires, 0)) {
+		return SessionClaims{}, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("session(%v) is already expired", claims.ID),
+		)
+	}
+
+	revocations, err := s.repo.Revocation().Find(ctx, []string{claims.ID, userRevocationPrefix + claims.UserID})
+	if err != nil {
+		return SessionClaims{}, err
+	}
+	for _, r := range revocations {
+		// Tokens issued in the same second as the revocation of the user are revoked to be safe.
+		if r.ID == claims.ID || claims.IssuedAt <= r.RevokedAt {
+			return SessionClaims{}, cerror.NewAuthorizationError(
+				nil,
+				fmt.Sprintf("session(%v) is revoked", claims.ID),
+			)
+		}
+	}
+	return claims, nil
+}
+
+// revokeSession adds the session of the token to the revocation list.
+func (s *Service) revokeSession(ctx context.Context, token string) error {
+	claims, err := s.openSession(ctx, token)
+	if err != nil {
+		return err
+	}
+	return s.repo.Revocation().Save(ctx, RevocationData{
+		ID:        claims.ID,
+		RevokedAt: s.clock.Now().Unix(),
+		Expires:   claims.Expires,
+	})
+}
+
+// deleteSessions deletes all sessions of the user. With session tokens, tokens issued until now
+// are revoked. They are assumed to expire within the current lifetime of sessions.
+func (s *Service) deleteSessions(ctx context.Context, userID string) error {
+	if err := s.repo.Session().DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if s.tokens == nil {
+		return nil
+	}
+
+	now := s.clock.Now()
+	return s.repo.Revocation().Save(ctx, RevocationData{
+		ID:        userRevocationPrefix + userID,
+		RevokedAt: now.Unix(),
+		Expires:   now.Add(s.lifetime).Unix(),
+	})
+}
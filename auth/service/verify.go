@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/x-color/calendar/auth/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+const (
+	verifyPurpose  = "verify"
+	verifyLifetime = 24 * time.Hour
+)
+
+func (s *Service) sendVerification(ctx context.Context, user model.User) error {
+	if s.mailer == nil {
+		return cerror.NewInternalError(
+			nil,
+			"mailer is not configured",
+		)
+	}
+
+	token, err := s.issueToken(ctx, user.ID, verifyPurpose, verifyLifetime)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%v/api/auth/verify?token=%v", s.baseURL, url.QueryEscape(token))
+	body := fmt.Sprintf(
+		"Hello %v,\n\nOpen the link below to verify your email address. It expires in %v.\n\n%v\n\nIf you did not sign up, ignore this mail.\n",
+		user.Name,
+		verifyLifetime,
+		link,
+	)
+	if err := s.mailer.Send(ctx, user.Email, "Verify your email address", body); err != nil {
+		return cerror.NewInternalError(
+			err,
+			"failed to send mail",
+		)
+	}
+
+	return nil
+}
+
+func (s *Service) ResendVerification(ctx context.Context, email string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.resendVerification(ctx, email)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to resend verification: %v", msg))
+		}
+	} else {
+		s.log.Info("Send verification link")
+	}
+
+	return err
+}
+
+func (s *Service) resendVerification(ctx context.Context, email string) error {
+	if err := validateEmail(email); err != nil {
+		return err
+	}
+
+	user, err := s.repo.User().FindByEmail(ctx, email)
+	if errors.Is(err, cerror.ErrNotFound) {
+		// The error of the repogitory contains the email. So it is replaced.
+		return cerror.NewNotFoundError(
+			nil,
+			"not found user of the email",
+		)
+	} else if err != nil {
+		return err
+	}
+
+	if user.Verified {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("user(%v) is already verified", user.ID),
+		)
+	}
+
+	return s.sendVerification(ctx, user.model())
+}
+
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	userID, err := s.verifyEmail(ctx, token)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to verify email: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Verify email of user(%v)", userID))
+	}
+
+	return err
+}
+
+func (s *Service) verifyEmail(ctx context.Context, token string) (string, error) {
+	userID, err := s.consumeToken(ctx, token, verifyPurpose)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.repo.User().Find(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	user.Verified = true
+	if err := s.repo.User().Update(ctx, user); err != nil {
+		return "", err
+	}
+
+	return user.ID, nil
+}
+
+func (s *Service) IsVerified(ctx context.Context, userID string) (bool, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	verified, err := s.isVerified(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to check verification: %v", msg))
+		}
+	}
+
+	return verified, err
+}
+
+func (s *Service) isVerified(ctx context.Context, userID string) (bool, error) {
+	user, err := s.repo.User().Find(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.Verified, nil
+}
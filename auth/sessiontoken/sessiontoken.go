@@ -0,0 +1,151 @@
+package sessiontoken
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/x-color/calendar/auth/service"
+	cerror "github.com/x-color/calendar/model/error"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// version is the header of tokens. Tokens are "v1.<key ID>.<nonce and ciphertext in base64>".
+// The header is authenticated with the ciphertext.
+const version = "v1"
+
+// Key is a secret key of tokens. ID is written in tokens to find the key opening them.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Sealer encrypts sessions into tokens with XChaCha20-Poly1305, so that tokens can not be read
+// nor forged without keys. Keys are rotated by adding a new key to the head. The first key seals
+// new tokens and all keys open them, until tokens sealed by old keys expire.
+type Sealer struct {
+	id    string
+	aeads map[string]cipher.AEAD
+}
+
+type claims struct {
+	ID       string `json:"sid"`
+	UserID   string `json:"uid"`
+	IssuedAt int64  `json:"iat"`
+	Expires  int64  `json:"exp"`
+}
+
+func NewSealer(keys []Key) (*Sealer, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys of session tokens")
+	}
+
+	s := &Sealer{
+		id:    keys[0].ID,
+		aeads: map[string]cipher.AEAD{},
+	}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ".") {
+			return nil, fmt.Errorf("ID of key(%v) is invalid", key.ID)
+		}
+		if _, ok := s.aeads[key.ID]; ok {
+			return nil, fmt.Errorf("ID of key(%v) is duplicated", key.ID)
+		}
+		aead, err := chacha20poly1305.NewX(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("secret of key(%v) is invalid: %w", key.ID, err)
+		}
+		s.aeads[key.ID] = aead
+	}
+	return s, nil
+}
+
+// ParseKeys parses keys written as "<ID>:<secret in base64>" separated by commas.
+// Secrets must be 32 bytes.
+func ParseKeys(s string) ([]Key, error) {
+	keys := []Key{}
+	for _, k := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(k), ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("key must be <ID>:<secret>")
+		}
+		secret, err := base64.StdEncoding.DecodeString(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("secret of key(%v) is not base64: %w", kv[0], err)
+		}
+		keys = append(keys, Key{kv[0], secret})
+	}
+	return keys, nil
+}
+
+func (s *Sealer) Seal(c service.SessionClaims) (string, error) {
+	b, err := json.Marshal(claims(c))
+	if err != nil {
+		return "", cerror.NewInternalError(
+			err,
+			"failed to encode session",
+		)
+	}
+
+	aead := s.aeads[s.id]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(b)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", cerror.NewInternalError(
+			err,
+			"failed to make nonce",
+		)
+	}
+
+	header := header(s.id)
+	sealed := aead.Seal(nonce, nonce, b, []byte(header))
+	return header + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (s *Sealer) Open(token string) (service.SessionClaims, error) {
+	parts := strings.SplitN(token, ".", 3)
+	if len(parts) != 3 || parts[0] != version {
+		return service.SessionClaims{}, cerror.NewAuthorizationError(
+			nil,
+			"session token is malformed",
+		)
+	}
+	aead, ok := s.aeads[parts[1]]
+	if !ok {
+		return service.SessionClaims{}, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("key(%v) of session token is unknown", parts[1]),
+		)
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return service.SessionClaims{}, cerror.NewAuthorizationError(
+			err,
+			"session token is malformed",
+		)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	b, err := aead.Open(nil, nonce, ciphertext, []byte(header(parts[1])))
+	if err != nil {
+		return service.SessionClaims{}, cerror.NewAuthorizationError(
+			err,
+			"session token is forged",
+		)
+	}
+
+	c := claims{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return service.SessionClaims{}, cerror.NewAuthorizationError(
+			err,
+			"session token is malformed",
+		)
+	}
+	return service.SessionClaims(c), nil
+}
+
+func header(id string) string {
+	return version + "." + id + "."
+}
@@ -0,0 +1,107 @@
+package sessiontoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/x-color/calendar/auth/service"
+)
+
+func TestSealer_Rotation(t *testing.T) {
+	old := Key{"old", []byte(strings.Repeat("o", 32))}
+	new := Key{"new", []byte(strings.Repeat("n", 32))}
+	claims := service.SessionClaims{
+		ID:       "session",
+		UserID:   "user",
+		IssuedAt: time.Now().Unix(),
+		Expires:  time.Now().Add(time.Hour).Unix(),
+	}
+
+	before, _ := NewSealer([]Key{old})
+	token, err := before.Seal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A character in the middle of the ciphertext is changed.
+	i := len(token) - 10
+	c := "A"
+	if token[i:i+1] == c {
+		c = "B"
+	}
+	forged := token[:i] + c + token[i+1:]
+
+	testcases := []struct {
+		name  string
+		keys  []Key
+		token string
+		valid bool
+	}{
+		{
+			name:  "old key is kept",
+			keys:  []Key{new, old},
+			token: token,
+			valid: true,
+		},
+		{
+			name:  "old key is removed",
+			keys:  []Key{new},
+			token: token,
+		},
+		{
+			name:  "key ID is changed",
+			keys:  []Key{new, old},
+			token: strings.Replace(token, "v1.old.", "v1.new.", 1),
+		},
+		{
+			name:  "ciphertext is changed",
+			keys:  []Key{new, old},
+			token: forged,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewSealer(tc.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := s.Open(tc.token)
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("want error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want no error, but got %v", err)
+			}
+			if actual != claims {
+				t.Errorf("want %+v but %+v", claims, actual)
+			}
+		})
+	}
+
+	after, _ := NewSealer([]Key{new, old})
+	token, _ = after.Seal(claims)
+	if !strings.HasPrefix(token, "v1.new.") {
+		t.Errorf("token is not sealed by the first key: %v", token)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys("new:" + strings.Repeat("A", 43) + "=" + ", old:" + strings.Repeat("B", 43) + "=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].ID != "new" || keys[1].ID != "old" || len(keys[0].Secret) != 32 {
+		t.Errorf("unexpected keys: %+v", keys)
+	}
+
+	for _, s := range []string{"", "new", "new:not base64!"} {
+		if _, err := ParseKeys(s); err == nil {
+			t.Errorf("want error for %q, but got nil", s)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Cache keeps values for the TTL of the cache. Failures of caches are treated as misses
+// so that callers fall back to their repogitories.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set keeps the value. Tags are used to delete related keys together.
+	Set(ctx context.Context, key string, value []byte, tags ...string)
+	Delete(ctx context.Context, keys ...string)
+	// DeleteTag deletes all keys set with the tag.
+	DeleteTag(ctx context.Context, tag string)
+	Stats() Stats
+}
+
+// Stats is the number of hits and misses of a cache.
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// counter counts hits and misses. It must be the first field of structs to be aligned for atomic operations.
+type counter struct {
+	hits   uint64
+	misses uint64
+}
+
+func (c *counter) count(hit bool) {
+	if hit {
+		atomic.AddUint64(&c.hits, 1)
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+	}
+}
+
+func (c *counter) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
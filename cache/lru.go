@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+// lru keeps values in memory. The least recently used value is evicted when it is full.
+// Values are not shared with other servers, so that they are not invalidated by writes there.
+type lru struct {
+	counter
+	m        sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	entries  *list.List
+	items    map[string]*list.Element
+	tags     map[string]map[string]struct{}
+}
+
+func NewLRU(capacity int, ttl time.Duration) lru {
+	return lru{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  list.New(),
+		items:    map[string]*list.Element{},
+		tags:     map[string]map[string]struct{}{},
+	}
+}
+
+func (c *lru) Get(ctx context.Context, key string) ([]byte, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	el, ok := c.items[key]
+	if ok && c.now().After(el.Value.(*entry).expires) {
+		c.remove(el)
+		ok = false
+	}
+	c.count(ok)
+	if !ok {
+		return nil, false
+	}
+	c.entries.MoveToFront(el)
+	return el.Value.(*entry).value, true
+}
+
+func (c *lru) Set(ctx context.Context, key string, value []byte, tags ...string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	e := &entry{
+		key:     key,
+		value:   value,
+		expires: c.now().Add(c.ttl),
+		tags:    tags,
+	}
+	c.items[key] = c.entries.PushFront(e)
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = map[string]struct{}{}
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.entries.Len() > c.capacity {
+		c.remove(c.entries.Back())
+	}
+}
+
+func (c *lru) Delete(ctx context.Context, keys ...string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *lru) DeleteTag(ctx context.Context, tag string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for key := range c.tags[tag] {
+		c.remove(c.items[key])
+	}
+}
+
+// remove removes the element from the list, the index and tags. The lock must be held.
+func (c *lru) remove(el *list.Element) {
+	e := c.entries.Remove(el).(*entry)
+	delete(c.items, e.key)
+	for _, tag := range e.tags {
+		delete(c.tags[tag], e.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
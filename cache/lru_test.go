@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewLRU(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "a", []byte("1"), "tag")
+	c.Set(ctx, "b", []byte("2"), "tag")
+	c.Get(ctx, "a")
+	// "b" is evicted because "a" has been used more recently.
+	c.Set(ctx, "c", []byte("3"))
+
+	testcases := []struct {
+		name  string
+		prep  func()
+		key   string
+		value string
+	}{
+		{
+			name:  "hit",
+			key:   "a",
+			value: "1",
+		},
+		{
+			name: "evicted",
+			key:  "b",
+		},
+		{
+			name: "deleted",
+			prep: func() { c.Delete(ctx, "c") },
+			key:  "c",
+		},
+		{
+			name: "deleted tag",
+			prep: func() { c.DeleteTag(ctx, "tag") },
+			key:  "a",
+		},
+		{
+			name: "expired",
+			prep: func() {
+				c.Set(ctx, "d", []byte("4"))
+				now = now.Add(2 * time.Minute)
+			},
+			key: "d",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.prep != nil {
+				tc.prep()
+			}
+			b, ok := c.Get(ctx, tc.key)
+			if ok != (tc.value != "") || string(b) != tc.value {
+				t.Errorf("want %q but %q(%v)", tc.value, b, ok)
+			}
+		})
+	}
+
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 4 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if len(c.items) != 0 || len(c.tags) != 0 {
+		t.Errorf("index is not cleaned: %v, %v", c.items, c.tags)
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisCache keeps values in Redis shared by all servers. Keys of each tag are kept in a set.
+type redisCache struct {
+	counter
+	rdb    *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func NewRedis(rdb *redis.Client, prefix string, ttl time.Duration) redisCache {
+	return redisCache{
+		rdb:    rdb,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	b, err := c.rdb.Get(ctx, c.prefix+key).Bytes()
+	c.count(err == nil)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, tags ...string) {
+	// Sets of tags expire with the latest key because all keys have the same TTL.
+	c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, c.prefix+key, value, c.ttl)
+		for _, tag := range tags {
+			pipe.SAdd(ctx, c.tagKey(tag), key)
+			pipe.Expire(ctx, c.tagKey(tag), c.ttl)
+		}
+		return nil
+	})
+}
+
+func (c *redisCache) Delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	c.rdb.Del(ctx, prefixed...)
+}
+
+func (c *redisCache) DeleteTag(ctx context.Context, tag string) {
+	keys, err := c.rdb.SMembers(ctx, c.tagKey(tag)).Result()
+	if err != nil {
+		return
+	}
+	c.Delete(ctx, append(keys, "tag:"+tag)...)
+}
+
+func (c *redisCache) tagKey(tag string) string {
+	return c.prefix + "tag:" + tag
+}
@@ -1,16 +1,24 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type Calendar struct {
 	ID     string
 	UserID string
+	// OrgID is set if the calendar is owned by the organization. All members can use it without shares.
+	OrgID  string
 	Name   string
 	Color  Color
 	Plans  []Plan
 	Shares []string
+	// GroupShares is IDs of groups sharing the calendar. Members of the groups can use it as users in shares.
+	GroupShares []string
+	// DeletedAt is set while the calendar is in the trash.
+	DeletedAt time.Time
 }
 
 func NewCalendar(userID, name string, color Color) Calendar {
@@ -23,3 +31,16 @@ func NewCalendar(userID, name string, color Color) Calendar {
 		Shares: []string{userID},
 	}
 }
+
+// NewOrgCalendar returns a calendar owned by the organization. userID is the member who makes it.
+func NewOrgCalendar(orgID, userID, name string, color Color) Calendar {
+	return Calendar{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		OrgID:  orgID,
+		Name:   name,
+		Color:  color,
+		Plans:  []Plan{},
+		Shares: []string{},
+	}
+}
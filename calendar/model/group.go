@@ -0,0 +1,22 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Group is a set of users. Calendars shared with a group are shared with all its current members.
+type Group struct {
+	ID      string
+	UserID  string
+	Name    string
+	Members []string
+}
+
+func NewGroup(userID, name string) Group {
+	return Group{
+		ID:      uuid.New().String(),
+		UserID:  userID,
+		Name:    name,
+		Members: []string{userID},
+	}
+}
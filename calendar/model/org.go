@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// Org is an organization whose members share calendars owned by it.
+type Org struct {
+	ID   string
+	Name string
+}
+
+func NewOrg(name string) Org {
+	return Org{
+		ID:   uuid.New().String(),
+		Name: name,
+	}
+}
+
+type Role string
+
+const (
+	OWNER  Role = "owner"
+	ADMIN  Role = "admin"
+	MEMBER Role = "member"
+)
+
+func ConvertToRole(r string) (Role, error) {
+	switch Role(r) {
+	case OWNER:
+		return OWNER, nil
+	case ADMIN:
+		return ADMIN, nil
+	case MEMBER:
+		return MEMBER, nil
+	}
+	return Role(""), cerror.NewInvalidContentError(
+		nil,
+		fmt.Sprintf("invalid role(%v)", r),
+	)
+}
+
+// CanManage tells whether the role is allowed to manage members and calendars of the organization.
+func (r Role) CanManage() bool {
+	return r == OWNER || r == ADMIN
+}
+
+type Member struct {
+	OrgID  string
+	UserID string
+	Role   Role
+}
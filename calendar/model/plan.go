@@ -24,6 +24,8 @@ type Plan struct {
 	Private    bool
 	Shares     []string
 	Period     Period
+	// DeletedAt is set while the plan is in the trash.
+	DeletedAt time.Time
 }
 
 func NewPlan(calendarID, userID, name, memo string, color Color, private bool, shares []string, period Period) Plan {
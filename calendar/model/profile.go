@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+)
+
+// Profile is preferences of a user for calendars.
+type Profile struct {
+	UserID            string
+	DisplayName       string
+	AvatarURL         string
+	TimeZone          string
+	Locale            string
+	WeekStart         time.Weekday
+	DefaultCalendarID string
+}
+
+func NewProfile(userID string) Profile {
+	return Profile{
+		UserID:    userID,
+		TimeZone:  "UTC",
+		Locale:    "en",
+		WeekStart: time.Sunday,
+	}
+}
+
+// Name returns the name to show other users. The ID is used until the user sets a display name.
+func (p Profile) Name() string {
+	if p.DisplayName == "" {
+		return p.UserID
+	}
+	return p.DisplayName
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+)
+
+type Target string
+
+const (
+	PLAN     Target = "plan"
+	CALENDAR Target = "calendar"
+)
+
+type Action string
+
+const (
+	CREATE  Action = "create"
+	UPDATE  Action = "update"
+	DELETE  Action = "delete"
+	RESTORE Action = "restore"
+)
+
+// Revision is an immutable record of a change of a plan or a calendar.
+type Revision struct {
+	ID string
+	// OperationID is ID of the request which made the change. It is used to undo the change.
+	OperationID string
+	Target      Target
+	TargetID    string
+	// CalendarID is the calendar whose audit trail has the revision. It is the parent calendar for plans.
+	CalendarID string
+	// UserID is the user who made the change.
+	UserID    string
+	Action    Action
+	CreatedAt time.Time
+	// Changes is fields differing between before and after the change.
+	Changes []Change
+}
+
+// Change is a field changed by a revision. Before is nil if it is created and After is nil if it is deleted.
+type Change struct {
+	Field  string
+	Before interface{}
+	After  interface{}
+}
@@ -0,0 +1,11 @@
+package model
+
+// Transfer is a request to hand ownership of a calendar to another user.
+// It takes effect when the recipient accepts it.
+type Transfer struct {
+	CalendarID string
+	FromUserID string
+	ToUserID   string
+	// TransferPlans tells whether plans of the old owner in the calendar are also handed to the recipient.
+	TransferPlans bool
+}
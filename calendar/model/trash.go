@@ -0,0 +1,7 @@
+package model
+
+// Trash is calendars and plans the user removed. They are purged after the retention period.
+type Trash struct {
+	Calendars []Calendar
+	Plans     []Plan
+}
@@ -9,3 +9,9 @@ func NewUser(id string) User {
 		ID: id,
 	}
 }
+
+// Usage is the number of items a user owns.
+type Usage struct {
+	Calendars int
+	Plans     int
+}
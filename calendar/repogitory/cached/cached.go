@@ -0,0 +1,97 @@
+package cached
+
+import (
+	"context"
+
+	"github.com/x-color/calendar/cache"
+	"github.com/x-color/calendar/calendar/service"
+)
+
+const (
+	userKeyPrefix     = "user:"
+	calendarKeyPrefix = "calendar:"
+	// groupTagPrefix and orgTagPrefix are prefixes of tags of calendars shared with each group
+	// and owned by each organization. They are deleted with the group and the organization.
+	groupTagPrefix = "group:"
+	orgTagPrefix   = "org:"
+)
+
+// repo reads users and calendars through the cache. Changed calendars are invalidated.
+// In transactions, the cache is not read and invalidations are deferred until they end
+// so that other requests do not cache data before it is committed.
+type repo struct {
+	service.Repogitory
+	cache cache.Cache
+	// pending is invalidations in the running transaction. It is nil out of transactions.
+	pending *invalidations
+}
+
+type invalidations struct {
+	keys []string
+	tags []string
+}
+
+func NewRepogitory(r service.Repogitory, c cache.Cache) repo {
+	return repo{
+		Repogitory: r,
+		cache:      c,
+	}
+}
+
+func (m *repo) Transaction(ctx context.Context, f func(service.Repogitory) error) error {
+	if m.pending != nil {
+		return m.Repogitory.Transaction(ctx, func(r service.Repogitory) error {
+			return f(&repo{r, m.cache, m.pending})
+		})
+	}
+
+	p := &invalidations{}
+	defer m.apply(p)
+	return m.Repogitory.Transaction(ctx, func(r service.Repogitory) error {
+		return f(&repo{r, m.cache, p})
+	})
+}
+
+func (m *repo) Calendar() service.CalendarRepogitory {
+	return &calendarRepo{m.Repogitory.Calendar(), m}
+}
+
+func (m *repo) User() service.UserRepogitory {
+	return &userRepo{m.Repogitory.User(), m}
+}
+
+func (m *repo) Org() service.OrgRepogitory {
+	return &orgRepo{m.Repogitory.Org(), m}
+}
+
+func (m *repo) Group() service.GroupRepogitory {
+	return &groupRepo{m.Repogitory.Group(), m}
+}
+
+// cached reports whether the cache is read. It is not in transactions.
+func (m *repo) cached() bool {
+	return m.pending == nil
+}
+
+// invalidate deletes the keys and keys with the tags from the cache, or defers it in transactions.
+func (m *repo) invalidate(keys []string, tags ...string) {
+	p := &invalidations{keys, tags}
+	if m.pending != nil {
+		m.pending.keys = append(m.pending.keys, p.keys...)
+		m.pending.tags = append(m.pending.tags, p.tags...)
+		return
+	}
+	m.apply(p)
+}
+
+// apply deletes the invalidated keys. It is not cancelled with requests because stale data
+// would be read until the TTL passes.
+func (m *repo) apply(p *invalidations) {
+	ctx := context.Background()
+	if len(p.keys) != 0 {
+		m.cache.Delete(ctx, p.keys...)
+	}
+	for _, tag := range p.tags {
+		m.cache.DeleteTag(ctx, tag)
+	}
+}
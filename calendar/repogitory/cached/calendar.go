@@ -0,0 +1,94 @@
+package cached
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/x-color/calendar/calendar/service"
+)
+
+type calendarRepo struct {
+	service.CalendarRepogitory
+	repo *repo
+}
+
+func (r *calendarRepo) Delete(ctx context.Context, id string) error {
+	defer r.repo.invalidate([]string{calendarKeyPrefix + id})
+	return r.CalendarRepogitory.Delete(ctx, id)
+}
+
+func (r *calendarRepo) Update(ctx context.Context, cal service.CalendarData) error {
+	defer r.repo.invalidate([]string{calendarKeyPrefix + cal.ID})
+	return r.CalendarRepogitory.Update(ctx, cal)
+}
+
+func (r *calendarRepo) Trash(ctx context.Context, id string, deletedAt int64) error {
+	defer r.repo.invalidate([]string{calendarKeyPrefix + id})
+	return r.CalendarRepogitory.Trash(ctx, id, deletedAt)
+}
+
+func (r *calendarRepo) Restore(ctx context.Context, id string) error {
+	defer r.repo.invalidate([]string{calendarKeyPrefix + id})
+	return r.CalendarRepogitory.Restore(ctx, id)
+}
+
+func (r *calendarRepo) Find(ctx context.Context, id string) (service.CalendarData, error) {
+	if !r.repo.cached() {
+		return r.CalendarRepogitory.Find(ctx, id)
+	}
+	cal := service.CalendarData{}
+	if b, ok := r.repo.cache.Get(ctx, calendarKeyPrefix+id); ok && json.Unmarshal(b, &cal) == nil {
+		return cal, nil
+	}
+
+	cal, err := r.CalendarRepogitory.Find(ctx, id)
+	if err != nil {
+		return service.CalendarData{}, err
+	}
+	r.set(ctx, cal)
+	return cal, nil
+}
+
+func (r *calendarRepo) FindCalendars(ctx context.Context, ids []string) ([]service.CalendarData, error) {
+	if !r.repo.cached() {
+		return r.CalendarRepogitory.FindCalendars(ctx, ids)
+	}
+	cals := []service.CalendarData{}
+	missed := []string{}
+	for _, id := range ids {
+		cal := service.CalendarData{}
+		if b, ok := r.repo.cache.Get(ctx, calendarKeyPrefix+id); ok && json.Unmarshal(b, &cal) == nil {
+			cals = append(cals, cal)
+		} else {
+			missed = append(missed, id)
+		}
+	}
+	if len(missed) == 0 {
+		return cals, nil
+	}
+
+	found, err := r.CalendarRepogitory.FindCalendars(ctx, missed)
+	if err != nil {
+		return nil, err
+	}
+	for _, cal := range found {
+		r.set(ctx, cal)
+	}
+	return append(cals, found...), nil
+}
+
+// set caches the calendar with tags of groups it is shared with and the organization owning it.
+func (r *calendarRepo) set(ctx context.Context, cal service.CalendarData) {
+	b, err := json.Marshal(cal)
+	if err != nil {
+		return
+	}
+	tags := []string{}
+	for _, groupID := range cal.GroupShares {
+		tags = append(tags, groupTagPrefix+groupID)
+	}
+	if cal.OrgID != "" {
+		tags = append(tags, orgTagPrefix+cal.OrgID)
+	}
+	r.repo.cache.Set(ctx, calendarKeyPrefix+cal.ID, b, tags...)
+}
@@ -0,0 +1,29 @@
+package cached
+
+import (
+	"context"
+
+	"github.com/x-color/calendar/calendar/service"
+)
+
+// orgRepo invalidates calendars of deleted organizations.
+type orgRepo struct {
+	service.OrgRepogitory
+	repo *repo
+}
+
+func (r *orgRepo) Delete(ctx context.Context, id string) error {
+	defer r.repo.invalidate(nil, orgTagPrefix+id)
+	return r.OrgRepogitory.Delete(ctx, id)
+}
+
+// groupRepo invalidates calendars shared with deleted groups.
+type groupRepo struct {
+	service.GroupRepogitory
+	repo *repo
+}
+
+func (r *groupRepo) Delete(ctx context.Context, id string) error {
+	defer r.repo.invalidate(nil, groupTagPrefix+id)
+	return r.GroupRepogitory.Delete(ctx, id)
+}
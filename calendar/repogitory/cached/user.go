@@ -0,0 +1,65 @@
+package cached
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/x-color/calendar/calendar/service"
+)
+
+// userRepo caches users. They are not changed after they are created.
+type userRepo struct {
+	service.UserRepogitory
+	repo *repo
+}
+
+func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error) {
+	if !r.repo.cached() {
+		return r.UserRepogitory.Find(ctx, id)
+	}
+	user := service.UserData{}
+	if b, ok := r.repo.cache.Get(ctx, userKeyPrefix+id); ok && json.Unmarshal(b, &user) == nil {
+		return user, nil
+	}
+
+	user, err := r.UserRepogitory.Find(ctx, id)
+	if err != nil {
+		return service.UserData{}, err
+	}
+	r.set(ctx, user)
+	return user, nil
+}
+
+func (r *userRepo) FindUsers(ctx context.Context, ids []string) ([]service.UserData, error) {
+	if !r.repo.cached() {
+		return r.UserRepogitory.FindUsers(ctx, ids)
+	}
+	users := []service.UserData{}
+	missed := []string{}
+	for _, id := range ids {
+		user := service.UserData{}
+		if b, ok := r.repo.cache.Get(ctx, userKeyPrefix+id); ok && json.Unmarshal(b, &user) == nil {
+			users = append(users, user)
+		} else {
+			missed = append(missed, id)
+		}
+	}
+	if len(missed) == 0 {
+		return users, nil
+	}
+
+	found, err := r.UserRepogitory.FindUsers(ctx, missed)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range found {
+		r.set(ctx, user)
+	}
+	return append(users, found...), nil
+}
+
+func (r *userRepo) set(ctx context.Context, user service.UserData) {
+	if b, err := json.Marshal(user); err == nil {
+		r.repo.cache.Set(ctx, userKeyPrefix+user.ID, b)
+	}
+}
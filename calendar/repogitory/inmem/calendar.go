@@ -3,7 +3,6 @@ package inmem
 import (
 	"context"
 	"fmt"
-	"sync"
 
 	"github.com/x-color/calendar/calendar/service"
 	cerror "github.com/x-color/calendar/model/error"
@@ -11,15 +10,15 @@ import (
 )
 
 type calendarRepo struct {
-	m         sync.RWMutex
-	calendars []service.CalendarData
+	m rwLocker
+	*tables
 }
 
 func (r *calendarRepo) Find(ctx context.Context, id string) (service.CalendarData, error) {
 	r.m.RLock()
 	defer r.m.RUnlock()
 	for _, c := range r.calendars {
-		if id == c.ID {
+		if id == c.ID && c.DeletedAt == 0 {
 			return c, nil
 		}
 	}
@@ -29,13 +28,27 @@ func (r *calendarRepo) Find(ctx context.Context, id string) (service.CalendarDat
 	)
 }
 
+func (r *calendarRepo) FindCalendars(ctx context.Context, ids []string) ([]service.CalendarData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	cals := []service.CalendarData{}
+	for _, c := range r.calendars {
+		if strs.Contains(ids, c.ID) && c.DeletedAt == 0 {
+			cals = append(cals, c)
+		}
+	}
+
+	return cals, nil
+}
+
 func (r *calendarRepo) FindByUserID(ctx context.Context, userID string) ([]service.CalendarData, error) {
 	r.m.RLock()
 	defer r.m.RUnlock()
 
 	cals := []service.CalendarData{}
 	for _, c := range r.calendars {
-		if strs.Contains(c.Shares, userID) {
+		if strs.Contains(c.Shares, userID) && c.DeletedAt == 0 {
 			cals = append(cals, c)
 		}
 	}
@@ -43,6 +56,68 @@ func (r *calendarRepo) FindByUserID(ctx context.Context, userID string) ([]servi
 	return cals, nil
 }
 
+func (r *calendarRepo) FindByOrgID(ctx context.Context, orgID string) ([]service.CalendarData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	cals := []service.CalendarData{}
+	for _, c := range r.calendars {
+		if c.OrgID == orgID && c.DeletedAt == 0 {
+			cals = append(cals, c)
+		}
+	}
+
+	return cals, nil
+}
+
+func (r *calendarRepo) FindByOrgIDs(ctx context.Context, orgIDs []string) ([]service.CalendarData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	cals := []service.CalendarData{}
+	for _, c := range r.calendars {
+		if strs.Contains(orgIDs, c.OrgID) && c.DeletedAt == 0 {
+			cals = append(cals, c)
+		}
+	}
+
+	return cals, nil
+}
+
+func (r *calendarRepo) FindByGroupID(ctx context.Context, groupID string) ([]service.CalendarData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	cals := []service.CalendarData{}
+	for _, c := range r.calendars {
+		if strs.Contains(c.GroupShares, groupID) && c.DeletedAt == 0 {
+			cals = append(cals, c)
+		}
+	}
+
+	return cals, nil
+}
+
+func (r *calendarRepo) FindByGroupIDs(ctx context.Context, groupIDs []string) ([]service.CalendarData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	cals := []service.CalendarData{}
+	for _, c := range r.calendars {
+		if c.DeletedAt != 0 {
+			continue
+		}
+		for _, id := range c.GroupShares {
+			if strs.Contains(groupIDs, id) {
+				cals = append(cals, c)
+				break
+			}
+		}
+	}
+
+	return cals, nil
+}
+
 func (r *calendarRepo) Create(ctx context.Context, cal service.CalendarData) error {
 	r.m.RLock()
 	for _, c := range r.calendars {
@@ -94,3 +169,89 @@ func (r *calendarRepo) Update(ctx context.Context, cal service.CalendarData) err
 		fmt.Sprintf("not found calendar(%v)", cal.ID),
 	)
 }
+
+func (r *calendarRepo) CountByUserID(ctx context.Context, userID string) (int, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	n := 0
+	for _, c := range r.calendars {
+		if c.UserID == userID && c.DeletedAt == 0 {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *calendarRepo) FindTrashed(ctx context.Context, id string) (service.CalendarData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, c := range r.calendars {
+		if id == c.ID && c.DeletedAt != 0 {
+			return c, nil
+		}
+	}
+	return service.CalendarData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found calendar(%v) in trash", id),
+	)
+}
+
+func (r *calendarRepo) FindTrashByUserID(ctx context.Context, userID string) ([]service.CalendarData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	calendars := []service.CalendarData{}
+	for _, c := range r.calendars {
+		if c.UserID == userID && c.DeletedAt != 0 {
+			calendars = append(calendars, c)
+		}
+	}
+
+	return calendars, nil
+}
+
+func (r *calendarRepo) Trash(ctx context.Context, id string, deletedAt int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, c := range r.calendars {
+		if id == c.ID && c.DeletedAt == 0 {
+			r.calendars[i].DeletedAt = deletedAt
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found calendar(%v)", id),
+	)
+}
+
+func (r *calendarRepo) Restore(ctx context.Context, id string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, c := range r.calendars {
+		if id == c.ID && c.DeletedAt != 0 {
+			r.calendars[i].DeletedAt = 0
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found calendar(%v) in trash", id),
+	)
+}
+
+func (r *calendarRepo) Purge(ctx context.Context, before int64) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	calendars := []service.CalendarData{}
+	for _, c := range r.calendars {
+		if c.DeletedAt == 0 || c.DeletedAt >= before {
+			calendars = append(calendars, c)
+		}
+	}
+	n := len(r.calendars) - len(calendars)
+	r.calendars = calendars
+	return n, nil
+}
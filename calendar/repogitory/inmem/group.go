@@ -0,0 +1,126 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+	"github.com/x-color/slice/strs"
+)
+
+type groupRepo struct {
+	m rwLocker
+	*tables
+}
+
+func (r *groupRepo) Create(ctx context.Context, group service.GroupData) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for _, g := range r.groups {
+		if g.ID == group.ID {
+			return cerror.NewDuplicationError(
+				nil,
+				fmt.Sprintf("same key(%v)", group.ID),
+			)
+		}
+	}
+	group.Members = []string{}
+	r.groups = append(r.groups, group)
+	return nil
+}
+
+func (r *groupRepo) Delete(ctx context.Context, id string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, g := range r.groups {
+		if g.ID == id {
+			r.groups = append(r.groups[:i], r.groups[i+1:]...)
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found group(%v)", id),
+	)
+}
+
+func (r *groupRepo) Find(ctx context.Context, id string) (service.GroupData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, g := range r.groups {
+		if g.ID == id {
+			return g, nil
+		}
+	}
+	return service.GroupData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found group(%v)", id),
+	)
+}
+
+func (r *groupRepo) FindGroups(ctx context.Context, ids []string) ([]service.GroupData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	groups := []service.GroupData{}
+	for _, g := range r.groups {
+		if strs.Contains(ids, g.ID) {
+			groups = append(groups, g)
+		}
+	}
+	return groups, nil
+}
+
+func (r *groupRepo) FindByUserID(ctx context.Context, userID string) ([]service.GroupData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	groups := []service.GroupData{}
+	for _, g := range r.groups {
+		if strs.Contains(g.Members, userID) {
+			groups = append(groups, g)
+		}
+	}
+	return groups, nil
+}
+
+func (r *groupRepo) AddMember(ctx context.Context, groupID, userID string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, g := range r.groups {
+		if g.ID == groupID {
+			if !strs.Contains(g.Members, userID) {
+				// Copy members not to change slices returned before.
+				r.groups[i].Members = append(append([]string{}, g.Members...), userID)
+			}
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found group(%v)", groupID),
+	)
+}
+
+func (r *groupRepo) RemoveMember(ctx context.Context, groupID, userID string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, g := range r.groups {
+		if g.ID == groupID {
+			l, err := strs.RemoveE(g.Members, userID)
+			if err != nil {
+				return cerror.NewNotFoundError(
+					nil,
+					fmt.Sprintf("not found member(%v) of group(%v)", userID, groupID),
+				)
+			}
+			r.groups[i].Members = l
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found group(%v)", groupID),
+	)
+}
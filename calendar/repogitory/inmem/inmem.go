@@ -6,10 +6,45 @@ import (
 	"github.com/x-color/calendar/calendar/service"
 )
 
+// tables is all data in the repogitory. It is shared by the repogitory and ones in transactions.
+type tables struct {
+	calendars []service.CalendarData
+	plans     []service.PlanData
+	users     []service.UserData
+	profiles  []service.ProfileData
+	orgs      []service.OrgData
+	members   []service.MemberData
+	groups    []service.GroupData
+	transfers []service.TransferData
+	revisions []service.RevisionData
+}
+
+// rwLocker is the lock of a repogitory. Repogitories in a transaction use noLock
+// because the transaction holds the locks of all repogitories.
+type rwLocker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+type noLock struct{}
+
+func (noLock) Lock()    {}
+func (noLock) Unlock()  {}
+func (noLock) RLock()   {}
+func (noLock) RUnlock() {}
+
 type inmem struct {
+	tables       *tables
 	calendarRepo calendarRepo
 	planRepo     planRepo
 	userRepo     userRepo
+	profileRepo  profileRepo
+	orgRepo      orgRepo
+	groupRepo    groupRepo
+	transferRepo transferRepo
+	revisionRepo revisionRepo
 }
 
 func (m *inmem) Calendar() service.CalendarRepogitory {
@@ -24,22 +59,51 @@ func (m *inmem) User() service.UserRepogitory {
 	return &m.userRepo
 }
 
-func NewRepogitory() inmem {
-	c := calendarRepo{
-		m:         sync.RWMutex{},
+func (m *inmem) Profile() service.ProfileRepogitory {
+	return &m.profileRepo
+}
+
+func (m *inmem) Org() service.OrgRepogitory {
+	return &m.orgRepo
+}
+
+func (m *inmem) Member() service.MemberRepogitory {
+	return &memberRepo{org: &m.orgRepo}
+}
+
+func (m *inmem) Group() service.GroupRepogitory {
+	return &m.groupRepo
+}
+
+func (m *inmem) Transfer() service.TransferRepogitory {
+	return &m.transferRepo
+}
+
+func (m *inmem) Revision() service.RevisionRepogitory {
+	return &m.revisionRepo
+}
+
+func NewRepogitory() *inmem {
+	t := &tables{
 		calendars: []service.CalendarData{},
+		plans:     []service.PlanData{},
+		users:     []service.UserData{},
+		profiles:  []service.ProfileData{},
+		orgs:      []service.OrgData{},
+		members:   []service.MemberData{},
+		groups:    []service.GroupData{},
+		transfers: []service.TransferData{},
+		revisions: []service.RevisionData{},
 	}
-	p := planRepo{
-		m:     sync.RWMutex{},
-		plans: []service.PlanData{},
-	}
-	u := userRepo{
-		m:     sync.RWMutex{},
-		users: []service.UserData{},
-	}
-	return inmem{
-		calendarRepo: c,
-		planRepo:     p,
-		userRepo:     u,
+	return &inmem{
+		tables:       t,
+		calendarRepo: calendarRepo{m: &sync.RWMutex{}, tables: t},
+		planRepo:     planRepo{m: &sync.RWMutex{}, tables: t},
+		userRepo:     userRepo{m: &sync.RWMutex{}, tables: t},
+		profileRepo:  profileRepo{m: &sync.RWMutex{}, tables: t},
+		orgRepo:      orgRepo{m: &sync.RWMutex{}, tables: t},
+		groupRepo:    groupRepo{m: &sync.RWMutex{}, tables: t},
+		transferRepo: transferRepo{m: &sync.RWMutex{}, tables: t},
+		revisionRepo: revisionRepo{m: &sync.RWMutex{}, tables: t},
 	}
 }
@@ -0,0 +1,15 @@
+package inmem_test
+
+import (
+	"testing"
+
+	"github.com/x-color/calendar/calendar/repogitory/inmem"
+	"github.com/x-color/calendar/calendar/repogitory/repotest"
+	"github.com/x-color/calendar/calendar/service"
+)
+
+func TestRepogitory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) service.Repogitory {
+		return inmem.NewRepogitory()
+	}, nil)
+}
@@ -0,0 +1,156 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// orgRepo keeps members together with organizations to delete them with the organization.
+type orgRepo struct {
+	m rwLocker
+	*tables
+}
+
+func (r *orgRepo) Create(ctx context.Context, org service.OrgData) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for _, o := range r.orgs {
+		if o.ID == org.ID {
+			return cerror.NewDuplicationError(
+				nil,
+				fmt.Sprintf("same key(%v)", org.ID),
+			)
+		}
+	}
+	r.orgs = append(r.orgs, org)
+	return nil
+}
+
+func (r *orgRepo) Delete(ctx context.Context, id string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, o := range r.orgs {
+		if o.ID == id {
+			r.orgs = append(r.orgs[:i], r.orgs[i+1:]...)
+			members := []service.MemberData{}
+			for _, m := range r.members {
+				if m.OrgID != id {
+					members = append(members, m)
+				}
+			}
+			r.members = members
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found organization(%v)", id),
+	)
+}
+
+func (r *orgRepo) Find(ctx context.Context, id string) (service.OrgData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, o := range r.orgs {
+		if o.ID == id {
+			return o, nil
+		}
+	}
+	return service.OrgData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found organization(%v)", id),
+	)
+}
+
+func (r *orgRepo) FindByUserID(ctx context.Context, userID string) ([]service.OrgData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	orgs := []service.OrgData{}
+	for _, o := range r.orgs {
+		for _, m := range r.members {
+			if m.OrgID == o.ID && m.UserID == userID {
+				orgs = append(orgs, o)
+				break
+			}
+		}
+	}
+	return orgs, nil
+}
+
+type memberRepo struct {
+	org *orgRepo
+}
+
+func (r *memberRepo) Save(ctx context.Context, member service.MemberData) error {
+	r.org.m.Lock()
+	defer r.org.m.Unlock()
+
+	found := false
+	for _, o := range r.org.orgs {
+		if o.ID == member.OrgID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found organization(%v)", member.OrgID),
+		)
+	}
+
+	for i, m := range r.org.members {
+		if m.OrgID == member.OrgID && m.UserID == member.UserID {
+			r.org.members[i] = member
+			return nil
+		}
+	}
+	r.org.members = append(r.org.members, member)
+	return nil
+}
+
+func (r *memberRepo) Delete(ctx context.Context, orgID, userID string) error {
+	r.org.m.Lock()
+	defer r.org.m.Unlock()
+	for i, m := range r.org.members {
+		if m.OrgID == orgID && m.UserID == userID {
+			r.org.members = append(r.org.members[:i], r.org.members[i+1:]...)
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found member(%v) of organization(%v)", userID, orgID),
+	)
+}
+
+func (r *memberRepo) Find(ctx context.Context, orgID, userID string) (service.MemberData, error) {
+	r.org.m.RLock()
+	defer r.org.m.RUnlock()
+	for _, m := range r.org.members {
+		if m.OrgID == orgID && m.UserID == userID {
+			return m, nil
+		}
+	}
+	return service.MemberData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found member(%v) of organization(%v)", userID, orgID),
+	)
+}
+
+func (r *memberRepo) FindByOrgID(ctx context.Context, orgID string) ([]service.MemberData, error) {
+	r.org.m.RLock()
+	defer r.org.m.RUnlock()
+
+	members := []service.MemberData{}
+	for _, m := range r.org.members {
+		if m.OrgID == orgID {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
@@ -3,7 +3,6 @@ package inmem
 import (
 	"context"
 	"fmt"
-	"sync"
 
 	"github.com/x-color/calendar/calendar/service"
 	cerror "github.com/x-color/calendar/model/error"
@@ -11,15 +10,15 @@ import (
 )
 
 type planRepo struct {
-	m     sync.RWMutex
-	plans []service.PlanData
+	m rwLocker
+	*tables
 }
 
 func (r *planRepo) Find(ctx context.Context, id string) (service.PlanData, error) {
 	r.m.RLock()
 	defer r.m.RUnlock()
 	for _, p := range r.plans {
-		if id == p.ID {
+		if id == p.ID && p.DeletedAt == 0 {
 			return p, nil
 		}
 	}
@@ -35,7 +34,7 @@ func (r *planRepo) FindByCalendarID(ctx context.Context, calID string) ([]servic
 
 	plans := []service.PlanData{}
 	for _, p := range r.plans {
-		if strs.Contains(p.Shares, calID) {
+		if strs.Contains(p.Shares, calID) && p.DeletedAt == 0 {
 			plans = append(plans, p)
 		}
 	}
@@ -43,6 +42,25 @@ func (r *planRepo) FindByCalendarID(ctx context.Context, calID string) ([]servic
 	return plans, nil
 }
 
+func (r *planRepo) FindByCalendarIDs(ctx context.Context, calIDs []string) (map[string][]service.PlanData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	plans := map[string][]service.PlanData{}
+	for _, p := range r.plans {
+		if p.DeletedAt != 0 {
+			continue
+		}
+		for _, id := range p.Shares {
+			if strs.Contains(calIDs, id) {
+				plans[id] = append(plans[id], p)
+			}
+		}
+	}
+
+	return plans, nil
+}
+
 func (r *planRepo) Create(ctx context.Context, plan service.PlanData) error {
 	r.m.RLock()
 	for _, c := range r.plans {
@@ -94,3 +112,89 @@ func (r *planRepo) Update(ctx context.Context, plan service.PlanData) error {
 		fmt.Sprintf("not found plan(%v)", plan.ID),
 	)
 }
+
+func (r *planRepo) CountByUserID(ctx context.Context, userID string) (int, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	n := 0
+	for _, c := range r.plans {
+		if c.UserID == userID && c.DeletedAt == 0 {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *planRepo) FindTrashed(ctx context.Context, id string) (service.PlanData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, p := range r.plans {
+		if id == p.ID && p.DeletedAt != 0 {
+			return p, nil
+		}
+	}
+	return service.PlanData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found plan(%v) in trash", id),
+	)
+}
+
+func (r *planRepo) FindTrashByUserID(ctx context.Context, userID string) ([]service.PlanData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	plans := []service.PlanData{}
+	for _, p := range r.plans {
+		if p.UserID == userID && p.DeletedAt != 0 {
+			plans = append(plans, p)
+		}
+	}
+
+	return plans, nil
+}
+
+func (r *planRepo) Trash(ctx context.Context, id string, deletedAt int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, p := range r.plans {
+		if id == p.ID && p.DeletedAt == 0 {
+			r.plans[i].DeletedAt = deletedAt
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found plan(%v)", id),
+	)
+}
+
+func (r *planRepo) Restore(ctx context.Context, id string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, p := range r.plans {
+		if id == p.ID && p.DeletedAt != 0 {
+			r.plans[i].DeletedAt = 0
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found plan(%v) in trash", id),
+	)
+}
+
+func (r *planRepo) Purge(ctx context.Context, before int64) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	plans := []service.PlanData{}
+	for _, p := range r.plans {
+		if p.DeletedAt == 0 || p.DeletedAt >= before {
+			plans = append(plans, p)
+		}
+	}
+	n := len(r.plans) - len(plans)
+	r.plans = plans
+	return n, nil
+}
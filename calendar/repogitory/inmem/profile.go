@@ -0,0 +1,55 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+	"github.com/x-color/slice/strs"
+)
+
+type profileRepo struct {
+	m rwLocker
+	*tables
+}
+
+func (r *profileRepo) Find(ctx context.Context, userID string) (service.ProfileData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, p := range r.profiles {
+		if userID == p.UserID {
+			return p, nil
+		}
+	}
+	return service.ProfileData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found profile of user(%v)", userID),
+	)
+}
+
+func (r *profileRepo) FindProfiles(ctx context.Context, userIDs []string) ([]service.ProfileData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	profiles := []service.ProfileData{}
+	for _, p := range r.profiles {
+		if strs.Contains(userIDs, p.UserID) {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles, nil
+}
+
+func (r *profileRepo) Save(ctx context.Context, profile service.ProfileData) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, p := range r.profiles {
+		if p.UserID == profile.UserID {
+			r.profiles[i] = profile
+			return nil
+		}
+	}
+	r.profiles = append(r.profiles, profile)
+	return nil
+}
@@ -0,0 +1,58 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/x-color/calendar/calendar/service"
+)
+
+type revisionRepo struct {
+	m rwLocker
+	*tables
+}
+
+func (r *revisionRepo) Create(ctx context.Context, rev service.RevisionData) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.revisions = append(r.revisions, rev)
+	return nil
+}
+
+func (r *revisionRepo) FindByTargetID(ctx context.Context, targetID string) ([]service.RevisionData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	revisions := []service.RevisionData{}
+	for _, rev := range r.revisions {
+		if rev.TargetID == targetID {
+			revisions = append(revisions, rev)
+		}
+	}
+	return revisions, nil
+}
+
+func (r *revisionRepo) FindByCalendarID(ctx context.Context, calID string) ([]service.RevisionData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	revisions := []service.RevisionData{}
+	for _, rev := range r.revisions {
+		if rev.CalendarID == calID {
+			revisions = append(revisions, rev)
+		}
+	}
+	return revisions, nil
+}
+
+func (r *revisionRepo) FindByOperationID(ctx context.Context, opID string) ([]service.RevisionData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	revisions := []service.RevisionData{}
+	for _, rev := range r.revisions {
+		if rev.OperationID == opID {
+			revisions = append(revisions, rev)
+		}
+	}
+	return revisions, nil
+}
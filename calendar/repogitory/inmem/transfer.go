@@ -0,0 +1,69 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type transferRepo struct {
+	m rwLocker
+	*tables
+}
+
+func (r *transferRepo) Save(ctx context.Context, transfer service.TransferData) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, t := range r.transfers {
+		if t.CalendarID == transfer.CalendarID {
+			r.transfers[i] = transfer
+			return nil
+		}
+	}
+	r.transfers = append(r.transfers, transfer)
+	return nil
+}
+
+func (r *transferRepo) Delete(ctx context.Context, calID string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, t := range r.transfers {
+		if t.CalendarID == calID {
+			r.transfers = append(r.transfers[:i], r.transfers[i+1:]...)
+			return nil
+		}
+	}
+	return cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found transfer of calendar(%v)", calID),
+	)
+}
+
+func (r *transferRepo) Find(ctx context.Context, calID string) (service.TransferData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, t := range r.transfers {
+		if t.CalendarID == calID {
+			return t, nil
+		}
+	}
+	return service.TransferData{}, cerror.NewNotFoundError(
+		nil,
+		fmt.Sprintf("not found transfer of calendar(%v)", calID),
+	)
+}
+
+func (r *transferRepo) FindByUserID(ctx context.Context, userID string) ([]service.TransferData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	transfers := []service.TransferData{}
+	for _, t := range r.transfers {
+		if t.ToUserID == userID {
+			transfers = append(transfers, t)
+		}
+	}
+	return transfers, nil
+}
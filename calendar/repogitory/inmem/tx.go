@@ -0,0 +1,83 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/x-color/calendar/calendar/service"
+)
+
+// txRepo is the repogitory used in a transaction. Transactions in it join the running one.
+type txRepo struct {
+	*inmem
+}
+
+func (r txRepo) Transaction(ctx context.Context, f func(service.Repogitory) error) error {
+	return f(r)
+}
+
+// Transaction runs f exclusively with other operations. It holds the locks of all repogitories
+// while f runs, so that changes made in f are discarded without losing concurrent ones if f returns an error.
+func (m *inmem) Transaction(ctx context.Context, f func(service.Repogitory) error) error {
+	locks := m.locks()
+	for _, l := range locks {
+		l.Lock()
+	}
+	defer func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}()
+
+	s := m.tables.copy()
+	if err := f(txRepo{m.unlocked()}); err != nil {
+		*m.tables = s
+		return err
+	}
+	return nil
+}
+
+// copy returns a copy of all data. Copying slices is enough because the repogitories
+// replace items instead of changing them.
+func (t *tables) copy() tables {
+	return tables{
+		calendars: append([]service.CalendarData{}, t.calendars...),
+		plans:     append([]service.PlanData{}, t.plans...),
+		users:     append([]service.UserData{}, t.users...),
+		profiles:  append([]service.ProfileData{}, t.profiles...),
+		orgs:      append([]service.OrgData{}, t.orgs...),
+		members:   append([]service.MemberData{}, t.members...),
+		groups:    append([]service.GroupData{}, t.groups...),
+		transfers: append([]service.TransferData{}, t.transfers...),
+		revisions: append([]service.RevisionData{}, t.revisions...),
+	}
+}
+
+// locks returns the locks of all repogitories. They are always locked in the order.
+func (m *inmem) locks() []rwLocker {
+	return []rwLocker{
+		m.calendarRepo.m,
+		m.planRepo.m,
+		m.userRepo.m,
+		m.profileRepo.m,
+		m.orgRepo.m,
+		m.groupRepo.m,
+		m.transferRepo.m,
+		m.revisionRepo.m,
+	}
+}
+
+// unlocked returns the repogitory sharing data with m without locking it.
+func (m *inmem) unlocked() *inmem {
+	l := noLock{}
+	return &inmem{
+		tables:       m.tables,
+		calendarRepo: calendarRepo{m: l, tables: m.tables},
+		planRepo:     planRepo{m: l, tables: m.tables},
+		userRepo:     userRepo{m: l, tables: m.tables},
+		profileRepo:  profileRepo{m: l, tables: m.tables},
+		orgRepo:      orgRepo{m: l, tables: m.tables},
+		groupRepo:    groupRepo{m: l, tables: m.tables},
+		transferRepo: transferRepo{m: l, tables: m.tables},
+		revisionRepo: revisionRepo{m: l, tables: m.tables},
+	}
+}
@@ -3,15 +3,15 @@ package inmem
 import (
 	"context"
 	"fmt"
-	"sync"
 
 	"github.com/x-color/calendar/calendar/service"
 	cerror "github.com/x-color/calendar/model/error"
+	"github.com/x-color/slice/strs"
 )
 
 type userRepo struct {
-	m     sync.RWMutex
-	users []service.UserData
+	m rwLocker
+	*tables
 }
 
 func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error) {
@@ -28,6 +28,20 @@ func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error
 	)
 }
 
+func (r *userRepo) FindUsers(ctx context.Context, ids []string) ([]service.UserData, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	users := []service.UserData{}
+	for _, u := range r.users {
+		if strs.Contains(ids, u.ID) {
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
+}
+
 func (r *userRepo) Create(ctx context.Context, user service.UserData) error {
 	r.m.RLock()
 	for _, c := range r.users {
@@ -0,0 +1,422 @@
+// Package repotest is the conformance suite of repogitories of calendars.
+// All backends run it, so that services work the same on any of them.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// opts ignores the order of results and shares, because backends return them in any order.
+var opts = []cmp.Option{
+	cmpopts.EquateEmpty(),
+	cmpopts.SortSlices(func(a, b string) bool { return a < b }),
+	cmpopts.SortSlices(func(a, b service.CalendarData) bool { return a.ID < b.ID }),
+	cmpopts.SortSlices(func(a, b service.PlanData) bool { return a.ID < b.ID }),
+	cmpopts.SortSlices(func(a, b service.UserData) bool { return a.ID < b.ID }),
+	cmpopts.SortSlices(func(a, b service.ProfileData) bool { return a.UserID < b.UserID }),
+	cmpopts.SortSlices(func(a, b service.GroupData) bool { return a.ID < b.ID }),
+}
+
+// Run runs the suite on repogitories made by newRepo, which must be empty.
+// addUser signs up the user before the user is created in the repogitory, if the backend
+// requires users in the authentication. It may be nil.
+func Run(t *testing.T, newRepo func(t *testing.T) service.Repogitory, addUser func(t *testing.T, id string)) {
+	s := suite{newRepo, addUser}
+	t.Run("Calendar", s.testCalendar)
+	t.Run("Plan", s.testPlan)
+	t.Run("User", s.testUser)
+	t.Run("Profile", s.testProfile)
+	t.Run("Group", s.testGroup)
+}
+
+type suite struct {
+	newRepo func(t *testing.T) service.Repogitory
+	addUser func(t *testing.T, id string)
+}
+
+func (s suite) newUser(t *testing.T, repo service.Repogitory) string {
+	id := uuid.New().String()
+	if s.addUser != nil {
+		s.addUser(t, id)
+	}
+	must(t, repo.User().Create(context.Background(), service.UserData{ID: id}))
+	return id
+}
+
+func (s suite) testCalendar(t *testing.T) {
+	ctx := context.Background()
+	repo := s.newRepo(t)
+	cals := repo.Calendar()
+
+	alice := s.newUser(t, repo)
+	bob := s.newUser(t, repo)
+	group := service.GroupData{ID: uuid.New().String(), UserID: alice, Name: "Team"}
+	must(t, repo.Group().Create(ctx, group))
+	org := service.OrgData{ID: uuid.New().String(), Name: "Company"}
+	must(t, repo.Org().Create(ctx, org))
+
+	shared := service.CalendarData{
+		ID:          uuid.New().String(),
+		UserID:      alice,
+		Name:        "Shared",
+		Color:       "red",
+		Shares:      []string{alice, bob},
+		GroupShares: []string{group.ID},
+	}
+	private := service.CalendarData{
+		ID:     uuid.New().String(),
+		UserID: bob,
+		Name:   "Private",
+		Color:  "blue",
+		Shares: []string{bob},
+	}
+	// Calendars of organizations may have no shares.
+	orgCal := service.CalendarData{
+		ID:     uuid.New().String(),
+		UserID: alice,
+		OrgID:  org.ID,
+		Name:   "Company",
+		Color:  "green",
+	}
+	for _, cal := range []service.CalendarData{shared, private, orgCal} {
+		must(t, cals.Create(ctx, cal))
+	}
+
+	checkErr(t, "create same calendar", cals.Create(ctx, shared), cerror.ErrDuplication)
+
+	cal, err := cals.Find(ctx, shared.ID)
+	must(t, err)
+	check(t, "found calendar", shared, cal)
+	_, err = cals.Find(ctx, uuid.New().String())
+	checkErr(t, "find unknown calendar", err, cerror.ErrNotFound)
+
+	l, err := cals.FindCalendars(ctx, []string{shared.ID, private.ID, uuid.New().String()})
+	must(t, err)
+	check(t, "calendars found by IDs", []service.CalendarData{shared, private}, l)
+	l, err = cals.FindCalendars(ctx, []string{})
+	must(t, err)
+	check(t, "calendars found by no IDs", []service.CalendarData{}, l)
+
+	l, err = cals.FindByUserID(ctx, bob)
+	must(t, err)
+	check(t, "calendars shared with user", []service.CalendarData{shared, private}, l)
+	l, err = cals.FindByGroupID(ctx, group.ID)
+	must(t, err)
+	check(t, "calendars shared with group", []service.CalendarData{shared}, l)
+	l, err = cals.FindByGroupIDs(ctx, []string{group.ID, uuid.New().String()})
+	must(t, err)
+	check(t, "calendars shared with groups", []service.CalendarData{shared}, l)
+	l, err = cals.FindByGroupIDs(ctx, []string{})
+	must(t, err)
+	check(t, "calendars shared with no groups", []service.CalendarData{}, l)
+	l, err = cals.FindByOrgID(ctx, org.ID)
+	must(t, err)
+	check(t, "calendars of organization", []service.CalendarData{orgCal}, l)
+	l, err = cals.FindByOrgIDs(ctx, []string{org.ID, uuid.New().String()})
+	must(t, err)
+	check(t, "calendars of organizations", []service.CalendarData{orgCal}, l)
+	l, err = cals.FindByOrgIDs(ctx, []string{})
+	must(t, err)
+	check(t, "calendars of no organizations", []service.CalendarData{}, l)
+
+	n, err := cals.CountByUserID(ctx, alice)
+	must(t, err)
+	check(t, "number of calendars of user", 2, n)
+
+	shared.Name = "Renamed"
+	shared.Color = "yellow"
+	shared.Shares = []string{alice}
+	shared.GroupShares = []string{}
+	must(t, cals.Update(ctx, shared))
+	cal, err = cals.Find(ctx, shared.ID)
+	must(t, err)
+	check(t, "updated calendar", shared, cal)
+	l, err = cals.FindByUserID(ctx, bob)
+	must(t, err)
+	check(t, "calendars shared with user after update", []service.CalendarData{private}, l)
+	l, err = cals.FindByGroupID(ctx, group.ID)
+	must(t, err)
+	check(t, "calendars shared with group after update", []service.CalendarData{}, l)
+	checkErr(t, "update unknown calendar", cals.Update(ctx, service.CalendarData{ID: uuid.New().String(), UserID: alice}), cerror.ErrNotFound)
+
+	must(t, cals.Trash(ctx, private.ID, 100))
+	checkErr(t, "trash calendar in trash", cals.Trash(ctx, private.ID, 100), cerror.ErrNotFound)
+	_, err = cals.Find(ctx, private.ID)
+	checkErr(t, "find calendar in trash", err, cerror.ErrNotFound)
+	l, err = cals.FindByUserID(ctx, bob)
+	must(t, err)
+	check(t, "calendars shared with user in trash", []service.CalendarData{}, l)
+	n, err = cals.CountByUserID(ctx, bob)
+	must(t, err)
+	check(t, "number of calendars of user in trash", 0, n)
+
+	trashed := private
+	trashed.DeletedAt = 100
+	cal, err = cals.FindTrashed(ctx, private.ID)
+	must(t, err)
+	check(t, "calendar found in trash", trashed, cal)
+	_, err = cals.FindTrashed(ctx, shared.ID)
+	checkErr(t, "find calendar not in trash", err, cerror.ErrNotFound)
+	l, err = cals.FindTrashByUserID(ctx, bob)
+	must(t, err)
+	check(t, "calendars of user in trash", []service.CalendarData{trashed}, l)
+
+	must(t, cals.Restore(ctx, private.ID))
+	checkErr(t, "restore calendar not in trash", cals.Restore(ctx, private.ID), cerror.ErrNotFound)
+	cal, err = cals.Find(ctx, private.ID)
+	must(t, err)
+	check(t, "restored calendar", private, cal)
+
+	must(t, cals.Trash(ctx, private.ID, 100))
+	must(t, cals.Trash(ctx, orgCal.ID, 200))
+	n, err = cals.Purge(ctx, 150)
+	must(t, err)
+	check(t, "number of purged calendars", 1, n)
+	_, err = cals.FindTrashed(ctx, private.ID)
+	checkErr(t, "find purged calendar", err, cerror.ErrNotFound)
+	_, err = cals.FindTrashed(ctx, orgCal.ID)
+	must(t, err)
+
+	must(t, cals.Delete(ctx, shared.ID))
+	_, err = cals.Find(ctx, shared.ID)
+	checkErr(t, "find deleted calendar", err, cerror.ErrNotFound)
+	checkErr(t, "delete deleted calendar", cals.Delete(ctx, shared.ID), cerror.ErrNotFound)
+}
+
+func (s suite) testPlan(t *testing.T) {
+	ctx := context.Background()
+	repo := s.newRepo(t)
+	plans := repo.Plan()
+
+	alice := s.newUser(t, repo)
+	bob := s.newUser(t, repo)
+	work := service.CalendarData{ID: uuid.New().String(), UserID: alice, Name: "Work", Color: "red", Shares: []string{alice}}
+	home := service.CalendarData{ID: uuid.New().String(), UserID: alice, Name: "Home", Color: "blue", Shares: []string{alice, bob}}
+	empty := service.CalendarData{ID: uuid.New().String(), UserID: bob, Name: "Empty", Color: "green", Shares: []string{bob}}
+	for _, cal := range []service.CalendarData{work, home, empty} {
+		must(t, repo.Calendar().Create(ctx, cal))
+	}
+
+	meeting := service.PlanData{
+		ID:         uuid.New().String(),
+		CalendarID: work.ID,
+		UserID:     alice,
+		Name:       "Meeting",
+		Memo:       "Room 1",
+		Color:      "red",
+		Shares:     []string{work.ID, home.ID},
+		Begin:      1600000000,
+		End:        1600003600,
+	}
+	holiday := service.PlanData{
+		ID:         uuid.New().String(),
+		CalendarID: home.ID,
+		UserID:     bob,
+		Name:       "Holiday",
+		Color:      "blue",
+		Private:    true,
+		Shares:     []string{home.ID},
+		IsAllDay:   true,
+		Begin:      1600041600,
+		End:        1600128000,
+	}
+	for _, plan := range []service.PlanData{meeting, holiday} {
+		must(t, plans.Create(ctx, plan))
+	}
+
+	checkErr(t, "create same plan", plans.Create(ctx, meeting), cerror.ErrDuplication)
+
+	plan, err := plans.Find(ctx, meeting.ID)
+	must(t, err)
+	check(t, "found plan", meeting, plan)
+	_, err = plans.Find(ctx, uuid.New().String())
+	checkErr(t, "find unknown plan", err, cerror.ErrNotFound)
+
+	l, err := plans.FindByCalendarID(ctx, home.ID)
+	must(t, err)
+	check(t, "plans of calendar", []service.PlanData{meeting, holiday}, l)
+	m, err := plans.FindByCalendarIDs(ctx, []string{work.ID, home.ID})
+	must(t, err)
+	check(t, "plans of calendars", map[string][]service.PlanData{
+		work.ID: {meeting},
+		home.ID: {meeting, holiday},
+	}, m)
+	l, err = plans.FindByCalendarID(ctx, empty.ID)
+	must(t, err)
+	check(t, "plans of calendar without plans", []service.PlanData{}, l)
+
+	n, err := plans.CountByUserID(ctx, alice)
+	must(t, err)
+	check(t, "number of plans of user", 1, n)
+
+	meeting.Name = "Lunch"
+	meeting.Memo = ""
+	meeting.Private = true
+	meeting.Shares = []string{work.ID}
+	meeting.Begin = 1600012800
+	meeting.End = 1600016400
+	must(t, plans.Update(ctx, meeting))
+	plan, err = plans.Find(ctx, meeting.ID)
+	must(t, err)
+	check(t, "updated plan", meeting, plan)
+	l, err = plans.FindByCalendarID(ctx, home.ID)
+	must(t, err)
+	check(t, "plans of calendar after update", []service.PlanData{holiday}, l)
+	checkErr(t, "update unknown plan", plans.Update(ctx, service.PlanData{ID: uuid.New().String(), CalendarID: work.ID, UserID: alice}), cerror.ErrNotFound)
+
+	must(t, plans.Trash(ctx, holiday.ID, 100))
+	checkErr(t, "trash plan in trash", plans.Trash(ctx, holiday.ID, 100), cerror.ErrNotFound)
+	_, err = plans.Find(ctx, holiday.ID)
+	checkErr(t, "find plan in trash", err, cerror.ErrNotFound)
+	l, err = plans.FindByCalendarID(ctx, home.ID)
+	must(t, err)
+	check(t, "plans of calendar in trash", []service.PlanData{}, l)
+	n, err = plans.CountByUserID(ctx, bob)
+	must(t, err)
+	check(t, "number of plans of user in trash", 0, n)
+
+	trashed := holiday
+	trashed.DeletedAt = 100
+	plan, err = plans.FindTrashed(ctx, holiday.ID)
+	must(t, err)
+	check(t, "plan found in trash", trashed, plan)
+	_, err = plans.FindTrashed(ctx, meeting.ID)
+	checkErr(t, "find plan not in trash", err, cerror.ErrNotFound)
+	l, err = plans.FindTrashByUserID(ctx, bob)
+	must(t, err)
+	check(t, "plans of user in trash", []service.PlanData{trashed}, l)
+
+	must(t, plans.Restore(ctx, holiday.ID))
+	checkErr(t, "restore plan not in trash", plans.Restore(ctx, holiday.ID), cerror.ErrNotFound)
+	plan, err = plans.Find(ctx, holiday.ID)
+	must(t, err)
+	check(t, "restored plan", holiday, plan)
+
+	must(t, plans.Trash(ctx, holiday.ID, 100))
+	must(t, plans.Trash(ctx, meeting.ID, 200))
+	n, err = plans.Purge(ctx, 150)
+	must(t, err)
+	check(t, "number of purged plans", 1, n)
+	_, err = plans.FindTrashed(ctx, holiday.ID)
+	checkErr(t, "find purged plan", err, cerror.ErrNotFound)
+	must(t, plans.Restore(ctx, meeting.ID))
+
+	must(t, plans.Delete(ctx, meeting.ID))
+	_, err = plans.Find(ctx, meeting.ID)
+	checkErr(t, "find deleted plan", err, cerror.ErrNotFound)
+	checkErr(t, "delete deleted plan", plans.Delete(ctx, meeting.ID), cerror.ErrNotFound)
+}
+
+func (s suite) testUser(t *testing.T) {
+	ctx := context.Background()
+	repo := s.newRepo(t)
+	users := repo.User()
+
+	alice := s.newUser(t, repo)
+	bob := s.newUser(t, repo)
+
+	checkErr(t, "create same user", users.Create(ctx, service.UserData{ID: alice}), cerror.ErrDuplication)
+
+	user, err := users.Find(ctx, alice)
+	must(t, err)
+	check(t, "found user", service.UserData{ID: alice}, user)
+	_, err = users.Find(ctx, uuid.New().String())
+	checkErr(t, "find unknown user", err, cerror.ErrNotFound)
+
+	l, err := users.FindUsers(ctx, []string{alice, bob, uuid.New().String()})
+	must(t, err)
+	check(t, "users found by IDs", []service.UserData{{ID: alice}, {ID: bob}}, l)
+	l, err = users.FindUsers(ctx, []string{})
+	must(t, err)
+	check(t, "users found by no IDs", []service.UserData{}, l)
+}
+
+func (s suite) testProfile(t *testing.T) {
+	ctx := context.Background()
+	repo := s.newRepo(t)
+	profiles := repo.Profile()
+
+	alice := s.newUser(t, repo)
+	bob := s.newUser(t, repo)
+	carol := s.newUser(t, repo)
+
+	_, err := profiles.Find(ctx, alice)
+	checkErr(t, "find unsaved profile", err, cerror.ErrNotFound)
+
+	aliceProfile := service.ProfileData{UserID: alice, DisplayName: "Alice", TimeZone: "Asia/Tokyo", Locale: "ja"}
+	bobProfile := service.ProfileData{UserID: bob, AvatarURL: "https://example.com/bob.png", WeekStart: 1}
+	must(t, profiles.Save(ctx, aliceProfile))
+	must(t, profiles.Save(ctx, bobProfile))
+	aliceProfile.DisplayName = "Alice A."
+	must(t, profiles.Save(ctx, aliceProfile))
+
+	p, err := profiles.Find(ctx, alice)
+	must(t, err)
+	check(t, "saved profile", aliceProfile, p)
+
+	l, err := profiles.FindProfiles(ctx, []string{alice, bob, carol})
+	must(t, err)
+	check(t, "profiles found by user IDs", []service.ProfileData{aliceProfile, bobProfile}, l)
+	l, err = profiles.FindProfiles(ctx, []string{})
+	must(t, err)
+	check(t, "profiles found by no user IDs", []service.ProfileData{}, l)
+}
+
+func (s suite) testGroup(t *testing.T) {
+	ctx := context.Background()
+	repo := s.newRepo(t)
+	groups := repo.Group()
+
+	alice := s.newUser(t, repo)
+	bob := s.newUser(t, repo)
+
+	team := service.GroupData{ID: uuid.New().String(), UserID: alice, Name: "Team"}
+	empty := service.GroupData{ID: uuid.New().String(), UserID: bob, Name: "Empty"}
+	must(t, groups.Create(ctx, team))
+	must(t, groups.Create(ctx, empty))
+	must(t, groups.AddMember(ctx, team.ID, alice))
+	must(t, groups.AddMember(ctx, team.ID, bob))
+	team.Members = []string{alice, bob}
+
+	g, err := groups.Find(ctx, team.ID)
+	must(t, err)
+	check(t, "found group", team, g)
+	_, err = groups.Find(ctx, uuid.New().String())
+	checkErr(t, "find unknown group", err, cerror.ErrNotFound)
+
+	l, err := groups.FindGroups(ctx, []string{team.ID, empty.ID, uuid.New().String()})
+	must(t, err)
+	check(t, "groups found by IDs", []service.GroupData{team, empty}, l)
+	l, err = groups.FindGroups(ctx, []string{})
+	must(t, err)
+	check(t, "groups found by no IDs", []service.GroupData{}, l)
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("want no error, but got %v", err)
+	}
+}
+
+func check(t *testing.T, name string, want, got interface{}) {
+	t.Helper()
+	if d := cmp.Diff(want, got, opts...); d != "" {
+		t.Errorf("unexpected %v: %v", name, d)
+	}
+}
+
+func checkErr(t *testing.T, name string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%v: want %v, but got %v", name, target, err)
+	}
+}
@@ -6,68 +6,62 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/lib/pq"
 	"github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/dialect"
 	cerror "github.com/x-color/calendar/model/error"
 	"github.com/x-color/slice/strs"
 )
 
 type calendarRepo struct {
-	db *sql.DB
-	tx *sql.Tx
+	q conn
 }
 
 func (r *calendarRepo) Find(ctx context.Context, id string) (service.CalendarData, error) {
+	// Calendars of organizations may have no shares.
 	const query = `
-		SELECT cals.id, cals.userid, cals.name, cals.color, shares.userid
+		SELECT cals.id, cals.userid, COALESCE(cals.orgid, ''), cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
 		FROM calendar.calendars cals
-		INNER JOIN calendar.calendar_shares shares
-		ON cals.id = shares.calendarid 
-		WHERE cals.id = $1
+		LEFT JOIN calendar.calendar_shares shares
+		ON cals.id = shares.calendarid
+		WHERE cals.id = $1 AND cals.deleted_at IS NULL
+		ORDER BY shares.ctid
 	`
 
-	var rows *sql.Rows
-	var err error
-	if r.tx != nil {
-		rows, err = r.tx.Query(query, id)
-	} else {
-		rows, err = r.db.Query(query, id)
-	}
-	defer rows.Close()
-
-	var userID string
-	calendar := service.CalendarData{Shares: []string{}}
-	for rows.Next() {
-		err := rows.Scan(&calendar.ID, &calendar.UserID, &calendar.Name, &calendar.Color, &userID)
-		if err != nil {
-			return calendar, cerror.NewInternalError(
-				err,
-				"failed to scan query result",
-			)
-		}
-		calendar.Shares = append(calendar.Shares, userID)
+	calendars, err := r.query(ctx, query, id)
+	if err != nil {
+		return service.CalendarData{}, err
 	}
-
-	err = rows.Err()
-	switch {
-	case errors.Is(err, sql.ErrNoRows) || len(calendar.ID) == 0:
-		return calendar, cerror.NewNotFoundError(
-			err,
+	if len(calendars) == 0 {
+		return service.CalendarData{}, cerror.NewNotFoundError(
+			nil,
 			fmt.Sprintf("not found calendar(%v)", id),
 		)
-	case err != nil:
-		return calendar, cerror.NewInternalError(
-			err,
-			"failed to scan query result",
-		)
 	}
 
-	return calendar, nil
+	return calendars[0], nil
+}
+
+func (r *calendarRepo) FindCalendars(ctx context.Context, ids []string) ([]service.CalendarData, error) {
+	if len(ids) == 0 {
+		return []service.CalendarData{}, nil
+	}
+
+	list, args := dialect.InList(1, ids)
+	query := fmt.Sprintf(`
+		SELECT cals.id, cals.userid, COALESCE(cals.orgid, ''), cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
+		FROM calendar.calendars cals
+		LEFT JOIN calendar.calendar_shares shares
+		ON cals.id = shares.calendarid
+		WHERE cals.id IN (%s) AND cals.deleted_at IS NULL
+		ORDER BY cals.id, shares.ctid
+	`, list)
+
+	return r.query(ctx, query, args...)
 }
 
 func (r *calendarRepo) FindByUserID(ctx context.Context, userID string) ([]service.CalendarData, error) {
 	const query = `
-		SELECT cals.id, cals.userid, cals.name, cals.color, shares.userid
+		SELECT cals.id, cals.userid, COALESCE(cals.orgid, ''), cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
 		FROM calendar.calendars cals
 		JOIN calendar.calendar_shares shares
 		ON cals.id = shares.calendarid
@@ -75,77 +69,185 @@ func (r *calendarRepo) FindByUserID(ctx context.Context, userID string) ([]servi
 			SELECT calendarid
 			FROM calendar.calendar_shares
 			WHERE userid = $1
+		) AND cals.deleted_at IS NULL
+		ORDER BY cals.id, shares.ctid
+	`
+
+	return r.query(ctx, query, userID)
+}
+
+func (r *calendarRepo) FindByOrgID(ctx context.Context, orgID string) ([]service.CalendarData, error) {
+	const query = `
+		SELECT cals.id, cals.userid, cals.orgid, cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
+		FROM calendar.calendars cals
+		LEFT JOIN calendar.calendar_shares shares
+		ON cals.id = shares.calendarid
+		WHERE cals.orgid = $1 AND cals.deleted_at IS NULL
+		ORDER BY cals.id, shares.ctid
+	`
+
+	return r.query(ctx, query, orgID)
+}
+
+func (r *calendarRepo) FindByGroupID(ctx context.Context, groupID string) ([]service.CalendarData, error) {
+	const query = `
+		SELECT cals.id, cals.userid, COALESCE(cals.orgid, ''), cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
+		FROM calendar.calendars cals
+		JOIN calendar.calendar_shares shares
+		ON cals.id = shares.calendarid
+		WHERE cals.id IN (
+			SELECT calendarid
+			FROM calendar.calendar_shares
+			WHERE groupid = $1
+		) AND cals.deleted_at IS NULL
+		ORDER BY cals.id, shares.ctid
+	`
+
+	return r.query(ctx, query, groupID)
+}
+
+func (r *calendarRepo) FindByOrgIDs(ctx context.Context, orgIDs []string) ([]service.CalendarData, error) {
+	if len(orgIDs) == 0 {
+		return []service.CalendarData{}, nil
+	}
+
+	list, args := dialect.InList(1, orgIDs)
+	query := fmt.Sprintf(`
+		SELECT cals.id, cals.userid, cals.orgid, cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
+		FROM calendar.calendars cals
+		LEFT JOIN calendar.calendar_shares shares
+		ON cals.id = shares.calendarid
+		WHERE cals.orgid IN (%s) AND cals.deleted_at IS NULL
+		ORDER BY cals.id, shares.ctid
+	`, list)
+
+	return r.query(ctx, query, args...)
+}
+
+func (r *calendarRepo) FindByGroupIDs(ctx context.Context, groupIDs []string) ([]service.CalendarData, error) {
+	if len(groupIDs) == 0 {
+		return []service.CalendarData{}, nil
+	}
+
+	list, args := dialect.InList(1, groupIDs)
+	query := fmt.Sprintf(`
+		SELECT cals.id, cals.userid, COALESCE(cals.orgid, ''), cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
+		FROM calendar.calendars cals
+		JOIN calendar.calendar_shares shares
+		ON cals.id = shares.calendarid
+		WHERE cals.id IN (
+			SELECT calendarid
+			FROM calendar.calendar_shares
+			WHERE groupid IN (%s)
+		) AND cals.deleted_at IS NULL
+		ORDER BY cals.id, shares.ctid
+	`, list)
+
+	return r.query(ctx, query, args...)
+}
+
+func (r *calendarRepo) FindTrashed(ctx context.Context, id string) (service.CalendarData, error) {
+	const query = `
+		SELECT cals.id, cals.userid, COALESCE(cals.orgid, ''), cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
+		FROM calendar.calendars cals
+		LEFT JOIN calendar.calendar_shares shares
+		ON cals.id = shares.calendarid
+		WHERE cals.id = $1 AND cals.deleted_at IS NOT NULL
+		ORDER BY shares.ctid
+	`
+
+	calendars, err := r.query(ctx, query, id)
+	if err != nil {
+		return service.CalendarData{}, err
+	}
+	if len(calendars) == 0 {
+		return service.CalendarData{}, cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found calendar(%v) in trash", id),
 		)
-		ORDER BY cals.id
+	}
+
+	return calendars[0], nil
+}
+
+func (r *calendarRepo) FindTrashByUserID(ctx context.Context, userID string) ([]service.CalendarData, error) {
+	const query = `
+		SELECT cals.id, cals.userid, COALESCE(cals.orgid, ''), cals.name, cals.color, COALESCE(cals.deleted_at, 0), shares.userid, shares.groupid
+		FROM calendar.calendars cals
+		LEFT JOIN calendar.calendar_shares shares
+		ON cals.id = shares.calendarid
+		WHERE cals.userid = $1 AND cals.deleted_at IS NOT NULL
+		ORDER BY cals.id, shares.ctid
 	`
 
-	var rows *sql.Rows
-	var err error
-	if r.tx != nil {
-		rows, err = r.tx.Query(query, userID)
-	} else {
-		rows, err = r.db.Query(query, userID)
+	return r.query(ctx, query, userID)
+}
+
+// query runs the query selecting calendars joined with their shares.
+// Rows of the same calendar must be in a row. A share has either userid or groupid.
+// Joined rows are ordered by ctid to keep the order they are added in.
+func (r *calendarRepo) query(ctx context.Context, query string, args ...interface{}) ([]service.CalendarData, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
 	}
 	defer rows.Close()
 
 	calendars := []service.CalendarData{}
-
-	var id string
-	var cal, newCal service.CalendarData
 	for rows.Next() {
-		err := rows.Scan(&newCal.ID, &newCal.UserID, &newCal.Name, &newCal.Color, &id)
+		var cal service.CalendarData
+		var userID, groupID sql.NullString
+		err := rows.Scan(&cal.ID, &cal.UserID, &cal.OrgID, &cal.Name, &cal.Color, &cal.DeletedAt, &userID, &groupID)
 		if err != nil {
-			return nil, cerror.NewInternalError(
+			return nil, cerror.NewQueryError(
+				ctx,
 				err,
 				"failed to scan query result",
 			)
 		}
 
-		if newCal.ID == cal.ID {
-			cal.Shares = append(cal.Shares, id)
-		} else {
+		if n := len(calendars); n == 0 || calendars[n-1].ID != cal.ID {
+			cal.Shares = []string{}
+			cal.GroupShares = []string{}
 			calendars = append(calendars, cal)
-			cal = service.CalendarData{
-				ID:     newCal.ID,
-				UserID: newCal.UserID,
-				Name:   newCal.Name,
-				Color:  newCal.Color,
-				Shares: []string{id},
-			}
+		}
+		last := &calendars[len(calendars)-1]
+		if userID.Valid {
+			last.Shares = append(last.Shares, userID.String)
+		}
+		if groupID.Valid {
+			last.GroupShares = append(last.GroupShares, groupID.String)
 		}
 	}
-	calendars = append(calendars, cal)
 
-	err = rows.Err()
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		return nil, cerror.NewNotFoundError(
-			err,
-			fmt.Sprintf("not found calendars for user(%v)", id),
-		)
-	case err != nil:
-		return nil, cerror.NewInternalError(
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to scan query result",
 		)
 	}
 
-	// Remove the first data of calnedars. It is empty.
-	return calendars[1:], nil
+	return calendars, nil
 }
 
 func (r *calendarRepo) Create(ctx context.Context, cal service.CalendarData) error {
-	var err error
-	if r.tx == nil {
-		err = r.transaction(func() error {
-			return r.create(ctx, cal)
-		})
-	} else {
-		err = r.create(ctx, cal)
-	}
+	err := r.q.transaction(ctx, func(q querier) error {
+		return r.create(ctx, q, cal)
+	})
 
-	if err != nil {
-		return cerror.NewInternalError(
+	if r.q.isDuplication(err) {
+		return cerror.NewDuplicationError(
+			err,
+			fmt.Sprintf("same key(%v)", cal.ID),
+		)
+	} else if err != nil {
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to create calendar",
 		)
@@ -153,20 +255,24 @@ func (r *calendarRepo) Create(ctx context.Context, cal service.CalendarData) err
 	return nil
 }
 
-func (r *calendarRepo) create(ctx context.Context, cal service.CalendarData) error {
-	const insCalQuery = "INSERT INTO calendar.calendars (id, userid, name, color) VALUES ($1, $2, $3, $4)"
-	_, err := r.tx.Exec(insCalQuery, cal.ID, cal.UserID, cal.Name, cal.Color)
+func (r *calendarRepo) create(ctx context.Context, q querier, cal service.CalendarData) error {
+	const insCalQuery = "INSERT INTO calendar.calendars (id, userid, orgid, name, color) VALUES ($1, $2, NULLIF($3, ''), $4, $5)"
+	_, err := q.ExecContext(ctx, insCalQuery, cal.ID, cal.UserID, cal.OrgID, cal.Name, cal.Color)
 	if err != nil {
 		return err
 	}
 
 	const insSharesQuery = "INSERT INTO calendar.calendar_shares (userid, calendarid) VALUES ($1, $2)"
-	stmt, err := r.tx.Prepare(insSharesQuery)
-	if err != nil {
-		return err
-	}
 	for _, userID := range cal.Shares {
-		_, err := stmt.Exec(userID, cal.ID)
+		_, err := q.ExecContext(ctx, insSharesQuery, userID, cal.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	const insGroupSharesQuery = "INSERT INTO calendar.calendar_shares (groupid, calendarid) VALUES ($1, $2)"
+	for _, groupID := range cal.GroupShares {
+		_, err := q.ExecContext(ctx, insGroupSharesQuery, groupID, cal.ID)
 		if err != nil {
 			return err
 		}
@@ -176,19 +282,15 @@ func (r *calendarRepo) create(ctx context.Context, cal service.CalendarData) err
 }
 
 func (r *calendarRepo) Delete(ctx context.Context, id string) error {
-	var err error
-	if r.tx == nil {
-		err = r.transaction(func() error {
-			return r.delete(ctx, id)
-		})
-	} else {
-		err = r.delete(ctx, id)
-	}
+	err := r.q.transaction(ctx, func(q querier) error {
+		return r.delete(ctx, q, id)
+	})
 	switch {
 	case errors.Is(err, cerror.ErrNotFound):
 		return err
 	case err != nil:
-		return cerror.NewInternalError(
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to delete calendar",
 		)
@@ -196,9 +298,9 @@ func (r *calendarRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *calendarRepo) delete(ctx context.Context, id string) error {
+func (r *calendarRepo) delete(ctx context.Context, q querier, id string) error {
 	const query = "DELETE FROM calendar.calendars WHERE id = $1"
-	res, err := r.tx.Exec(query, id)
+	res, err := q.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -217,21 +319,78 @@ func (r *calendarRepo) delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *calendarRepo) Update(ctx context.Context, cal service.CalendarData) error {
-	var err error
-	if r.tx == nil {
-		err = r.transaction(func() error {
-			return r.update(ctx, cal)
-		})
-	} else {
-		err = r.update(ctx, cal)
+func (r *calendarRepo) Trash(ctx context.Context, id string, deletedAt int64) error {
+	const query = "UPDATE calendar.calendars SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL"
+	return r.setDeletedAt(ctx, query, fmt.Sprintf("not found calendar(%v)", id), deletedAt, id)
+}
+
+func (r *calendarRepo) Restore(ctx context.Context, id string) error {
+	const query = "UPDATE calendar.calendars SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL"
+	return r.setDeletedAt(ctx, query, fmt.Sprintf("not found calendar(%v) in trash", id), id)
+}
+
+// setDeletedAt runs the query updating deleted_at of a calendar. It is a not found error if no calendar is updated.
+func (r *calendarRepo) setDeletedAt(ctx context.Context, query, notFoundMsg string, args ...interface{}) error {
+	res, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
 	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			notFoundMsg,
+		)
+	}
+	return nil
+}
+
+func (r *calendarRepo) Purge(ctx context.Context, before int64) (int, error) {
+	const query = "DELETE FROM calendar.calendars WHERE deleted_at < $1"
+
+	res, err := r.q.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to purge calendars",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	return int(n), nil
+}
+
+func (r *calendarRepo) Update(ctx context.Context, cal service.CalendarData) error {
+	err := r.q.transaction(ctx, func(q querier) error {
+		return r.update(ctx, q, cal)
+	})
 
 	switch {
 	case errors.Is(err, cerror.ErrNotFound):
 		return err
 	case err != nil:
-		return cerror.NewInternalError(
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to update calendar",
 		)
@@ -240,52 +399,71 @@ func (r *calendarRepo) Update(ctx context.Context, cal service.CalendarData) err
 	return nil
 }
 
-func (r *calendarRepo) update(ctx context.Context, cal service.CalendarData) error {
-	const query = "SELECT userid FROM calendar.calendar_shares WHERE calendarid = $1"
+func (r *calendarRepo) update(ctx context.Context, q querier, cal service.CalendarData) error {
+	// Shares are changed after the calendar is found by updating it.
+	const updateCalQuery = `
+		UPDATE calendar.calendars
+		SET name = $1, color = $2, userid = $3
+		WHERE id = $4
+	`
+	res, err := q.ExecContext(ctx, updateCalQuery, cal.Name, cal.Color, cal.UserID, cal.ID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found calendar(%v)", cal.ID),
+		)
+	}
+
+	const query = "SELECT userid, groupid FROM calendar.calendar_shares WHERE calendarid = $1"
 
-	rows, err := r.tx.Query(query, cal.ID)
+	rows, err := q.QueryContext(ctx, query, cal.ID)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
 	userIDs := []string{}
+	groupIDs := []string{}
 	for rows.Next() {
-		var id string
-		err := rows.Scan(&id)
+		var userID, groupID sql.NullString
+		err := rows.Scan(&userID, &groupID)
 		if err != nil {
-			return cerror.NewInternalError(
+			return cerror.NewQueryError(
+				ctx,
 				err,
 				"failed to scan query result",
 			)
 		}
-		userIDs = append(userIDs, id)
+		if userID.Valid {
+			userIDs = append(userIDs, userID.String)
+		}
+		if groupID.Valid {
+			groupIDs = append(groupIDs, groupID.String)
+		}
 	}
 
-	err = rows.Err()
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		return cerror.NewNotFoundError(
-			err,
-			fmt.Sprintf("not found calendar(%v)", cal.ID),
-		)
-	case err != nil:
-		return cerror.NewInternalError(
+	if err := rows.Err(); err != nil {
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to scan query result",
 		)
 	}
 
 	if delUserIDs := strs.Sub(userIDs, cal.Shares); len(delUserIDs) > 0 {
-		l := make([]string, len(delUserIDs))
-		for i := 0; i < len(delUserIDs); i++ {
-			l[i] = fmt.Sprintf("$%v", i+2)
-		}
-		delSharesQuery := `
+		list, args := dialect.InList(2, delUserIDs)
+		delSharesQuery := fmt.Sprintf(`
 			DELETE FROM calendar.calendar_shares
-			WHERE calendarid = $1 AND userid = ANY($2)
-		`
-		_, err = r.tx.Exec(delSharesQuery, cal.ID, pq.Array(delUserIDs))
+			WHERE calendarid = $1 AND userid IN (%s)
+		`, list)
+		_, err = q.ExecContext(ctx, delSharesQuery, append([]interface{}{cal.ID}, args...)...)
 		if err != nil {
 			return err
 		}
@@ -294,62 +472,46 @@ func (r *calendarRepo) update(ctx context.Context, cal service.CalendarData) err
 	addUserIDs := strs.Sub(cal.Shares, userIDs)
 	addSharesQuery := "INSERT INTO calendar.calendar_shares (calendarid, userid) VALUES ($1, $2)"
 	for _, id := range addUserIDs {
-		_, err := r.tx.Exec(addSharesQuery, cal.ID, id)
+		_, err := q.ExecContext(ctx, addSharesQuery, cal.ID, id)
 		if err != nil {
 			return err
 		}
 	}
 
-	const updateCalQuery = `
-		UPDATE calendar.calendars
-		SET name = $1, color = $2
-		WHERE id = $3
-	`
-	_, err = r.tx.Exec(updateCalQuery, cal.Name, cal.Color, cal.ID)
-	return err
-}
-
-func (r *calendarRepo) transaction(f func() error) error {
-	err := r.beginTx()
-	if err != nil {
-		return err
+	if delGroupIDs := strs.Sub(groupIDs, cal.GroupShares); len(delGroupIDs) > 0 {
+		list, args := dialect.InList(2, delGroupIDs)
+		delGroupSharesQuery := fmt.Sprintf(`
+			DELETE FROM calendar.calendar_shares
+			WHERE calendarid = $1 AND groupid IN (%s)
+		`, list)
+		_, err = q.ExecContext(ctx, delGroupSharesQuery, append([]interface{}{cal.ID}, args...)...)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = f()
-	if err != nil {
-		if rerr := r.rollbackTx(); rerr != nil {
-			return rerr
+	addGroupSharesQuery := "INSERT INTO calendar.calendar_shares (calendarid, groupid) VALUES ($1, $2)"
+	for _, id := range strs.Sub(cal.GroupShares, groupIDs) {
+		_, err := q.ExecContext(ctx, addGroupSharesQuery, cal.ID, id)
+		if err != nil {
+			return err
 		}
-		return err
 	}
 
-	return r.commitTx()
+	return nil
 }
 
-func (r *calendarRepo) beginTx() error {
-	tx, err := r.db.Begin()
-	if err == nil {
-		r.tx = tx
-	}
-	return err
-}
+func (r *calendarRepo) CountByUserID(ctx context.Context, userID string) (int, error) {
+	const query = "SELECT COUNT(*) FROM calendar.calendars WHERE userid = $1 AND deleted_at IS NULL"
 
-func (r *calendarRepo) commitTx() error {
-	err := r.tx.Commit()
+	var n int
+	err := r.q.QueryRowContext(ctx, query, userID).Scan(&n)
 	if err != nil {
-		if rerr := r.tx.Rollback(); rerr != nil {
-			err = cerror.NewInternalError(
-				rerr,
-				"failed to commit and rollback",
-			)
-		}
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to count calendars",
+		)
 	}
-	r.tx = nil
-	return err
-}
-
-func (r *calendarRepo) rollbackTx() error {
-	err := r.tx.Rollback()
-	r.tx = nil
-	return err
+	return n, nil
 }
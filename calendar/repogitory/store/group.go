@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/dialect"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type groupRepo struct {
+	q conn
+}
+
+func (r *groupRepo) Create(ctx context.Context, group service.GroupData) error {
+	const query = "INSERT INTO calendar.groups (id, userid, name) VALUES ($1, $2, $3)"
+
+	_, err := r.q.ExecContext(ctx, query, group.ID, group.UserID, group.Name)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	return nil
+}
+
+func (r *groupRepo) Delete(ctx context.Context, id string) error {
+	// Members and shares of calendars are deleted by cascade.
+	const query = "DELETE FROM calendar.groups WHERE id = $1"
+
+	res, err := r.q.ExecContext(ctx, query, id)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found group(%v)", id),
+		)
+	}
+	return nil
+}
+
+func (r *groupRepo) Find(ctx context.Context, id string) (service.GroupData, error) {
+	const query = `
+		SELECT grps.id, grps.userid, grps.name, members.userid
+		FROM calendar.groups grps
+		LEFT JOIN calendar.group_members members
+		ON grps.id = members.groupid
+		WHERE grps.id = $1
+		ORDER BY members.ctid
+	`
+
+	groups, err := r.query(ctx, query, id)
+	if err != nil {
+		return service.GroupData{}, err
+	}
+	if len(groups) == 0 {
+		return service.GroupData{}, cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found group(%v)", id),
+		)
+	}
+	return groups[0], nil
+}
+
+func (r *groupRepo) FindGroups(ctx context.Context, ids []string) ([]service.GroupData, error) {
+	if len(ids) == 0 {
+		return []service.GroupData{}, nil
+	}
+
+	list, args := dialect.InList(1, ids)
+	query := fmt.Sprintf(`
+		SELECT grps.id, grps.userid, grps.name, members.userid
+		FROM calendar.groups grps
+		LEFT JOIN calendar.group_members members
+		ON grps.id = members.groupid
+		WHERE grps.id IN (%s)
+		ORDER BY grps.id, members.ctid
+	`, list)
+
+	return r.query(ctx, query, args...)
+}
+
+func (r *groupRepo) FindByUserID(ctx context.Context, userID string) ([]service.GroupData, error) {
+	const query = `
+		SELECT grps.id, grps.userid, grps.name, members.userid
+		FROM calendar.groups grps
+		JOIN calendar.group_members members
+		ON grps.id = members.groupid
+		WHERE grps.id IN (
+			SELECT groupid
+			FROM calendar.group_members
+			WHERE userid = $1
+		)
+		ORDER BY grps.id, members.ctid
+	`
+
+	return r.query(ctx, query, userID)
+}
+
+// query runs the query selecting groups joined with their members. Rows of the same group must be in a row.
+// Joined rows are ordered by ctid to keep the order they are added in.
+func (r *groupRepo) query(ctx context.Context, query string, args ...interface{}) ([]service.GroupData, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	groups := []service.GroupData{}
+	for rows.Next() {
+		var group service.GroupData
+		var userID sql.NullString
+		if err := rows.Scan(&group.ID, &group.UserID, &group.Name, &userID); err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+
+		if n := len(groups); n == 0 || groups[n-1].ID != group.ID {
+			group.Members = []string{}
+			groups = append(groups, group)
+		}
+		if userID.Valid {
+			last := &groups[len(groups)-1]
+			last.Members = append(last.Members, userID.String)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return groups, nil
+}
+
+func (r *groupRepo) AddMember(ctx context.Context, groupID, userID string) error {
+	const query = `
+		INSERT INTO calendar.group_members (groupid, userid) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	_, err := r.q.ExecContext(ctx, query, groupID, userID)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	return nil
+}
+
+func (r *groupRepo) RemoveMember(ctx context.Context, groupID, userID string) error {
+	const query = "DELETE FROM calendar.group_members WHERE groupid = $1 AND userid = $2"
+
+	res, err := r.q.ExecContext(ctx, query, groupID, userID)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found member(%v) of group(%v)", userID, groupID),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type orgRepo struct {
+	q conn
+}
+
+func (r *orgRepo) Create(ctx context.Context, org service.OrgData) error {
+	const query = "INSERT INTO calendar.orgs (id, name) VALUES ($1, $2)"
+
+	_, err := r.q.ExecContext(ctx, query, org.ID, org.Name)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	return nil
+}
+
+func (r *orgRepo) Delete(ctx context.Context, id string) error {
+	// Members and calendars are deleted by cascade.
+	const query = "DELETE FROM calendar.orgs WHERE id = $1"
+
+	res, err := r.q.ExecContext(ctx, query, id)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found organization(%v)", id),
+		)
+	}
+	return nil
+}
+
+func (r *orgRepo) Find(ctx context.Context, id string) (service.OrgData, error) {
+	const query = "SELECT id, name FROM calendar.orgs WHERE id = $1"
+
+	org := service.OrgData{}
+	err := r.q.QueryRowContext(ctx, query, id).Scan(&org.ID, &org.Name)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return org, cerror.NewNotFoundError(
+			err,
+			fmt.Sprintf("not found organization(%v)", id),
+		)
+	case err != nil:
+		return org, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return org, nil
+}
+
+func (r *orgRepo) FindByUserID(ctx context.Context, userID string) ([]service.OrgData, error) {
+	const query = `
+		SELECT orgs.id, orgs.name
+		FROM calendar.orgs orgs
+		JOIN calendar.org_members members
+		ON orgs.id = members.orgid
+		WHERE members.userid = $1
+		ORDER BY orgs.name, orgs.id
+	`
+
+	rows, err := r.q.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	orgs := []service.OrgData{}
+	for rows.Next() {
+		var org service.OrgData
+		if err := rows.Scan(&org.ID, &org.Name); err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+		orgs = append(orgs, org)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return orgs, nil
+}
+
+type memberRepo struct {
+	q conn
+}
+
+func (r *memberRepo) Save(ctx context.Context, member service.MemberData) error {
+	const query = `
+		INSERT INTO calendar.org_members (orgid, userid, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (orgid, userid) DO UPDATE SET role = EXCLUDED.role
+	`
+
+	_, err := r.q.ExecContext(ctx, query, member.OrgID, member.UserID, member.Role)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	return nil
+}
+
+func (r *memberRepo) Delete(ctx context.Context, orgID, userID string) error {
+	const query = "DELETE FROM calendar.org_members WHERE orgid = $1 AND userid = $2"
+
+	res, err := r.q.ExecContext(ctx, query, orgID, userID)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found member(%v) of organization(%v)", userID, orgID),
+		)
+	}
+	return nil
+}
+
+func (r *memberRepo) Find(ctx context.Context, orgID, userID string) (service.MemberData, error) {
+	const query = "SELECT orgid, userid, role FROM calendar.org_members WHERE orgid = $1 AND userid = $2"
+
+	member := service.MemberData{}
+	err := r.q.QueryRowContext(ctx, query, orgID, userID).Scan(&member.OrgID, &member.UserID, &member.Role)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return member, cerror.NewNotFoundError(
+			err,
+			fmt.Sprintf("not found member(%v) of organization(%v)", userID, orgID),
+		)
+	case err != nil:
+		return member, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return member, nil
+}
+
+func (r *memberRepo) FindByOrgID(ctx context.Context, orgID string) ([]service.MemberData, error) {
+	const query = "SELECT orgid, userid, role FROM calendar.org_members WHERE orgid = $1 ORDER BY userid"
+
+	rows, err := r.q.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	members := []service.MemberData{}
+	for rows.Next() {
+		var m service.MemberData
+		if err := rows.Scan(&m.OrgID, &m.UserID, &m.Role); err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return members, nil
+}
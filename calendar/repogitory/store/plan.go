@@ -2,75 +2,47 @@ package store
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
 
-	"github.com/lib/pq"
 	"github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/dialect"
 	cerror "github.com/x-color/calendar/model/error"
 	"github.com/x-color/slice/strs"
 )
 
 type planRepo struct {
-	db *sql.DB
-	tx *sql.Tx
+	q conn
 }
 
 func (r *planRepo) Find(ctx context.Context, id string) (service.PlanData, error) {
 	const query = `
-		SELECT plans.id, plans.userid, plans.calendarid, plans.name, plans.memo,
-			   plans.color, plans.private, plans.isallday, plans.begintime, plans.endtime, shares.calendarid
+		SELECT plans.id, plans.userid, plans.calendarid, plans.name, plans.memo, plans.color, plans.private,
+			   plans.isallday, plans.begintime, plans.endtime, COALESCE(plans.deleted_at, 0), shares.calendarid
 		FROM calendar.plans plans
 		INNER JOIN calendar.plan_shares shares
-		ON plans.id = shares.planid 
-		WHERE plans.id = $1
+		ON plans.id = shares.planid
+		WHERE plans.id = $1 AND plans.deleted_at IS NULL
+		ORDER BY shares.ctid
 	`
 
-	var rows *sql.Rows
-	var err error
-	if r.tx != nil {
-		rows, err = r.tx.Query(query, id)
-	} else {
-		rows, err = r.db.Query(query, id)
-	}
-	defer rows.Close()
-
-	var calID string
-	plan := service.PlanData{Shares: []string{}}
-	for rows.Next() {
-		err := rows.Scan(&plan.ID, &plan.UserID, &plan.CalendarID, &plan.Name, &plan.Memo,
-			&plan.Color, &plan.Private, &plan.IsAllDay, &plan.Begin, &plan.End, &calID)
-		if err != nil {
-			return plan, cerror.NewInternalError(
-				err,
-				"failed to scan query result",
-			)
-		}
-		plan.Shares = append(plan.Shares, calID)
+	plans, err := r.query(ctx, query, id)
+	if err != nil {
+		return service.PlanData{}, err
 	}
-
-	err = rows.Err()
-	switch {
-	case errors.Is(err, sql.ErrNoRows) || len(plan.ID) == 0:
-		return plan, cerror.NewNotFoundError(
-			err,
+	if len(plans) == 0 {
+		return service.PlanData{}, cerror.NewNotFoundError(
+			nil,
 			fmt.Sprintf("not found plan(%v)", id),
 		)
-	case err != nil:
-		return plan, cerror.NewInternalError(
-			err,
-			"failed to scan query result",
-		)
 	}
 
-	return plan, nil
+	return plans[0], nil
 }
 
 func (r *planRepo) FindByCalendarID(ctx context.Context, calID string) ([]service.PlanData, error) {
 	const query = `
-		SELECT plans.id, plans.userid, plans.calendarid, plans.name, plans.memo,
-			   plans.color, plans.private, plans.isallday, plans.begintime,  plans.endtime, shares.calendarid
+		SELECT plans.id, plans.userid, plans.calendarid, plans.name, plans.memo, plans.color, plans.private,
+			   plans.isallday, plans.begintime, plans.endtime, COALESCE(plans.deleted_at, 0), shares.calendarid
 		FROM calendar.plans plans
 		JOIN calendar.plan_shares shares
 		ON plans.id = shares.planid
@@ -78,84 +50,151 @@ func (r *planRepo) FindByCalendarID(ctx context.Context, calID string) ([]servic
 			SELECT planid
 			FROM calendar.plan_shares
 			WHERE calendarid = $1
+		) AND plans.deleted_at IS NULL
+		ORDER BY plans.id, shares.ctid
+	`
+
+	return r.query(ctx, query, calID)
+}
+
+func (r *planRepo) FindByCalendarIDs(ctx context.Context, calIDs []string) (map[string][]service.PlanData, error) {
+	if len(calIDs) == 0 {
+		return map[string][]service.PlanData{}, nil
+	}
+
+	list, args := dialect.InList(1, calIDs)
+	query := fmt.Sprintf(`
+		SELECT plans.id, plans.userid, plans.calendarid, plans.name, plans.memo, plans.color, plans.private,
+			   plans.isallday, plans.begintime, plans.endtime, COALESCE(plans.deleted_at, 0), shares.calendarid
+		FROM calendar.plans plans
+		JOIN calendar.plan_shares shares
+		ON plans.id = shares.planid
+		WHERE plans.id IN (
+			SELECT planid
+			FROM calendar.plan_shares
+			WHERE calendarid IN (%s)
+		) AND plans.deleted_at IS NULL
+		ORDER BY plans.id, shares.ctid
+	`, list)
+
+	plans, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return groupByCalendarIDs(plans, calIDs), nil
+}
+
+// groupByCalendarIDs returns plans keyed by the calendar IDs they are shared with.
+func groupByCalendarIDs(plans []service.PlanData, calIDs []string) map[string][]service.PlanData {
+	grouped := map[string][]service.PlanData{}
+	for _, plan := range plans {
+		for _, id := range plan.Shares {
+			if strs.Contains(calIDs, id) {
+				grouped[id] = append(grouped[id], plan)
+			}
+		}
+	}
+	return grouped
+}
+
+func (r *planRepo) FindTrashed(ctx context.Context, id string) (service.PlanData, error) {
+	const query = `
+		SELECT plans.id, plans.userid, plans.calendarid, plans.name, plans.memo, plans.color, plans.private,
+			   plans.isallday, plans.begintime, plans.endtime, COALESCE(plans.deleted_at, 0), shares.calendarid
+		FROM calendar.plans plans
+		INNER JOIN calendar.plan_shares shares
+		ON plans.id = shares.planid
+		WHERE plans.id = $1 AND plans.deleted_at IS NOT NULL
+		ORDER BY shares.ctid
+	`
+
+	plans, err := r.query(ctx, query, id)
+	if err != nil {
+		return service.PlanData{}, err
+	}
+	if len(plans) == 0 {
+		return service.PlanData{}, cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found plan(%v) in trash", id),
 		)
-		ORDER BY plans.id
+	}
+
+	return plans[0], nil
+}
+
+func (r *planRepo) FindTrashByUserID(ctx context.Context, userID string) ([]service.PlanData, error) {
+	const query = `
+		SELECT plans.id, plans.userid, plans.calendarid, plans.name, plans.memo, plans.color, plans.private,
+			   plans.isallday, plans.begintime, plans.endtime, COALESCE(plans.deleted_at, 0), shares.calendarid
+		FROM calendar.plans plans
+		JOIN calendar.plan_shares shares
+		ON plans.id = shares.planid
+		WHERE plans.userid = $1 AND plans.deleted_at IS NOT NULL
+		ORDER BY plans.id, shares.ctid
 	`
 
-	var rows *sql.Rows
-	var err error
-	if r.tx != nil {
-		rows, err = r.tx.Query(query, calID)
-	} else {
-		rows, err = r.db.Query(query, calID)
+	return r.query(ctx, query, userID)
+}
+
+// query runs the query selecting plans joined with their shares. Rows of the same plan must be in a row.
+// Joined rows are ordered by ctid to keep the order they are added in.
+func (r *planRepo) query(ctx context.Context, query string, args ...interface{}) ([]service.PlanData, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
 	}
 	defer rows.Close()
 
 	plans := []service.PlanData{}
-
-	var id string
-	var plan, newPlan service.PlanData
 	for rows.Next() {
-		err := rows.Scan(&newPlan.ID, &newPlan.UserID, &newPlan.CalendarID, &newPlan.Name, &newPlan.Memo,
-			&newPlan.Color, &newPlan.Private, &newPlan.IsAllDay, &newPlan.Begin, &newPlan.End, &id)
+		var plan service.PlanData
+		var calID string
+		err := rows.Scan(&plan.ID, &plan.UserID, &plan.CalendarID, &plan.Name, &plan.Memo, &plan.Color, &plan.Private,
+			&plan.IsAllDay, &plan.Begin, &plan.End, &plan.DeletedAt, &calID)
 		if err != nil {
-			return nil, cerror.NewInternalError(
+			return nil, cerror.NewQueryError(
+				ctx,
 				err,
 				"failed to scan query result",
 			)
 		}
 
-		if newPlan.ID == plan.ID {
-			plan.Shares = append(plan.Shares, id)
-		} else {
+		if n := len(plans); n == 0 || plans[n-1].ID != plan.ID {
+			plan.Shares = []string{}
 			plans = append(plans, plan)
-			plan = service.PlanData{
-				ID:         newPlan.ID,
-				UserID:     newPlan.UserID,
-				CalendarID: newPlan.CalendarID,
-				Name:       newPlan.Name,
-				Memo:       newPlan.Memo,
-				Color:      newPlan.Color,
-				Private:    newPlan.Private,
-				IsAllDay:   newPlan.IsAllDay,
-				Begin:      newPlan.Begin,
-				End:        newPlan.End,
-				Shares:     []string{id},
-			}
 		}
+		last := &plans[len(plans)-1]
+		last.Shares = append(last.Shares, calID)
 	}
-	plans = append(plans, plan)
 
-	err = rows.Err()
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		return nil, cerror.NewNotFoundError(
-			err,
-			fmt.Sprintf("not found plans of calendar(%v)", calID),
-		)
-	case err != nil:
-		return nil, cerror.NewInternalError(
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to scan query result",
 		)
 	}
 
-	// Remove the first data of plans. It is empty.
-	return plans[1:], nil
+	return plans, nil
 }
 
 func (r *planRepo) Create(ctx context.Context, plan service.PlanData) error {
-	var err error
-	if r.tx == nil {
-		err = r.transaction(func() error {
-			return r.create(ctx, plan)
-		})
-	} else {
-		err = r.create(ctx, plan)
-	}
+	err := r.q.transaction(ctx, func(q querier) error {
+		return r.create(ctx, q, plan)
+	})
 
-	if err != nil {
-		return cerror.NewInternalError(
+	if r.q.isDuplication(err) {
+		return cerror.NewDuplicationError(
+			err,
+			fmt.Sprintf("same key(%v)", plan.ID),
+		)
+	} else if err != nil {
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to create plan",
 		)
@@ -163,24 +202,20 @@ func (r *planRepo) Create(ctx context.Context, plan service.PlanData) error {
 	return nil
 }
 
-func (r *planRepo) create(ctx context.Context, plan service.PlanData) error {
+func (r *planRepo) create(ctx context.Context, q querier, plan service.PlanData) error {
 	const insPlanQuery = `
 		INSERT INTO calendar.plans (id, userid, calendarid, name, memo, color, private, isallday, begintime, endtime)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
-	_, err := r.tx.Exec(insPlanQuery, plan.ID, plan.UserID, plan.CalendarID, plan.Name, plan.Memo,
+	_, err := q.ExecContext(ctx, insPlanQuery, plan.ID, plan.UserID, plan.CalendarID, plan.Name, plan.Memo,
 		plan.Color, plan.Private, plan.IsAllDay, plan.Begin, plan.End)
 	if err != nil {
 		return err
 	}
 
 	const insSharesQuery = "INSERT INTO calendar.plan_shares (calendarid, planid) VALUES ($1, $2)"
-	stmt, err := r.tx.Prepare(insSharesQuery)
-	if err != nil {
-		return err
-	}
 	for _, calID := range plan.Shares {
-		_, err := stmt.Exec(calID, plan.ID)
+		_, err := q.ExecContext(ctx, insSharesQuery, calID, plan.ID)
 		if err != nil {
 			return err
 		}
@@ -190,17 +225,13 @@ func (r *planRepo) create(ctx context.Context, plan service.PlanData) error {
 }
 
 func (r *planRepo) Delete(ctx context.Context, id string) error {
-	var err error
-	if r.tx == nil {
-		err = r.transaction(func() error {
-			return r.delete(ctx, id)
-		})
-	} else {
-		err = r.delete(ctx, id)
-	}
+	err := r.q.transaction(ctx, func(q querier) error {
+		return r.delete(ctx, q, id)
+	})
 
 	if err != nil {
-		return cerror.NewInternalError(
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to delete plan",
 		)
@@ -208,9 +239,9 @@ func (r *planRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *planRepo) delete(ctx context.Context, id string) error {
+func (r *planRepo) delete(ctx context.Context, q querier, id string) error {
 	const query = "DELETE FROM calendar.plans WHERE id = $1"
-	res, err := r.tx.Exec(query, id)
+	res, err := q.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -229,18 +260,75 @@ func (r *planRepo) delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *planRepo) Update(ctx context.Context, plan service.PlanData) error {
-	var err error
-	if r.tx == nil {
-		err = r.transaction(func() error {
-			return r.update(ctx, plan)
-		})
-	} else {
-		err = r.update(ctx, plan)
+func (r *planRepo) Trash(ctx context.Context, id string, deletedAt int64) error {
+	const query = "UPDATE calendar.plans SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL"
+	return r.setDeletedAt(ctx, query, fmt.Sprintf("not found plan(%v)", id), deletedAt, id)
+}
+
+func (r *planRepo) Restore(ctx context.Context, id string) error {
+	const query = "UPDATE calendar.plans SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL"
+	return r.setDeletedAt(ctx, query, fmt.Sprintf("not found plan(%v) in trash", id), id)
+}
+
+// setDeletedAt runs the query updating deleted_at of a plan. It is a not found error if no plan is updated.
+func (r *planRepo) setDeletedAt(ctx context.Context, query, notFoundMsg string, args ...interface{}) error {
+	res, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
 	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			notFoundMsg,
+		)
+	}
+	return nil
+}
+
+func (r *planRepo) Purge(ctx context.Context, before int64) (int, error) {
+	const query = "DELETE FROM calendar.plans WHERE deleted_at < $1"
 
+	res, err := r.q.ExecContext(ctx, query, before)
 	if err != nil {
-		return cerror.NewInternalError(
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to purge plans",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	return int(n), nil
+}
+
+func (r *planRepo) Update(ctx context.Context, plan service.PlanData) error {
+	err := r.q.transaction(ctx, func(q querier) error {
+		return r.update(ctx, q, plan)
+	})
+
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to update plan",
 		)
@@ -248,10 +336,32 @@ func (r *planRepo) Update(ctx context.Context, plan service.PlanData) error {
 	return nil
 }
 
-func (r *planRepo) update(ctx context.Context, plan service.PlanData) error {
+func (r *planRepo) update(ctx context.Context, q querier, plan service.PlanData) error {
+	// Shares are changed after the plan is found by updating it.
+	const updateCalQuery = `
+		UPDATE calendar.plans
+		SET name = $1, memo = $2, color = $3, private = $4, isallday = $5, begintime = $6, endtime = $7, userid = $8
+		WHERE id = $9
+	`
+	res, err := q.ExecContext(ctx, updateCalQuery, plan.Name, plan.Memo, plan.Color, plan.Private,
+		plan.IsAllDay, plan.Begin, plan.End, plan.UserID, plan.ID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found plan(%v)", plan.ID),
+		)
+	}
+
 	const query = "SELECT calendarid FROM calendar.plan_shares WHERE planid = $1"
 
-	rows, err := r.tx.Query(query, plan.ID)
+	rows, err := q.QueryContext(ctx, query, plan.ID)
 	if err != nil {
 		return err
 	}
@@ -262,7 +372,8 @@ func (r *planRepo) update(ctx context.Context, plan service.PlanData) error {
 		var id string
 		err := rows.Scan(&id)
 		if err != nil {
-			return cerror.NewInternalError(
+			return cerror.NewQueryError(
+				ctx,
 				err,
 				"failed to scan query result",
 			)
@@ -270,30 +381,21 @@ func (r *planRepo) update(ctx context.Context, plan service.PlanData) error {
 		calIDs = append(calIDs, id)
 	}
 
-	err = rows.Err()
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		return cerror.NewNotFoundError(
-			err,
-			fmt.Sprintf("not found plan(%v)", plan.ID),
-		)
-	case err != nil:
-		return cerror.NewInternalError(
+	if err := rows.Err(); err != nil {
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to scan query result",
 		)
 	}
 
 	if delCalIDs := strs.Sub(calIDs, plan.Shares); len(delCalIDs) > 0 {
-		l := make([]string, len(delCalIDs))
-		for i := 0; i < len(delCalIDs); i++ {
-			l[i] = fmt.Sprintf("$%v", i+2)
-		}
-		delSharesQuery := `
+		list, args := dialect.InList(2, delCalIDs)
+		delSharesQuery := fmt.Sprintf(`
 			DELETE FROM calendar.plan_shares
-			WHERE planid = $1 AND calendarid = ANY($2)
-		`
-		_, err = r.tx.Exec(delSharesQuery, plan.ID, pq.Array(delCalIDs))
+			WHERE planid = $1 AND calendarid IN (%s)
+		`, list)
+		_, err = q.ExecContext(ctx, delSharesQuery, append([]interface{}{plan.ID}, args...)...)
 		if err != nil {
 			return err
 		}
@@ -302,63 +404,26 @@ func (r *planRepo) update(ctx context.Context, plan service.PlanData) error {
 	addCalIDs := strs.Sub(plan.Shares, calIDs)
 	addSharesQuery := "INSERT INTO calendar.plan_shares (planid, calendarid) VALUES ($1, $2)"
 	for _, id := range addCalIDs {
-		_, err := r.tx.Exec(addSharesQuery, plan.ID, id)
+		_, err := q.ExecContext(ctx, addSharesQuery, plan.ID, id)
 		if err != nil {
 			return err
 		}
 	}
 
-	const updateCalQuery = `
-		UPDATE calendar.plans
-		SET name = $1, memo = $2, color = $3, private = $4, isallday = $5, begintime = $6, endtime = $7
-		WHERE id = $8
-	`
-	_, err = r.tx.Exec(updateCalQuery, plan.Name, plan.Memo, plan.Color, plan.Private,
-		plan.IsAllDay, plan.Begin, plan.End, plan.ID)
-	return err
-}
-
-func (r *planRepo) transaction(f func() error) error {
-	err := r.beginTx()
-	if err != nil {
-		return err
-	}
-
-	err = f()
-	if err != nil {
-		if rerr := r.rollbackTx(); rerr != nil {
-			return rerr
-		}
-		return err
-	}
-
-	return r.commitTx()
+	return nil
 }
 
-func (r *planRepo) beginTx() error {
-	tx, err := r.db.Begin()
-	if err == nil {
-		r.tx = tx
-	}
-	return err
-}
+func (r *planRepo) CountByUserID(ctx context.Context, userID string) (int, error) {
+	const query = "SELECT COUNT(*) FROM calendar.plans WHERE userid = $1 AND deleted_at IS NULL"
 
-func (r *planRepo) commitTx() error {
-	err := r.tx.Commit()
+	var n int
+	err := r.q.QueryRowContext(ctx, query, userID).Scan(&n)
 	if err != nil {
-		if rerr := r.tx.Rollback(); rerr != nil {
-			err = cerror.NewInternalError(
-				rerr,
-				"failed to commit and rollback",
-			)
-		}
+		return 0, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to count plans",
+		)
 	}
-	r.tx = nil
-	return err
-}
-
-func (r *planRepo) rollbackTx() error {
-	err := r.tx.Rollback()
-	r.tx = nil
-	return err
+	return n, nil
 }
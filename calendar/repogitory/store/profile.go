@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/dialect"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type profileRepo struct {
+	q conn
+}
+
+func (r *profileRepo) Find(ctx context.Context, userID string) (service.ProfileData, error) {
+	const query = `
+		SELECT userid, display_name, avatar_url, time_zone, locale, week_start, COALESCE(default_calendar_id, '')
+		FROM calendar.profiles
+		WHERE userid = $1
+	`
+
+	row := r.q.QueryRowContext(ctx, query, userID)
+
+	profile := service.ProfileData{}
+	err := row.Scan(
+		&profile.UserID,
+		&profile.DisplayName,
+		&profile.AvatarURL,
+		&profile.TimeZone,
+		&profile.Locale,
+		&profile.WeekStart,
+		&profile.DefaultCalendarID,
+	)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return profile, cerror.NewNotFoundError(
+			err,
+			fmt.Sprintf("not found profile of user(%v)", userID),
+		)
+	case err != nil:
+		return profile, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return profile, nil
+}
+
+func (r *profileRepo) FindProfiles(ctx context.Context, userIDs []string) ([]service.ProfileData, error) {
+	if len(userIDs) == 0 {
+		return []service.ProfileData{}, nil
+	}
+
+	list, args := dialect.InList(1, userIDs)
+	query := fmt.Sprintf(`
+		SELECT userid, display_name, avatar_url, time_zone, locale, week_start, COALESCE(default_calendar_id, '')
+		FROM calendar.profiles
+		WHERE userid IN (%s)
+		ORDER BY userid
+	`, list)
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	profiles := []service.ProfileData{}
+	for rows.Next() {
+		var profile service.ProfileData
+		err := rows.Scan(
+			&profile.UserID,
+			&profile.DisplayName,
+			&profile.AvatarURL,
+			&profile.TimeZone,
+			&profile.Locale,
+			&profile.WeekStart,
+			&profile.DefaultCalendarID,
+		)
+		if err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return profiles, nil
+}
+
+func (r *profileRepo) Save(ctx context.Context, profile service.ProfileData) error {
+	const query = `
+		INSERT INTO calendar.profiles (userid, display_name, avatar_url, time_zone, locale, week_start, default_calendar_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		ON CONFLICT (userid) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			avatar_url = EXCLUDED.avatar_url,
+			time_zone = EXCLUDED.time_zone,
+			locale = EXCLUDED.locale,
+			week_start = EXCLUDED.week_start,
+			default_calendar_id = EXCLUDED.default_calendar_id
+	`
+
+	args := []interface{}{
+		profile.UserID,
+		profile.DisplayName,
+		profile.AvatarURL,
+		profile.TimeZone,
+		profile.Locale,
+		profile.WeekStart,
+		profile.DefaultCalendarID,
+	}
+	_, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+
+	"github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type revisionRepo struct {
+	q conn
+}
+
+func (r *revisionRepo) Create(ctx context.Context, rev service.RevisionData) error {
+	const query = `
+		INSERT INTO calendar.revisions (id, operationid, target, targetid, calendarid, userid, action, created_at, before_data, after_data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	args := []interface{}{
+		rev.ID,
+		rev.OperationID,
+		rev.Target,
+		rev.TargetID,
+		rev.CalendarID,
+		rev.UserID,
+		rev.Action,
+		rev.CreatedAt,
+		rev.Before,
+		rev.After,
+	}
+	_, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to create revision",
+		)
+	}
+	return nil
+}
+
+func (r *revisionRepo) FindByTargetID(ctx context.Context, targetID string) ([]service.RevisionData, error) {
+	const query = `
+		SELECT id, operationid, target, targetid, calendarid, userid, action, created_at, before_data, after_data
+		FROM calendar.revisions
+		WHERE targetid = $1
+		ORDER BY seq
+	`
+
+	return r.query(ctx, query, targetID)
+}
+
+func (r *revisionRepo) FindByCalendarID(ctx context.Context, calID string) ([]service.RevisionData, error) {
+	const query = `
+		SELECT id, operationid, target, targetid, calendarid, userid, action, created_at, before_data, after_data
+		FROM calendar.revisions
+		WHERE calendarid = $1
+		ORDER BY seq
+	`
+
+	return r.query(ctx, query, calID)
+}
+
+func (r *revisionRepo) FindByOperationID(ctx context.Context, opID string) ([]service.RevisionData, error) {
+	const query = `
+		SELECT id, operationid, target, targetid, calendarid, userid, action, created_at, before_data, after_data
+		FROM calendar.revisions
+		WHERE operationid = $1
+		ORDER BY seq
+	`
+
+	return r.query(ctx, query, opID)
+}
+
+func (r *revisionRepo) query(ctx context.Context, query string, args ...interface{}) ([]service.RevisionData, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	revisions := []service.RevisionData{}
+	for rows.Next() {
+		var rev service.RevisionData
+		err := rows.Scan(&rev.ID, &rev.OperationID, &rev.Target, &rev.TargetID, &rev.CalendarID, &rev.UserID,
+			&rev.Action, &rev.CreatedAt, &rev.Before, &rev.After)
+		if err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+	return revisions, nil
+}
@@ -1,91 +1,170 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/dialect"
 	cerror "github.com/x-color/calendar/model/error"
 )
 
+// querier runs queries on the database or in a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn runs queries on the database, or in the transaction if it is in one.
+// Queries are written for PostgreSQL and rewritten for the dialect of the database.
+type conn struct {
+	db *sql.DB
+	tx *sql.Tx
+	d  dialect.Dialect
+}
+
+func (c conn) querier() querier {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.querier().ExecContext(ctx, c.d.Rebind(query), args...)
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.querier().QueryContext(ctx, c.d.Rebind(query), args...)
+}
+
+func (c conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.querier().QueryRowContext(ctx, c.d.Rebind(query), args...)
+}
+
+// isDuplication tells whether err is caused by a primary key or an unique constraint.
+func (c conn) isDuplication(err error) bool {
+	return c.d.IsDuplication(err)
+}
+
+// transaction runs f in the transaction of c. A new transaction is used if c is not in one.
+func (c conn) transaction(ctx context.Context, f func(q querier) error) error {
+	if c.tx != nil {
+		return f(c)
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := f(conn{db: c.db, tx: tx, d: c.d}); err != nil {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			return rerr
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			return cerror.NewInternalError(
+				rerr,
+				"failed to commit and rollback",
+			)
+		}
+		return err
+	}
+	return nil
+}
+
+// store makes repogitories bound to the database, or to the transaction if it is in one.
+// Repogitories are made for each call, so that the store is safe for concurrent use.
 type store struct {
-	db           *sql.DB
-	tx           *sql.Tx
-	calendarRepo calendarRepo
-	planRepo     planRepo
-	userRepo     userRepo
+	conn
 }
 
 func (m *store) Calendar() service.CalendarRepogitory {
-	m.calendarRepo.tx = m.tx
-	return &m.calendarRepo
+	return &calendarRepo{q: m.conn}
 }
 
 func (m *store) Plan() service.PlanRepogitory {
-	m.planRepo.tx = m.tx
-	return &m.planRepo
+	return &planRepo{q: m.conn}
 }
 
 func (m *store) User() service.UserRepogitory {
-	m.userRepo.tx = m.tx
-	return &m.userRepo
+	return &userRepo{q: m.conn}
 }
 
-func (m *store) BeginTX() error {
-	tx, err := m.db.Begin()
-	if err != nil {
-		return cerror.NewInternalError(
-			err,
-			"failed to begin transaction",
-		)
-	}
-	m.tx = tx
-	return nil
+func (m *store) Profile() service.ProfileRepogitory {
+	return &profileRepo{q: m.conn}
+}
+
+func (m *store) Org() service.OrgRepogitory {
+	return &orgRepo{q: m.conn}
+}
+
+func (m *store) Member() service.MemberRepogitory {
+	return &memberRepo{q: m.conn}
+}
+
+func (m *store) Group() service.GroupRepogitory {
+	return &groupRepo{q: m.conn}
+}
+
+func (m *store) Transfer() service.TransferRepogitory {
+	return &transferRepo{q: m.conn}
+}
+
+func (m *store) Revision() service.RevisionRepogitory {
+	return &revisionRepo{q: m.conn}
 }
 
-func (m *store) Commit() error {
-	if m.tx == nil {
-		return nil
+// Transaction runs f with the repogitory in a database transaction.
+// It is committed if f succeeds and rolled back if not. Transactions in f join the running one.
+func (m *store) Transaction(ctx context.Context, f func(service.Repogitory) error) error {
+	if m.tx != nil {
+		return f(m)
 	}
-	err := m.tx.Commit()
+
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
-		err = m.Rollback()
-		return cerror.NewInternalError(
+		return cerror.NewQueryError(
+			ctx,
 			err,
-			"failed to commit transaction",
+			"failed to begin transaction",
 		)
 	}
-	m.tx = nil
-	return nil
-}
 
-func (m *store) Rollback() error {
-	if m.tx == nil {
-		return nil
+	t := store{conn{db: m.db, tx: tx, d: m.d}}
+	// Transactions cancelled with ctx are already rolled back.
+	if err := f(&t); err != nil {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			return cerror.NewInternalError(
+				rerr,
+				"failed to rollback transaction",
+			)
+		}
+		return err
 	}
-	err := m.tx.Rollback()
-	m.tx = nil
-	if err != nil {
-		cerror.NewInternalError(
+
+	if err := tx.Commit(); err != nil {
+		return cerror.NewQueryError(
+			ctx,
 			err,
-			"failed to rollback transaction",
+			"failed to commit transaction",
 		)
 	}
 	return nil
 }
 
+// NewRepogitory returns the repogitory in PostgreSQL.
 func NewRepogitory(db *sql.DB) store {
-	c := calendarRepo{
-		db: db,
-	}
-	p := planRepo{
-		db: db,
-	}
-	u := userRepo{
-		db: db,
-	}
-	return store{
-		calendarRepo: c,
-		planRepo:     p,
-		userRepo:     u,
-	}
+	return store{conn{db: db, d: dialect.PostgreSQL}}
+}
+
+// NewSQLiteRepogitory returns the repogitory in SQLite. It runs the same queries as PostgreSQL.
+func NewSQLiteRepogitory(db *sql.DB) store {
+	return store{conn{db: db, d: dialect.SQLite}}
 }
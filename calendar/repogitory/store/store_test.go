@@ -0,0 +1,88 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/x-color/calendar/calendar/repogitory/repotest"
+	"github.com/x-color/calendar/calendar/repogitory/store"
+	"github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/migration"
+)
+
+func TestRepogitory(t *testing.T) {
+	if os.Getenv("TEST_DB") == "sqlite" {
+		t.Skip("tests run on SQLite")
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=testuser password=password dbname=calendar sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+
+	newRepo := func(t *testing.T) service.Repogitory {
+		// Data of calendars are deleted by cascade.
+		if _, err := db.Exec("DELETE FROM auth.users"); err != nil {
+			t.Fatal(err)
+		}
+		r := store.NewRepogitory(db)
+		return &r
+	}
+	// Calendar users refer to users signed up in the authentication.
+	addUser := func(t *testing.T, id string) {
+		if _, err := db.Exec("INSERT INTO auth.users (id, name, password) VALUES ($1, $1, '')", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repotest.Run(t, newRepo, addUser)
+}
+
+func TestSQLiteRepogitory(t *testing.T) {
+	var db *sql.DB
+	newRepo := func(t *testing.T) service.Repogitory {
+		db = openSQLite(t)
+		r := store.NewSQLiteRepogitory(db)
+		return &r
+	}
+	addUser := func(t *testing.T, id string) {
+		if _, err := db.Exec("INSERT INTO auth_users (id, name, password) VALUES (?1, ?1, '')", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repotest.Run(t, newRepo, addUser)
+}
+
+// openSQLite opens a migrated database in a temporary directory with the same options as the server.
+func openSQLite(t *testing.T) *sql.DB {
+	dir, err := ioutil.TempDir("", "calendar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(dir, "calendar.db")+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.RemoveAll(dir)
+	})
+
+	m, err := migration.NewMigrator(db, "sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/x-color/calendar/calendar/service"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+type transferRepo struct {
+	q conn
+}
+
+func (r *transferRepo) Save(ctx context.Context, transfer service.TransferData) error {
+	const query = `
+		INSERT INTO calendar.calendar_transfers (calendarid, from_userid, to_userid, transfer_plans)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (calendarid) DO UPDATE SET
+			from_userid = EXCLUDED.from_userid,
+			to_userid = EXCLUDED.to_userid,
+			transfer_plans = EXCLUDED.transfer_plans
+	`
+
+	args := []interface{}{
+		transfer.CalendarID,
+		transfer.FromUserID,
+		transfer.ToUserID,
+		transfer.TransferPlans,
+	}
+	_, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	return nil
+}
+
+func (r *transferRepo) Delete(ctx context.Context, calID string) error {
+	const query = "DELETE FROM calendar.calendar_transfers WHERE calendarid = $1"
+
+	res, err := r.q.ExecContext(ctx, query, calID)
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to get affected rows",
+		)
+	}
+	if n == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found transfer of calendar(%v)", calID),
+		)
+	}
+	return nil
+}
+
+func (r *transferRepo) Find(ctx context.Context, calID string) (service.TransferData, error) {
+	const query = `
+		SELECT calendarid, from_userid, to_userid, transfer_plans
+		FROM calendar.calendar_transfers
+		WHERE calendarid = $1
+	`
+
+	row := r.q.QueryRowContext(ctx, query, calID)
+
+	transfer := service.TransferData{}
+	err := row.Scan(&transfer.CalendarID, &transfer.FromUserID, &transfer.ToUserID, &transfer.TransferPlans)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return transfer, cerror.NewNotFoundError(
+			err,
+			fmt.Sprintf("not found transfer of calendar(%v)", calID),
+		)
+	case err != nil:
+		return transfer, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return transfer, nil
+}
+
+func (r *transferRepo) FindByUserID(ctx context.Context, userID string) ([]service.TransferData, error) {
+	const query = `
+		SELECT calendarid, from_userid, to_userid, transfer_plans
+		FROM calendar.calendar_transfers
+		WHERE to_userid = $1
+		ORDER BY calendarid
+	`
+
+	rows, err := r.q.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	transfers := []service.TransferData{}
+	for rows.Next() {
+		var t service.TransferData
+		if err := rows.Scan(&t.CalendarID, &t.FromUserID, &t.ToUserID, &t.TransferPlans); err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+		transfers = append(transfers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return transfers, nil
+}
@@ -7,24 +7,19 @@ import (
 	"fmt"
 
 	"github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/dialect"
 	cerror "github.com/x-color/calendar/model/error"
 )
 
 type userRepo struct {
-	db *sql.DB
-	tx *sql.Tx
+	q conn
 }
 
 func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error) {
 	const query = "SELECT id FROM calendar.users WHERE id = $1"
 
 	user := service.UserData{}
-	var err error
-	if r.tx != nil {
-		err = r.tx.QueryRow(query, id).Scan(&user.ID)
-	} else {
-		err = r.db.QueryRow(query, id).Scan(&user.ID)
-	}
+	err := r.q.QueryRowContext(ctx, query, id).Scan(&user.ID)
 
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
@@ -33,7 +28,8 @@ func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error
 			fmt.Sprintf("not found a user(%v)", id),
 		)
 	case err != nil:
-		return user, cerror.NewInternalError(
+		return user, cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to scan query result",
 		)
@@ -42,17 +38,65 @@ func (r *userRepo) Find(ctx context.Context, id string) (service.UserData, error
 	return user, nil
 }
 
+func (r *userRepo) FindUsers(ctx context.Context, ids []string) ([]service.UserData, error) {
+	if len(ids) == 0 {
+		return []service.UserData{}, nil
+	}
+
+	list, args := dialect.InList(1, ids)
+	query := fmt.Sprintf("SELECT id FROM calendar.users WHERE id IN (%s) ORDER BY id", list)
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to query",
+		)
+	}
+	defer rows.Close()
+
+	return scanUsers(ctx, rows)
+}
+
+// scanUsers returns users selected by rows.
+func scanUsers(ctx context.Context, rows *sql.Rows) ([]service.UserData, error) {
+	users := []service.UserData{}
+	for rows.Next() {
+		var user service.UserData
+		if err := rows.Scan(&user.ID); err != nil {
+			return nil, cerror.NewQueryError(
+				ctx,
+				err,
+				"failed to scan query result",
+			)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, cerror.NewQueryError(
+			ctx,
+			err,
+			"failed to scan query result",
+		)
+	}
+
+	return users, nil
+}
+
 func (r *userRepo) Create(ctx context.Context, user service.UserData) error {
 	const query = "INSERT INTO calendar.users (id) VALUES ($1)"
 
-	var err error
-	if r.tx != nil {
-		_, err = r.tx.Exec(query, user.ID)
-	} else {
-		_, err = r.db.Exec(query, user.ID)
-	}
-	if err != nil {
-		return cerror.NewInternalError(
+	_, err := r.q.ExecContext(ctx, query, user.ID)
+	if r.q.isDuplication(err) {
+		return cerror.NewDuplicationError(
+			err,
+			fmt.Sprintf("same key(%v)", user.ID),
+		)
+	} else if err != nil {
+		return cerror.NewQueryError(
+			ctx,
 			err,
 			"failed to query",
 		)
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/x-color/calendar/calendar/model"
+	cerror "github.com/x-color/calendar/model/error"
+	"github.com/x-color/slice/strs"
+)
+
+// canAccess tells whether the user can see and schedule plans in the calendar.
+// Members of groups in shares and members of the organization can use it without being in shares.
+func (s *Service) canAccess(ctx context.Context, userID string, cal CalendarData) (bool, error) {
+	access, err := s.canAccessCalendars(ctx, userID, []CalendarData{cal})
+	if err != nil {
+		return false, err
+	}
+	return access[cal.ID], nil
+}
+
+// canAccessCalendars tells whether the user can access each of the calendars keyed by the IDs.
+// Groups in shares of all calendars are found at once.
+func (s *Service) canAccessCalendars(ctx context.Context, userID string, cals []CalendarData) (map[string]bool, error) {
+	access := map[string]bool{}
+	groupIDs := []string{}
+	for _, cal := range cals {
+		if strs.Contains(cal.Shares, userID) {
+			access[cal.ID] = true
+		} else {
+			groupIDs = append(groupIDs, cal.GroupShares...)
+		}
+	}
+
+	// joined has groups the user is a member of.
+	joined := map[string]bool{}
+	if len(groupIDs) != 0 {
+		groups, err := s.repo.Group().FindGroups(ctx, groupIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			if strs.Contains(g.Members, userID) {
+				joined[g.ID] = true
+			}
+		}
+	}
+
+	// orgs has whether the user is a member of organizations checked already.
+	orgs := map[string]bool{}
+	for _, cal := range cals {
+		if access[cal.ID] {
+			continue
+		}
+		for _, id := range cal.GroupShares {
+			if joined[id] {
+				access[cal.ID] = true
+				break
+			}
+		}
+		if access[cal.ID] || cal.OrgID == "" {
+			continue
+		}
+
+		member, ok := orgs[cal.OrgID]
+		if !ok {
+			_, err := s.repo.Member().Find(ctx, cal.OrgID, userID)
+			if err != nil && !errors.Is(err, cerror.ErrNotFound) {
+				return nil, err
+			}
+			member = err == nil
+			orgs[cal.OrgID] = member
+		}
+		access[cal.ID] = member
+	}
+	return access, nil
+}
+
+// canManage tells whether the user can change and remove the calendar.
+// Calendars of organizations are managed by its owners and admins.
+func (s *Service) canManage(ctx context.Context, userID string, cal CalendarData) (bool, error) {
+	if cal.OrgID == "" {
+		return cal.UserID == userID, nil
+	}
+
+	m, err := s.repo.Member().Find(ctx, cal.OrgID, userID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return model.Role(m.Role).CanManage(), nil
+}
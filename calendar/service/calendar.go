@@ -38,20 +38,93 @@ func (s *Service) getCalendars(ctx context.Context, userID string) ([]model.Cale
 		return nil, err
 	}
 
+	found := map[string]bool{}
+	for _, cal := range cl {
+		found[cal.ID] = true
+	}
+	addCalendars := func(l []CalendarData) {
+		for _, cal := range l {
+			if !found[cal.ID] {
+				found[cal.ID] = true
+				cl = append(cl, cal)
+			}
+		}
+	}
+
+	// Calendars shared with groups are shown to their current members.
+	groups, err := s.repo.Group().FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs := make([]string, len(groups))
+	for i, group := range groups {
+		groupIDs[i] = group.ID
+	}
+	groupCals, err := s.repo.Calendar().FindByGroupIDs(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	addCalendars(groupCals)
+
+	// Calendars of organizations are shown to all members even if they are not in shares.
+	orgs, err := s.repo.Org().FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	orgIDs := make([]string, len(orgs))
+	for i, org := range orgs {
+		orgIDs[i] = org.ID
+	}
+	orgCals, err := s.repo.Calendar().FindByOrgIDs(ctx, orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	addCalendars(orgCals)
+
+	ids := make([]string, len(cl))
+	for i, cal := range cl {
+		ids[i] = cal.ID
+	}
+	plansByCal, err := s.repo.Plan().FindByCalendarIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plans published from calendars in the trash are hidden until the calendars are restored.
+	alive := map[string]bool{}
+	for id := range found {
+		alive[id] = true
+	}
+	others := []string{}
+	for _, pl := range plansByCal {
+		for _, p := range pl {
+			if _, ok := alive[p.CalendarID]; !ok {
+				alive[p.CalendarID] = false
+				others = append(others, p.CalendarID)
+			}
+		}
+	}
+	ol, err := s.repo.Calendar().FindCalendars(ctx, others)
+	if err != nil {
+		return nil, err
+	}
+	for _, cal := range ol {
+		alive[cal.ID] = true
+	}
+
 	cals := make([]model.Calendar, len(cl))
 	for i, cal := range cl {
 		cals[i] = cal.model()
-		pl, err := s.repo.Plan().FindByCalendarID(ctx, cal.ID)
-		if err != nil {
-			return nil, err
-		}
-		plans := make([]model.Plan, len(pl))
-		for j, p := range pl {
+		plans := []model.Plan{}
+		for _, p := range plansByCal[cal.ID] {
+			if !alive[p.CalendarID] {
+				continue
+			}
 			plan := p.model()
 			if plan.Private && plan.UserID != userID {
 				plan, _ = maskPlan(plan, cal.ID)
 			}
-			plans[j] = plan
+			plans = append(plans, plan)
 		}
 		cals[i].Plans = plans
 	}
@@ -63,7 +136,12 @@ func (s *Service) MakeCalendar(ctx context.Context, userID, name, color string)
 	reqID := ctx.Value(cctx.ReqIDKey).(string)
 	s.log = s.log.Uniq(reqID)
 
-	cal, err := s.makeCalendar(ctx, userID, name, color)
+	var cal model.Calendar
+	err := s.transaction(ctx, func(s *Service) error {
+		var err error
+		cal, err = s.makeCalendar(ctx, userID, "", name, color)
+		return err
+	})
 
 	if err != nil {
 		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
@@ -79,7 +157,33 @@ func (s *Service) MakeCalendar(ctx context.Context, userID, name, color string)
 	return cal, err
 }
 
-func (s *Service) makeCalendar(ctx context.Context, userID, name, color string) (model.Calendar, error) {
+// MakeOrgCalendar makes the calendar owned by the organization. Only owners and admins can make it.
+func (s *Service) MakeOrgCalendar(ctx context.Context, userID, orgID, name, color string) (model.Calendar, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	var cal model.Calendar
+	err := s.transaction(ctx, func(s *Service) error {
+		var err error
+		cal, err = s.makeCalendar(ctx, userID, orgID, name, color)
+		return err
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to make calendar: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Make calendar(%v) in organization(%v)", cal.ID, orgID))
+	}
+
+	return cal, err
+}
+
+func (s *Service) makeCalendar(ctx context.Context, userID, orgID, name, color string) (model.Calendar, error) {
 	if name == "" {
 		return model.Calendar{}, cerror.NewInvalidContentError(
 			nil,
@@ -92,11 +196,27 @@ func (s *Service) makeCalendar(ctx context.Context, userID, name, color string)
 	}
 
 	cal := model.NewCalendar(userID, name, c)
+	if orgID != "" {
+		m, err := s.repo.Member().Find(ctx, orgID, userID)
+		if errors.Is(err, cerror.ErrNotFound) || (err == nil && !model.Role(m.Role).CanManage()) {
+			return model.Calendar{}, cerror.NewAuthorizationError(
+				nil,
+				fmt.Sprintf("user(%v) does not permit to make calendar in organization(%v)", userID, orgID),
+			)
+		} else if err != nil {
+			return model.Calendar{}, err
+		}
+		cal = model.NewOrgCalendar(orgID, userID, name, c)
+	}
 
-	err = s.repo.Calendar().Create(ctx, newCalendarData(cal))
+	data := newCalendarData(cal)
+	err = s.repo.Calendar().Create(ctx, data)
 	if err != nil {
 		return model.Calendar{}, err
 	}
+	if err := s.recordCalendar(ctx, userID, model.CREATE, nil, &data); err != nil {
+		return model.Calendar{}, err
+	}
 	return cal, nil
 }
 
@@ -104,7 +224,9 @@ func (s *Service) RemoveCalendar(ctx context.Context, userID, id string) error {
 	reqID := ctx.Value(cctx.ReqIDKey).(string)
 	s.log = s.log.Uniq(reqID)
 
-	err := s.removeCalendar(ctx, userID, id)
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.removeCalendar(ctx, userID, id)
+	})
 
 	if err != nil {
 		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
@@ -133,16 +255,24 @@ func (s *Service) removeCalendar(ctx context.Context, userID, id string) error {
 		return err
 	}
 
+	manage, err := s.canManage(ctx, userID, cal)
+	if err != nil {
+		return err
+	}
+
 	// User is not owner of the model.
-	if userID != cal.UserID {
+	if !manage {
 		return s.unshareCalendar(ctx, userID, cal.model())
 	}
 
-	return s.repo.Calendar().Delete(ctx, id)
-
+	if err := s.repo.Calendar().Trash(ctx, id, s.clock.Now().Unix()); err != nil {
+		return err
+	}
+	return s.recordCalendar(ctx, userID, model.DELETE, &cal, nil)
 }
 
 func (s *Service) unshareCalendar(ctx context.Context, userID string, cal model.Calendar) error {
+	before := newCalendarData(cal)
 	l, err := strs.RemoveE(cal.Shares, userID)
 	if err != nil {
 		return cerror.NewAuthorizationError(
@@ -152,14 +282,20 @@ func (s *Service) unshareCalendar(ctx context.Context, userID string, cal model.
 	}
 	cal.Shares = l
 
-	return s.repo.Calendar().Update(ctx, newCalendarData(cal))
+	after := newCalendarData(cal)
+	if err := s.repo.Calendar().Update(ctx, after); err != nil {
+		return err
+	}
+	return s.recordCalendar(ctx, userID, model.UPDATE, &before, &after)
 }
 
 func (s *Service) ChangeCalendar(ctx context.Context, userID string, calPram model.Calendar) error {
 	reqID := ctx.Value(cctx.ReqIDKey).(string)
 	s.log = s.log.Uniq(reqID)
 
-	err := s.changeCalendar(ctx, userID, calPram)
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.changeCalendar(ctx, userID, calPram)
+	})
 
 	if err != nil {
 		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
@@ -190,15 +326,16 @@ func (s *Service) changeCalendar(ctx context.Context, userID string, calPram mod
 		)
 	}
 
-	if !strs.Contains(calPram.Shares, userID) {
-		return cerror.NewInvalidContentError(
-			nil,
-			"owner is not in shares",
-		)
+	users, err := s.repo.User().FindUsers(ctx, calPram.Shares)
+	if err != nil {
+		return err
+	}
+	exists := map[string]bool{}
+	for _, u := range users {
+		exists[u.ID] = true
 	}
-
 	for _, uid := range calPram.Shares {
-		if _, err := s.repo.User().Find(ctx, uid); err != nil {
+		if !exists[uid] {
 			return cerror.NewInvalidContentError(
 				nil,
 				"invalid user in shares",
@@ -211,16 +348,62 @@ func (s *Service) changeCalendar(ctx context.Context, userID string, calPram mod
 		return err
 	}
 
-	if userID != c.UserID {
+	// Calendars of organizations are used by members without shares.
+	if c.OrgID == "" && !strs.Contains(calPram.Shares, c.UserID) {
+		return cerror.NewInvalidContentError(
+			nil,
+			"owner is not in shares",
+		)
+	}
+
+	manage, err := s.canManage(ctx, userID, c)
+	if err != nil {
+		return err
+	}
+	if !manage {
 		return cerror.NewAuthorizationError(
 			nil,
 			fmt.Sprintf("user(%v) does not permit to change calendar(%v)", userID, calPram.ID),
 		)
 	}
 
+	// Group shares are kept if they are not given.
+	if calPram.GroupShares == nil {
+		calPram.GroupShares = c.GroupShares
+	}
+	for _, gid := range strs.Sub(calPram.GroupShares, c.GroupShares) {
+		group, err := s.repo.Group().Find(ctx, gid)
+		if errors.Is(err, cerror.ErrNotFound) || (err == nil && !strs.Contains(group.Members, userID)) {
+			return cerror.NewInvalidContentError(
+				nil,
+				fmt.Sprintf("invalid group(%v) in shares", gid),
+			)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	for _, uid := range strs.Sub(calPram.Shares, c.Shares) {
+		verified, err := s.isVerified(ctx, uid)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return cerror.NewInvalidContentError(
+				nil,
+				fmt.Sprintf("user(%v) in shares is not verified", uid),
+			)
+		}
+	}
+
 	calPram.UserID = c.UserID
+	calPram.OrgID = c.OrgID
 
-	return s.repo.Calendar().Update(ctx, newCalendarData(calPram))
+	after := newCalendarData(calPram)
+	if err := s.repo.Calendar().Update(ctx, after); err != nil {
+		return err
+	}
+	return s.recordCalendar(ctx, userID, model.UPDATE, &c, &after)
 }
 
 func maskPlan(plan model.Plan, calID string) (model.Plan, error) {
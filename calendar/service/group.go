@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+	"github.com/x-color/slice/strs"
+)
+
+func (s *Service) MakeGroup(ctx context.Context, userID, name string) (model.Group, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	var group model.Group
+	err := s.transaction(ctx, func(s *Service) error {
+		var err error
+		group, err = s.makeGroup(ctx, userID, name)
+		return err
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to make group: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Make group(%v)", group.ID))
+	}
+
+	return group, err
+}
+
+func (s *Service) makeGroup(ctx context.Context, userID, name string) (model.Group, error) {
+	if name == "" || utf8.RuneCountInString(name) > 64 {
+		return model.Group{}, cerror.NewInvalidContentError(
+			nil,
+			"invalid name",
+		)
+	}
+
+	group := model.NewGroup(userID, name)
+	if err := s.repo.Group().Create(ctx, newGroupData(group)); err != nil {
+		return model.Group{}, err
+	}
+	if err := s.repo.Group().AddMember(ctx, group.ID, userID); err != nil {
+		return model.Group{}, err
+	}
+
+	return group, nil
+}
+
+func (s *Service) GetGroups(ctx context.Context, userID string) ([]model.Group, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	groups, err := s.getGroups(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get groups: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get groups for user(%v)", userID))
+	}
+
+	return groups, err
+}
+
+func (s *Service) getGroups(ctx context.Context, userID string) ([]model.Group, error) {
+	gl, err := s.repo.Group().FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]model.Group, len(gl))
+	for i, g := range gl {
+		groups[i] = g.model()
+	}
+	return groups, nil
+}
+
+func (s *Service) RemoveGroup(ctx context.Context, userID, groupID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.removeGroup(ctx, userID, groupID)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to remove group: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Remove group(%v)", groupID))
+	}
+
+	return err
+}
+
+func (s *Service) removeGroup(ctx context.Context, userID, groupID string) error {
+	if _, err := s.findOwnGroup(ctx, userID, groupID); err != nil {
+		return err
+	}
+	return s.repo.Group().Delete(ctx, groupID)
+}
+
+// AddGroupMember adds the user to the group. Only the owner of the group can add members.
+func (s *Service) AddGroupMember(ctx context.Context, userID, groupID, memberID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.addGroupMember(ctx, userID, groupID, memberID)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to add group member: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Add user(%v) to group(%v)", memberID, groupID))
+	}
+
+	return err
+}
+
+func (s *Service) addGroupMember(ctx context.Context, userID, groupID, memberID string) error {
+	group, err := s.findOwnGroup(ctx, userID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if strs.Contains(group.Members, memberID) {
+		return nil
+	}
+
+	if _, err := s.repo.User().Find(ctx, memberID); err != nil {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("invalid user(%v)", memberID),
+		)
+	}
+
+	verified, err := s.isVerified(ctx, memberID)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("user(%v) is not verified", memberID),
+		)
+	}
+
+	return s.repo.Group().AddMember(ctx, groupID, memberID)
+}
+
+// RemoveGroupMember removes the member from the group. Members can leave by themselves.
+func (s *Service) RemoveGroupMember(ctx context.Context, userID, groupID, memberID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.removeGroupMember(ctx, userID, groupID, memberID)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to remove group member: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Remove user(%v) from group(%v)", memberID, groupID))
+	}
+
+	return err
+}
+
+func (s *Service) removeGroupMember(ctx context.Context, userID, groupID, memberID string) error {
+	group, err := s.repo.Group().Find(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	if userID != memberID && userID != group.UserID {
+		return cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to remove member of group(%v)", userID, groupID),
+		)
+	}
+
+	if memberID == group.UserID {
+		return cerror.NewInvalidContentError(
+			nil,
+			"owner can not leave the group",
+		)
+	}
+
+	return s.repo.Group().RemoveMember(ctx, groupID, memberID)
+}
+
+// findOwnGroup returns the group. It is an authorization error if the user is not the owner.
+func (s *Service) findOwnGroup(ctx context.Context, userID, groupID string) (GroupData, error) {
+	group, err := s.repo.Group().Find(ctx, groupID)
+	if err != nil {
+		return GroupData{}, err
+	}
+	if group.UserID != userID {
+		return GroupData{}, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to manage group(%v)", userID, groupID),
+		)
+	}
+	return group, nil
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+func (s *Service) MakeOrg(ctx context.Context, userID, name string) (model.Org, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	var org model.Org
+	err := s.transaction(ctx, func(s *Service) error {
+		var err error
+		org, err = s.makeOrg(ctx, userID, name)
+		return err
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to make organization: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Make organization(%v)", org.ID))
+	}
+
+	return org, err
+}
+
+func (s *Service) makeOrg(ctx context.Context, userID, name string) (model.Org, error) {
+	if name == "" || utf8.RuneCountInString(name) > 64 {
+		return model.Org{}, cerror.NewInvalidContentError(
+			nil,
+			"invalid name",
+		)
+	}
+
+	org := model.NewOrg(name)
+	if err := s.repo.Org().Create(ctx, newOrgData(org)); err != nil {
+		return model.Org{}, err
+	}
+
+	owner := model.Member{OrgID: org.ID, UserID: userID, Role: model.OWNER}
+	if err := s.repo.Member().Save(ctx, newMemberData(owner)); err != nil {
+		return model.Org{}, err
+	}
+
+	return org, nil
+}
+
+func (s *Service) GetOrgs(ctx context.Context, userID string) ([]model.Org, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	orgs, err := s.getOrgs(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get organizations: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get organizations for user(%v)", userID))
+	}
+
+	return orgs, err
+}
+
+func (s *Service) getOrgs(ctx context.Context, userID string) ([]model.Org, error) {
+	ol, err := s.repo.Org().FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := make([]model.Org, len(ol))
+	for i, o := range ol {
+		orgs[i] = o.model()
+	}
+	return orgs, nil
+}
+
+func (s *Service) RemoveOrg(ctx context.Context, userID, orgID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.removeOrg(ctx, userID, orgID)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to remove organization: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Remove organization(%v)", orgID))
+	}
+
+	return err
+}
+
+func (s *Service) removeOrg(ctx context.Context, userID, orgID string) error {
+	m, err := s.findMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if m.Role != string(model.OWNER) {
+		return cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to remove organization(%v)", userID, orgID),
+		)
+	}
+
+	cals, err := s.repo.Calendar().FindByOrgID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, cal := range cals {
+		if err := s.repo.Calendar().Delete(ctx, cal.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Org().Delete(ctx, orgID)
+}
+
+// GetMembers returns the member directory of the organization. Only members can see it.
+func (s *Service) GetMembers(ctx context.Context, userID, orgID string) ([]model.Member, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	members, err := s.getMembers(ctx, userID, orgID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get members: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get members of organization(%v)", orgID))
+	}
+
+	return members, err
+}
+
+func (s *Service) getMembers(ctx context.Context, userID, orgID string) ([]model.Member, error) {
+	if _, err := s.findMember(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+
+	ml, err := s.repo.Member().FindByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]model.Member, len(ml))
+	for i, m := range ml {
+		members[i] = m.model()
+	}
+	return members, nil
+}
+
+// SetMember adds the user to the organization or changes the role of the member.
+// Only owners can make or change other owners.
+func (s *Service) SetMember(ctx context.Context, userID string, memberPram model.Member) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.setMember(ctx, userID, memberPram)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to set member: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Set user(%v) as %v of organization(%v)", memberPram.UserID, memberPram.Role, memberPram.OrgID))
+	}
+
+	return err
+}
+
+func (s *Service) setMember(ctx context.Context, userID string, memberPram model.Member) error {
+	if _, err := model.ConvertToRole(string(memberPram.Role)); err != nil {
+		return err
+	}
+
+	m, err := s.findMember(ctx, memberPram.OrgID, userID)
+	if err != nil {
+		return err
+	}
+	if !model.Role(m.Role).CanManage() {
+		return cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to manage organization(%v)", userID, memberPram.OrgID),
+		)
+	}
+
+	if _, err := s.repo.User().Find(ctx, memberPram.UserID); err != nil {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("invalid user(%v)", memberPram.UserID),
+		)
+	}
+
+	old, err := s.repo.Member().Find(ctx, memberPram.OrgID, memberPram.UserID)
+	switch {
+	case errors.Is(err, cerror.ErrNotFound):
+		verified, err := s.isVerified(ctx, memberPram.UserID)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return cerror.NewInvalidContentError(
+				nil,
+				fmt.Sprintf("user(%v) is not verified", memberPram.UserID),
+			)
+		}
+	case err != nil:
+		return err
+	}
+
+	if (memberPram.Role == model.OWNER || old.Role == string(model.OWNER)) && m.Role != string(model.OWNER) {
+		return cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to change owners of organization(%v)", userID, memberPram.OrgID),
+		)
+	}
+
+	if old.Role == string(model.OWNER) && memberPram.Role != model.OWNER {
+		if err := s.checkOtherOwner(ctx, memberPram.OrgID, memberPram.UserID); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Member().Save(ctx, newMemberData(memberPram))
+}
+
+// RemoveMember removes the member from the organization. Members can leave by themselves.
+func (s *Service) RemoveMember(ctx context.Context, userID, orgID, memberID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.removeMember(ctx, userID, orgID, memberID)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to remove member: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Remove user(%v) from organization(%v)", memberID, orgID))
+	}
+
+	return err
+}
+
+func (s *Service) removeMember(ctx context.Context, userID, orgID, memberID string) error {
+	m, err := s.findMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.repo.Member().Find(ctx, orgID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if userID != memberID {
+		if !model.Role(m.Role).CanManage() || (target.Role == string(model.OWNER) && m.Role != string(model.OWNER)) {
+			return cerror.NewAuthorizationError(
+				nil,
+				fmt.Sprintf("user(%v) does not permit to remove member(%v)", userID, memberID),
+			)
+		}
+	}
+
+	if target.Role == string(model.OWNER) {
+		if err := s.checkOtherOwner(ctx, orgID, memberID); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Member().Delete(ctx, orgID, memberID)
+}
+
+// findMember returns the membership of the user. It is an authorization error if the user is not a member.
+func (s *Service) findMember(ctx context.Context, orgID, userID string) (MemberData, error) {
+	m, err := s.repo.Member().Find(ctx, orgID, userID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return MemberData{}, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) is not a member of organization(%v)", userID, orgID),
+		)
+	}
+	return m, err
+}
+
+// checkOtherOwner returns an error unless the organization has an owner other than the user.
+// An organization must always have at least one owner.
+func (s *Service) checkOtherOwner(ctx context.Context, orgID, userID string) error {
+	ml, err := s.repo.Member().FindByOrgID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, m := range ml {
+		if m.UserID != userID && m.Role == string(model.OWNER) {
+			return nil
+		}
+	}
+	return cerror.NewInvalidContentError(
+		nil,
+		fmt.Sprintf("organization(%v) must have another owner", orgID),
+	)
+}
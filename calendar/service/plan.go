@@ -18,7 +18,12 @@ func (s *Service) Schedule(ctx context.Context, userID string, planPram model.Pl
 
 	planPram.UserID = userID
 
-	plan, err := s.schedule(ctx, planPram)
+	var plan model.Plan
+	err := s.transaction(ctx, func(s *Service) error {
+		var err error
+		plan, err = s.schedule(ctx, planPram)
+		return err
+	})
 	if err != nil {
 		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
 		if errors.Is(err, cerror.ErrInternal) {
@@ -58,21 +63,19 @@ func (s *Service) schedule(ctx context.Context, planPram model.Plan) (model.Plan
 		return model.Plan{}, err
 	}
 
-	if !strs.Contains(cal.Shares, planPram.UserID) {
+	access, err := s.canAccess(ctx, planPram.UserID, cal)
+	if err != nil {
+		return model.Plan{}, err
+	}
+	if !access {
 		return model.Plan{}, cerror.NewAuthorizationError(
 			nil,
 			fmt.Sprintf("user(%v) does not permit to access the calendar(%v)", planPram.UserID, planPram.CalendarID),
 		)
 	}
 
-	for _, id := range planPram.Shares {
-		cal, err := s.repo.Calendar().Find(ctx, id)
-		if err != nil || !strs.Contains(cal.model().Shares, planPram.UserID) {
-			return model.Plan{}, cerror.NewInvalidContentError(
-				nil,
-				"invalid calendar id in shares",
-			)
-		}
+	if err := s.checkPlanShares(ctx, planPram.UserID, planPram.Shares); err != nil {
+		return model.Plan{}, err
 	}
 
 	plan := model.NewPlan(
@@ -86,11 +89,16 @@ func (s *Service) schedule(ctx context.Context, planPram model.Plan) (model.Plan
 		planPram.Period,
 	)
 
-	err = s.repo.Plan().Create(ctx, newPlanData(plan))
+	data := newPlanData(plan)
+	err = s.repo.Plan().Create(ctx, data)
 	if err != nil {
 		return model.Plan{}, err
 	}
 
+	if err := s.recordPlan(ctx, plan.UserID, model.CREATE, nil, &data); err != nil {
+		return model.Plan{}, err
+	}
+
 	return plan, nil
 }
 
@@ -98,7 +106,9 @@ func (s *Service) Unschedule(ctx context.Context, userID, calID, id string) erro
 	reqID := ctx.Value(cctx.ReqIDKey).(string)
 	s.log = s.log.Uniq(reqID)
 
-	err := s.unschedule(ctx, userID, calID, id)
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.unschedule(ctx, userID, calID, id)
+	})
 	if err != nil {
 		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
 		if errors.Is(err, cerror.ErrInternal) {
@@ -127,15 +137,19 @@ func (s *Service) unschedule(ctx context.Context, userID, calID, id string) erro
 	}
 
 	// It changes not to share plan in the calendar if calID is not parent calendar for the plan.
-	// If not, it deletes the plan in all calendars.
+	// If not, it moves the plan into the trash and the plan disappears from all calendars.
 	if plan.UserID != userID || plan.CalendarID != calID {
 		return s.unsharePlan(ctx, userID, calID, plan.model())
 	}
 
-	return s.repo.Plan().Delete(ctx, id)
+	if err := s.repo.Plan().Trash(ctx, id, s.clock.Now().Unix()); err != nil {
+		return err
+	}
+	return s.recordPlan(ctx, userID, model.DELETE, &plan, nil)
 }
 
 func (s *Service) unsharePlan(ctx context.Context, userID, calID string, plan model.Plan) error {
+	before := newPlanData(plan)
 	l, err := strs.RemoveE(plan.Shares, calID)
 	if err != nil {
 		return cerror.NewInvalidContentError(
@@ -150,14 +164,22 @@ func (s *Service) unsharePlan(ctx context.Context, userID, calID string, plan mo
 		return err
 	}
 
-	if cal.UserID != userID {
+	manage, err := s.canManage(ctx, userID, cal)
+	if err != nil {
+		return err
+	}
+	if !manage {
 		return cerror.NewAuthorizationError(
 			nil,
 			fmt.Sprintf("user(%v) does not permit to access the plan(%v)", userID, plan.ID),
 		)
 	}
 
-	return s.repo.Plan().Update(ctx, newPlanData(plan))
+	after := newPlanData(plan)
+	if err := s.repo.Plan().Update(ctx, after); err != nil {
+		return err
+	}
+	return s.recordPlan(ctx, userID, model.UPDATE, &before, &after)
 }
 
 func (s *Service) Reschedule(ctx context.Context, userID string, planPram model.Plan) (model.Plan, error) {
@@ -166,7 +188,12 @@ func (s *Service) Reschedule(ctx context.Context, userID string, planPram model.
 
 	planPram.UserID = userID
 
-	plan, err := s.reschedule(ctx, planPram)
+	var plan model.Plan
+	err := s.transaction(ctx, func(s *Service) error {
+		var err error
+		plan, err = s.reschedule(ctx, planPram)
+		return err
+	})
 	if err != nil {
 		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
 		if errors.Is(err, cerror.ErrInternal) {
@@ -234,20 +261,42 @@ func (s *Service) reschedule(ctx context.Context, planPram model.Plan) (model.Pl
 		)
 	}
 
-	for _, id := range planPram.Shares {
-		cal, err := s.repo.Calendar().Find(ctx, id)
-		if err != nil || !strs.Contains(cal.model().Shares, planPram.UserID) {
-			return model.Plan{}, cerror.NewInvalidContentError(
-				nil,
-				"invalid calendar id in shares",
-			)
-		}
+	if err := s.checkPlanShares(ctx, planPram.UserID, planPram.Shares); err != nil {
+		return model.Plan{}, err
 	}
 
-	err = s.repo.Plan().Update(ctx, newPlanData(planPram))
+	after := newPlanData(planPram)
+	err = s.repo.Plan().Update(ctx, after)
 	if err != nil {
 		return model.Plan{}, err
 	}
 
+	if err := s.recordPlan(ctx, planPram.UserID, model.UPDATE, &plan, &after); err != nil {
+		return model.Plan{}, err
+	}
+
 	return planPram, nil
 }
+
+// checkPlanShares checks the user can publish plans to all calendars in shares.
+func (s *Service) checkPlanShares(ctx context.Context, userID string, shares []string) error {
+	cl, err := s.repo.Calendar().FindCalendars(ctx, shares)
+	if err != nil {
+		return err
+	}
+	access, err := s.canAccessCalendars(ctx, userID, cl)
+	if err != nil {
+		return err
+	}
+
+	// Calendars not found are not in access.
+	for _, id := range shares {
+		if !access[id] {
+			return cerror.NewInvalidContentError(
+				nil,
+				"invalid calendar id in shares",
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// localePattern matches BCP 47 language tags such as "en" and "ja-JP".
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+func (s *Service) GetProfile(ctx context.Context, userID string) (model.Profile, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	profile, err := s.getProfile(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get profile: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get profile of user(%v)", userID))
+	}
+
+	return profile, err
+}
+
+func (s *Service) getProfile(ctx context.Context, userID string) (model.Profile, error) {
+	p, err := s.repo.Profile().Find(ctx, userID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return model.NewProfile(userID), nil
+	} else if err != nil {
+		return model.Profile{}, err
+	}
+	return p.model(), nil
+}
+
+func (s *Service) ChangeProfile(ctx context.Context, userID string, profilePram model.Profile) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.changeProfile(ctx, userID, profilePram)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to change profile: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Change profile of user(%v)", userID))
+	}
+
+	return err
+}
+
+func (s *Service) changeProfile(ctx context.Context, userID string, profilePram model.Profile) error {
+	if utf8.RuneCountInString(profilePram.DisplayName) > 64 {
+		return cerror.NewInvalidContentError(
+			nil,
+			"display name is too long",
+		)
+	}
+
+	if profilePram.AvatarURL != "" {
+		u, err := url.Parse(profilePram.AvatarURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return cerror.NewInvalidContentError(
+				err,
+				fmt.Sprintf("invalid avatar url(%v)", profilePram.AvatarURL),
+			)
+		}
+	}
+
+	if _, err := time.LoadLocation(profilePram.TimeZone); err != nil || profilePram.TimeZone == "" || profilePram.TimeZone == "Local" {
+		return cerror.NewInvalidContentError(
+			err,
+			fmt.Sprintf("invalid time zone(%v)", profilePram.TimeZone),
+		)
+	}
+
+	if !localePattern.MatchString(profilePram.Locale) {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("invalid locale(%v)", profilePram.Locale),
+		)
+	}
+
+	if profilePram.WeekStart < time.Sunday || time.Saturday < profilePram.WeekStart {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("invalid week start(%v)", int(profilePram.WeekStart)),
+		)
+	}
+
+	if profilePram.DefaultCalendarID != "" {
+		cal, err := s.repo.Calendar().Find(ctx, profilePram.DefaultCalendarID)
+		if errors.Is(err, cerror.ErrNotFound) {
+			return cerror.NewInvalidContentError(
+				err,
+				fmt.Sprintf("invalid default calendar(%v)", profilePram.DefaultCalendarID),
+			)
+		} else if err != nil {
+			return err
+		}
+
+		access, err := s.canAccess(ctx, userID, cal)
+		if err != nil {
+			return err
+		}
+		if !access {
+			return cerror.NewInvalidContentError(
+				nil,
+				fmt.Sprintf("invalid default calendar(%v)", profilePram.DefaultCalendarID),
+			)
+		}
+	}
+
+	profilePram.UserID = userID
+
+	return s.repo.Profile().Save(ctx, newProfileData(profilePram))
+}
+
+// GetDisplayNames returns names to show for the users. Users without display names are
+// shown by names of their accounts, and unknown users are shown by their IDs.
+func (s *Service) GetDisplayNames(ctx context.Context, userIDs []string) (map[string]string, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	names, err := s.getDisplayNames(ctx, userIDs)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get display names: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get display names of %v users", len(names)))
+	}
+
+	return names, err
+}
+
+func (s *Service) getDisplayNames(ctx context.Context, userIDs []string) (map[string]string, error) {
+	pl, err := s.repo.Profile().FindProfiles(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	profiles := map[string]ProfileData{}
+	for _, p := range pl {
+		profiles[p.UserID] = p
+	}
+
+	names := map[string]string{}
+	unnamed := []string{}
+	for _, id := range userIDs {
+		if _, ok := names[id]; ok {
+			continue
+		}
+		p := model.NewProfile(id)
+		if data, ok := profiles[id]; ok {
+			p = data.model()
+		}
+		names[id] = p.Name()
+		if p.DisplayName == "" {
+			unnamed = append(unnamed, id)
+		}
+	}
+
+	if s.namer == nil || len(unnamed) == 0 {
+		return names, nil
+	}
+	accounts, err := s.namer.UserNames(ctx, unnamed)
+	if err != nil {
+		return nil, err
+	}
+	for id, name := range accounts {
+		names[id] = name
+	}
+	return names, nil
+}
@@ -8,33 +8,137 @@ import (
 )
 
 type Repogitory interface {
-	// TxBegin()
-	// TxCommit()
-	// TxRollback()
+	// Transaction runs f with the repogitory in a transaction. Changes made in f are discarded if f returns an error.
+	Transaction(ctx context.Context, f func(Repogitory) error) error
 	Calendar() CalendarRepogitory
 	Plan() PlanRepogitory
 	User() UserRepogitory
+	Profile() ProfileRepogitory
+	Org() OrgRepogitory
+	Member() MemberRepogitory
+	Group() GroupRepogitory
+	Transfer() TransferRepogitory
+	Revision() RevisionRepogitory
 }
 
+// CalendarRepogitory stores calendars. Finders except FindTrashed and FindTrashByUserID ignore calendars in the trash.
 type CalendarRepogitory interface {
 	Create(ctx context.Context, cal CalendarData) error
+	// Delete removes the calendar permanently.
 	Delete(ctx context.Context, id string) error
 	Update(ctx context.Context, cal CalendarData) error
+	// Trash moves the calendar into the trash. deletedAt is unix time.
+	Trash(ctx context.Context, id string, deletedAt int64) error
+	// Restore takes the calendar out of the trash.
+	Restore(ctx context.Context, id string) error
+	// Purge removes calendars moved into the trash before the time and returns the number of them.
+	Purge(ctx context.Context, before int64) (int, error)
 	Find(ctx context.Context, id string) (CalendarData, error)
+	// FindCalendars returns calendars with the IDs in a query. Calendars not found are omitted.
+	FindCalendars(ctx context.Context, ids []string) ([]CalendarData, error)
+	FindTrashed(ctx context.Context, id string) (CalendarData, error)
+	// FindTrashByUserID returns calendars in the trash the user owns.
+	FindTrashByUserID(ctx context.Context, userID string) ([]CalendarData, error)
 	FindByUserID(ctx context.Context, userID string) ([]CalendarData, error)
+	FindByOrgID(ctx context.Context, orgID string) ([]CalendarData, error)
+	// FindByOrgIDs returns calendars of the organizations in a query.
+	FindByOrgIDs(ctx context.Context, orgIDs []string) ([]CalendarData, error)
+	// FindByGroupID returns calendars shared with the group.
+	FindByGroupID(ctx context.Context, groupID string) ([]CalendarData, error)
+	// FindByGroupIDs returns calendars shared with any of the groups in a query.
+	FindByGroupIDs(ctx context.Context, groupIDs []string) ([]CalendarData, error)
+	// CountByUserID returns the number of calendars the user owns.
+	CountByUserID(ctx context.Context, userID string) (int, error)
 }
 
+// PlanRepogitory stores plans. Finders except FindTrashed and FindTrashByUserID ignore plans in the trash.
 type PlanRepogitory interface {
 	Create(ctx context.Context, plan PlanData) error
+	// Delete removes the plan permanently.
 	Delete(ctx context.Context, id string) error
 	Update(ctx context.Context, plan PlanData) error
+	// Trash moves the plan into the trash. deletedAt is unix time.
+	Trash(ctx context.Context, id string, deletedAt int64) error
+	// Restore takes the plan out of the trash.
+	Restore(ctx context.Context, id string) error
+	// Purge removes plans moved into the trash before the time and returns the number of them.
+	Purge(ctx context.Context, before int64) (int, error)
 	Find(ctx context.Context, id string) (PlanData, error)
+	FindTrashed(ctx context.Context, id string) (PlanData, error)
+	// FindTrashByUserID returns plans in the trash the user made.
+	FindTrashByUserID(ctx context.Context, userID string) ([]PlanData, error)
 	FindByCalendarID(ctx context.Context, calID string) ([]PlanData, error)
+	// FindByCalendarIDs returns plans of the calendars in a query keyed by the calendar IDs.
+	// A plan shared with some of the calendars is in all of their values.
+	FindByCalendarIDs(ctx context.Context, calIDs []string) (map[string][]PlanData, error)
+	// CountByUserID returns the number of plans the user made.
+	CountByUserID(ctx context.Context, userID string) (int, error)
 }
 
 type UserRepogitory interface {
 	Create(ctx context.Context, user UserData) error
 	Find(ctx context.Context, id string) (UserData, error)
+	// FindUsers returns users with the IDs in a query. Users not found are omitted.
+	FindUsers(ctx context.Context, ids []string) ([]UserData, error)
+}
+
+// ProfileRepogitory stores profiles. Users without saved profile are not found.
+type ProfileRepogitory interface {
+	Find(ctx context.Context, userID string) (ProfileData, error)
+	// FindProfiles returns profiles of the users in a query. Users without saved profile are omitted.
+	FindProfiles(ctx context.Context, userIDs []string) ([]ProfileData, error)
+	// Save creates or updates the profile.
+	Save(ctx context.Context, profile ProfileData) error
+}
+
+type OrgRepogitory interface {
+	Create(ctx context.Context, org OrgData) error
+	Delete(ctx context.Context, id string) error
+	Find(ctx context.Context, id string) (OrgData, error)
+	// FindByUserID returns organizations the user belongs to.
+	FindByUserID(ctx context.Context, userID string) ([]OrgData, error)
+}
+
+type MemberRepogitory interface {
+	// Save adds the member or changes the role.
+	Save(ctx context.Context, member MemberData) error
+	Delete(ctx context.Context, orgID, userID string) error
+	Find(ctx context.Context, orgID, userID string) (MemberData, error)
+	FindByOrgID(ctx context.Context, orgID string) ([]MemberData, error)
+}
+
+type GroupRepogitory interface {
+	// Create saves the group without members.
+	Create(ctx context.Context, group GroupData) error
+	Delete(ctx context.Context, id string) error
+	Find(ctx context.Context, id string) (GroupData, error)
+	// FindGroups returns groups with the IDs in a query. Groups not found are omitted.
+	FindGroups(ctx context.Context, ids []string) ([]GroupData, error)
+	// FindByUserID returns groups the user is a member of.
+	FindByUserID(ctx context.Context, userID string) ([]GroupData, error)
+	AddMember(ctx context.Context, groupID, userID string) error
+	RemoveMember(ctx context.Context, groupID, userID string) error
+}
+
+// TransferRepogitory stores pending transfers. A calendar has at most one pending transfer.
+type TransferRepogitory interface {
+	// Save creates the transfer or replaces the pending one of the calendar.
+	Save(ctx context.Context, transfer TransferData) error
+	Delete(ctx context.Context, calID string) error
+	Find(ctx context.Context, calID string) (TransferData, error)
+	// FindByUserID returns transfers to the user.
+	FindByUserID(ctx context.Context, userID string) ([]TransferData, error)
+}
+
+// RevisionRepogitory stores revisions. They are never changed after created.
+type RevisionRepogitory interface {
+	Create(ctx context.Context, rev RevisionData) error
+	// FindByTargetID returns revisions of the plan or the calendar in order of creation.
+	FindByTargetID(ctx context.Context, targetID string) ([]RevisionData, error)
+	// FindByCalendarID returns revisions of the calendar and plans in it in order of creation.
+	FindByCalendarID(ctx context.Context, calID string) ([]RevisionData, error)
+	// FindByOperationID returns revisions made by the operation in order of creation.
+	FindByOperationID(ctx context.Context, opID string) ([]RevisionData, error)
 }
 
 type UserData struct {
@@ -53,32 +157,182 @@ func (u *UserData) model() model.User {
 	}
 }
 
-type CalendarData struct {
-	ID     string
+type ProfileData struct {
+	UserID            string
+	DisplayName       string
+	AvatarURL         string
+	TimeZone          string
+	Locale            string
+	WeekStart         int
+	DefaultCalendarID string
+}
+
+func newProfileData(profile model.Profile) ProfileData {
+	return ProfileData{
+		UserID:            profile.UserID,
+		DisplayName:       profile.DisplayName,
+		AvatarURL:         profile.AvatarURL,
+		TimeZone:          profile.TimeZone,
+		Locale:            profile.Locale,
+		WeekStart:         int(profile.WeekStart),
+		DefaultCalendarID: profile.DefaultCalendarID,
+	}
+}
+
+func (p *ProfileData) model() model.Profile {
+	return model.Profile{
+		UserID:            p.UserID,
+		DisplayName:       p.DisplayName,
+		AvatarURL:         p.AvatarURL,
+		TimeZone:          p.TimeZone,
+		Locale:            p.Locale,
+		WeekStart:         time.Weekday(p.WeekStart),
+		DefaultCalendarID: p.DefaultCalendarID,
+	}
+}
+
+type OrgData struct {
+	ID   string
+	Name string
+}
+
+func newOrgData(org model.Org) OrgData {
+	return OrgData{
+		ID:   org.ID,
+		Name: org.Name,
+	}
+}
+
+func (o *OrgData) model() model.Org {
+	return model.Org{
+		ID:   o.ID,
+		Name: o.Name,
+	}
+}
+
+type MemberData struct {
+	OrgID  string
 	UserID string
-	Name   string
-	Color  string
-	Shares []string
+	Role   string
+}
+
+func newMemberData(member model.Member) MemberData {
+	return MemberData{
+		OrgID:  member.OrgID,
+		UserID: member.UserID,
+		Role:   string(member.Role),
+	}
+}
+
+func (m *MemberData) model() model.Member {
+	return model.Member{
+		OrgID:  m.OrgID,
+		UserID: m.UserID,
+		Role:   model.Role(m.Role),
+	}
+}
+
+type GroupData struct {
+	ID      string
+	UserID  string
+	Name    string
+	Members []string
+}
+
+func newGroupData(group model.Group) GroupData {
+	return GroupData{
+		ID:      group.ID,
+		UserID:  group.UserID,
+		Name:    group.Name,
+		Members: group.Members,
+	}
+}
+
+func (g *GroupData) model() model.Group {
+	return model.Group{
+		ID:      g.ID,
+		UserID:  g.UserID,
+		Name:    g.Name,
+		Members: g.Members,
+	}
+}
+
+type TransferData struct {
+	CalendarID    string
+	FromUserID    string
+	ToUserID      string
+	TransferPlans bool
+}
+
+func newTransferData(transfer model.Transfer) TransferData {
+	return TransferData{
+		CalendarID:    transfer.CalendarID,
+		FromUserID:    transfer.FromUserID,
+		ToUserID:      transfer.ToUserID,
+		TransferPlans: transfer.TransferPlans,
+	}
+}
+
+func (t *TransferData) model() model.Transfer {
+	return model.Transfer{
+		CalendarID:    t.CalendarID,
+		FromUserID:    t.FromUserID,
+		ToUserID:      t.ToUserID,
+		TransferPlans: t.TransferPlans,
+	}
+}
+
+type RevisionData struct {
+	ID string
+	// OperationID is ID of the request which made the revision. An operation may make several revisions.
+	OperationID string
+	Target      string
+	TargetID    string
+	CalendarID  string
+	UserID      string
+	Action      string
+	CreatedAt   int64
+	// Before and After are JSON of PlanData or CalendarData. Before is empty if created and After is empty if deleted.
+	Before string
+	After  string
+}
+
+type CalendarData struct {
+	ID          string
+	UserID      string
+	OrgID       string
+	Name        string
+	Color       string
+	Shares      []string
+	GroupShares []string
+	// DeletedAt is unix time when the calendar was moved into the trash. It is 0 if not.
+	DeletedAt int64
 }
 
 func newCalendarData(cal model.Calendar) CalendarData {
 	return CalendarData{
-		ID:     cal.ID,
-		UserID: cal.UserID,
-		Name:   cal.Name,
-		Color:  string(cal.Color),
-		Shares: cal.Shares,
+		ID:          cal.ID,
+		UserID:      cal.UserID,
+		OrgID:       cal.OrgID,
+		Name:        cal.Name,
+		Color:       string(cal.Color),
+		Shares:      cal.Shares,
+		GroupShares: cal.GroupShares,
+		DeletedAt:   unixTime(cal.DeletedAt),
 	}
 }
 
 func (c *CalendarData) model() model.Calendar {
 	return model.Calendar{
-		ID:     c.ID,
-		UserID: c.UserID,
-		Name:   c.Name,
-		Color:  model.Color(c.Color),
-		Plans:  []model.Plan{},
-		Shares: c.Shares,
+		ID:          c.ID,
+		UserID:      c.UserID,
+		OrgID:       c.OrgID,
+		Name:        c.Name,
+		Color:       model.Color(c.Color),
+		Plans:       []model.Plan{},
+		Shares:      c.Shares,
+		GroupShares: c.GroupShares,
+		DeletedAt:   timeOf(c.DeletedAt),
 	}
 }
 
@@ -94,6 +348,8 @@ type PlanData struct {
 	IsAllDay   bool
 	Begin      int64
 	End        int64
+	// DeletedAt is unix time when the plan was moved into the trash. It is 0 if not.
+	DeletedAt int64
 }
 
 func newPlanData(plan model.Plan) PlanData {
@@ -109,6 +365,7 @@ func newPlanData(plan model.Plan) PlanData {
 		IsAllDay:   plan.Period.IsAllDay,
 		Begin:      plan.Period.Begin.Unix(),
 		End:        plan.Period.End.Unix(),
+		DeletedAt:  unixTime(plan.DeletedAt),
 	}
 }
 
@@ -127,5 +384,22 @@ func (p *PlanData) model() model.Plan {
 			Begin:    time.Unix(p.Begin, 0),
 			End:      time.Unix(p.End, 0),
 		},
+		DeletedAt: timeOf(p.DeletedAt),
+	}
+}
+
+// unixTime converts t to unix time. Zero time is 0.
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeOf converts unix time to time. 0 is zero time.
+func timeOf(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
 	}
+	return time.Unix(sec, 0)
 }
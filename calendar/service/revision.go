@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// GetPlanHistory returns revisions of the plan. Users who can access the plan can see them.
+func (s *Service) GetPlanHistory(ctx context.Context, userID, planID string) ([]model.Revision, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	revisions, err := s.getPlanHistory(ctx, userID, planID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get history of plan: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get history of plan(%v)", planID))
+	}
+
+	return revisions, err
+}
+
+func (s *Service) getPlanHistory(ctx context.Context, userID, planID string) ([]model.Revision, error) {
+	// History of plans in the trash is available for the owners.
+	plan, err := s.repo.Plan().Find(ctx, planID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		plan, err = s.repo.Plan().FindTrashed(ctx, planID)
+		if err == nil && plan.UserID != userID {
+			err = cerror.NewNotFoundError(
+				nil,
+				fmt.Sprintf("not found plan(%v)", planID),
+			)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.canAccessPlan(ctx, userID, plan)
+	if err != nil {
+		return nil, err
+	}
+	if !access {
+		return nil, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to access the plan(%v)", userID, planID),
+		)
+	}
+
+	rl, err := s.repo.Revision().FindByTargetID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	return revisionsModel(userID, rl)
+}
+
+// GetCalendarAudit returns revisions of the calendar and plans in it. Users who can access the calendar can see them.
+func (s *Service) GetCalendarAudit(ctx context.Context, userID, calID string) ([]model.Revision, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	revisions, err := s.getCalendarAudit(ctx, userID, calID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get audit of calendar: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get audit of calendar(%v)", calID))
+	}
+
+	return revisions, err
+}
+
+func (s *Service) getCalendarAudit(ctx context.Context, userID, calID string) ([]model.Revision, error) {
+	cal, err := s.repo.Calendar().Find(ctx, calID)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.canAccess(ctx, userID, cal)
+	if err != nil {
+		return nil, err
+	}
+	if !access {
+		return nil, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to access the calendar(%v)", userID, calID),
+		)
+	}
+
+	rl, err := s.repo.Revision().FindByCalendarID(ctx, calID)
+	if err != nil {
+		return nil, err
+	}
+	return revisionsModel(userID, rl)
+}
+
+// canAccessPlan tells whether the user made the plan or can access a calendar it is published to.
+func (s *Service) canAccessPlan(ctx context.Context, userID string, plan PlanData) (bool, error) {
+	if plan.UserID == userID {
+		return true, nil
+	}
+	cals, err := s.repo.Calendar().FindCalendars(ctx, plan.Shares)
+	if err != nil {
+		return false, err
+	}
+	access, err := s.canAccessCalendars(ctx, userID, cals)
+	if err != nil {
+		return false, err
+	}
+	for _, ok := range access {
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordPlan saves the revision of the plan. before is nil if it is created and after is nil if it is deleted.
+func (s *Service) recordPlan(ctx context.Context, userID string, action model.Action, before, after *PlanData) error {
+	rev := RevisionData{
+		Target: string(model.PLAN),
+		UserID: userID,
+		Action: string(action),
+	}
+	for _, p := range []*PlanData{before, after} {
+		if p != nil {
+			rev.TargetID = p.ID
+			rev.CalendarID = p.CalendarID
+		}
+	}
+	var err error
+	if before != nil {
+		if rev.Before, err = snapshot(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if rev.After, err = snapshot(after); err != nil {
+			return err
+		}
+	}
+	return s.record(ctx, rev)
+}
+
+// recordCalendar saves the revision of the calendar. before is nil if it is created and after is nil if it is deleted.
+func (s *Service) recordCalendar(ctx context.Context, userID string, action model.Action, before, after *CalendarData) error {
+	rev := RevisionData{
+		Target: string(model.CALENDAR),
+		UserID: userID,
+		Action: string(action),
+	}
+	for _, c := range []*CalendarData{before, after} {
+		if c != nil {
+			rev.TargetID = c.ID
+			rev.CalendarID = c.ID
+		}
+	}
+	var err error
+	if before != nil {
+		if rev.Before, err = snapshot(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if rev.After, err = snapshot(after); err != nil {
+			return err
+		}
+	}
+	return s.record(ctx, rev)
+}
+
+// record saves the revision as a part of the operation of the request.
+func (s *Service) record(ctx context.Context, rev RevisionData) error {
+	rev.ID = uuid.New().String()
+	rev.OperationID = ctx.Value(cctx.ReqIDKey).(string)
+	rev.CreatedAt = s.clock.Now().Unix()
+	return s.repo.Revision().Create(ctx, rev)
+}
+
+func snapshot(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", cerror.NewInternalError(
+			err,
+			"failed to encode snapshot",
+		)
+	}
+	return string(b), nil
+}
+
+// revisionsModel converts revisions into models for the user. Names and memos of private plans are hidden from others.
+func revisionsModel(userID string, rl []RevisionData) ([]model.Revision, error) {
+	revisions := make([]model.Revision, len(rl))
+	for i, r := range rl {
+		var before, after map[string]interface{}
+		var err error
+		switch model.Target(r.Target) {
+		case model.PLAN:
+			before, after, err = planRevisionFields(userID, r)
+		case model.CALENDAR:
+			before, after, err = calendarRevisionFields(r)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		revisions[i] = model.Revision{
+			ID:          r.ID,
+			OperationID: r.OperationID,
+			Target:      model.Target(r.Target),
+			TargetID:    r.TargetID,
+			CalendarID:  r.CalendarID,
+			UserID:      r.UserID,
+			Action:      model.Action(r.Action),
+			CreatedAt:   time.Unix(r.CreatedAt, 0),
+			Changes:     diffFields(before, after),
+		}
+	}
+	return revisions, nil
+}
+
+// plans returns states of the plan before and after the revision. They are nil if the plan does not exist.
+func (r *RevisionData) plans() (*PlanData, *PlanData, error) {
+	var before, after *PlanData
+	if r.Before != "" {
+		before = &PlanData{}
+		if err := json.Unmarshal([]byte(r.Before), before); err != nil {
+			return nil, nil, cerror.NewInternalError(
+				err,
+				fmt.Sprintf("failed to decode revision(%v)", r.ID),
+			)
+		}
+	}
+	if r.After != "" {
+		after = &PlanData{}
+		if err := json.Unmarshal([]byte(r.After), after); err != nil {
+			return nil, nil, cerror.NewInternalError(
+				err,
+				fmt.Sprintf("failed to decode revision(%v)", r.ID),
+			)
+		}
+	}
+	return before, after, nil
+}
+
+// calendars returns states of the calendar before and after the revision. They are nil if the calendar does not exist.
+func (r *RevisionData) calendars() (*CalendarData, *CalendarData, error) {
+	var before, after *CalendarData
+	if r.Before != "" {
+		before = &CalendarData{}
+		if err := json.Unmarshal([]byte(r.Before), before); err != nil {
+			return nil, nil, cerror.NewInternalError(
+				err,
+				fmt.Sprintf("failed to decode revision(%v)", r.ID),
+			)
+		}
+	}
+	if r.After != "" {
+		after = &CalendarData{}
+		if err := json.Unmarshal([]byte(r.After), after); err != nil {
+			return nil, nil, cerror.NewInternalError(
+				err,
+				fmt.Sprintf("failed to decode revision(%v)", r.ID),
+			)
+		}
+	}
+	return before, after, nil
+}
+
+func planRevisionFields(userID string, r RevisionData) (map[string]interface{}, map[string]interface{}, error) {
+	before, after, err := r.plans()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	private := false
+	for _, p := range []*PlanData{before, after} {
+		if p != nil && p.Private && p.UserID != userID {
+			private = true
+		}
+	}
+	if private {
+		for _, p := range []*PlanData{before, after} {
+			if p != nil {
+				p.Name = ""
+				p.Memo = ""
+				p.Shares = nil
+			}
+		}
+	}
+	return planFields(before), planFields(after), nil
+}
+
+func calendarRevisionFields(r RevisionData) (map[string]interface{}, map[string]interface{}, error) {
+	before, after, err := r.calendars()
+	if err != nil {
+		return nil, nil, err
+	}
+	return calendarFields(before), calendarFields(after), nil
+}
+
+// planFields returns fields of the plan shown in revisions. It is empty for nil.
+func planFields(p *PlanData) map[string]interface{} {
+	if p == nil {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"calendar_id": p.CalendarID,
+		"user_id":     p.UserID,
+		"name":        p.Name,
+		"memo":        p.Memo,
+		"color":       p.Color,
+		"private":     p.Private,
+		"shares":      sortedIDs(p.Shares),
+		"is_all_day":  p.IsAllDay,
+		"begin":       p.Begin,
+		"end":         p.End,
+	}
+}
+
+// calendarFields returns fields of the calendar shown in revisions. It is empty for nil.
+func calendarFields(c *CalendarData) map[string]interface{} {
+	if c == nil {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"user_id":      c.UserID,
+		"org_id":       c.OrgID,
+		"name":         c.Name,
+		"color":        c.Color,
+		"shares":       sortedIDs(c.Shares),
+		"group_shares": sortedIDs(c.GroupShares),
+	}
+}
+
+// sortedIDs returns sorted copy of IDs not to regard order of shares and nil as a change.
+func sortedIDs(l []string) []string {
+	ids := make([]string, len(l))
+	copy(ids, l)
+	sort.Strings(ids)
+	return ids
+}
+
+// diffFields returns changes between fields in order of the field name.
+func diffFields(before, after map[string]interface{}) []model.Change {
+	names := []string{}
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []model.Change{}
+	for _, name := range names {
+		b, a := before[name], after[name]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		changes = append(changes, model.Change{
+			Field:  name,
+			Before: b,
+			After:  a,
+		})
+	}
+	return changes
+}
@@ -1,17 +1,70 @@
 package service
 
 import (
+	"context"
+
+	"github.com/x-color/calendar/clock"
 	"github.com/x-color/calendar/logging"
 )
 
+// UserVerifier tells whether a user verified the email address.
+// Unverified users are not allowed to be invited to calendars.
+type UserVerifier interface {
+	IsVerified(ctx context.Context, userID string) (bool, error)
+}
+
+// UserNamer tells names of accounts of users.
+// They are shown for users without display names.
+type UserNamer interface {
+	UserNames(ctx context.Context, userIDs []string) (map[string]string, error)
+}
+
 type Service struct {
-	repo Repogitory
-	log  logging.Logger
+	repo     Repogitory
+	log      logging.Logger
+	verifier UserVerifier
+	namer    UserNamer
+	clock    clock.Clock
 }
 
 func NewService(repo Repogitory, log logging.Logger) Service {
 	return Service{
-		repo: repo,
-		log:  log,
+		repo:  repo,
+		log:   log,
+		clock: clock.Real,
+	}
+}
+
+// SetClock sets the clock telling the time of trash and revisions and the window of undo.
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetUserVerifier sets verifier to restrict unverified users.
+// All users are regarded as verified if it is not set.
+func (s *Service) SetUserVerifier(verifier UserVerifier) {
+	s.verifier = verifier
+}
+
+// SetUserNamer sets namer to show users without display names by names of their accounts.
+// They are shown by their IDs if it is not set.
+func (s *Service) SetUserNamer(namer UserNamer) {
+	s.namer = namer
+}
+
+// transaction runs f with a copy of the service whose repogitory is in a transaction.
+// Changes made in f are discarded if it returns an error.
+func (s *Service) transaction(ctx context.Context, f func(s *Service) error) error {
+	return s.repo.Transaction(ctx, func(repo Repogitory) error {
+		t := *s
+		t.repo = repo
+		return f(&t)
+	})
+}
+
+func (s *Service) isVerified(ctx context.Context, userID string) (bool, error) {
+	if s.verifier == nil {
+		return true, nil
 	}
+	return s.verifier.IsVerified(ctx, userID)
 }
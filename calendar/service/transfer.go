@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+	"github.com/x-color/slice/strs"
+)
+
+// RequestTransfer asks the user in shares to take ownership of the calendar.
+// It replaces the pending transfer of the calendar if it exists.
+func (s *Service) RequestTransfer(ctx context.Context, userID string, transferPram model.Transfer) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	transferPram.FromUserID = userID
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.requestTransfer(ctx, transferPram)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to request transfer: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Request transfer of calendar(%v) to user(%v)", transferPram.CalendarID, transferPram.ToUserID))
+	}
+
+	return err
+}
+
+func (s *Service) requestTransfer(ctx context.Context, transferPram model.Transfer) error {
+	if transferPram.CalendarID == "" || transferPram.ToUserID == "" {
+		return cerror.NewInvalidContentError(
+			nil,
+			"some id are empty",
+		)
+	}
+
+	cal, err := s.repo.Calendar().Find(ctx, transferPram.CalendarID)
+	if err != nil {
+		return err
+	}
+
+	if cal.OrgID != "" {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("calendar(%v) is owned by organization", cal.ID),
+		)
+	}
+
+	if cal.UserID != transferPram.FromUserID {
+		return cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to transfer calendar(%v)", transferPram.FromUserID, cal.ID),
+		)
+	}
+
+	if transferPram.ToUserID == cal.UserID || !strs.Contains(cal.Shares, transferPram.ToUserID) {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("invalid recipient(%v)", transferPram.ToUserID),
+		)
+	}
+
+	return s.repo.Transfer().Save(ctx, newTransferData(transferPram))
+}
+
+// GetTransfers returns transfers waiting for the user to accept.
+func (s *Service) GetTransfers(ctx context.Context, userID string) ([]model.Transfer, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	transfers, err := s.getTransfers(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get transfers: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get transfers for user(%v)", userID))
+	}
+
+	return transfers, err
+}
+
+func (s *Service) getTransfers(ctx context.Context, userID string) ([]model.Transfer, error) {
+	tl, err := s.repo.Transfer().FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]model.Transfer, len(tl))
+	for i, t := range tl {
+		transfers[i] = t.model()
+	}
+	return transfers, nil
+}
+
+// AcceptTransfer makes the user owner of the calendar.
+func (s *Service) AcceptTransfer(ctx context.Context, userID, calID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	var stale *TransferData
+	err := s.transaction(ctx, func(s *Service) (err error) {
+		stale, err = s.acceptTransfer(ctx, userID, calID)
+		return err
+	})
+
+	// The transaction is rolled back with the error, so the stale transfer is deleted after it.
+	if stale != nil {
+		derr := s.transaction(ctx, func(s *Service) error {
+			return s.deleteStaleTransfer(ctx, *stale)
+		})
+		if derr != nil {
+			s.log.Error(strings.Replace(derr.Error(), "\n", "%NL", -1))
+		}
+	}
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to accept transfer: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("User(%v) takes ownership of calendar(%v)", userID, calID))
+	}
+
+	return err
+}
+
+// acceptTransfer returns the transfer with the error if the transfer is no longer valid.
+func (s *Service) acceptTransfer(ctx context.Context, userID, calID string) (*TransferData, error) {
+	transfer, err := s.repo.Transfer().Find(ctx, calID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer.ToUserID != userID {
+		return nil, cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("transfer of calendar(%v) is not for user(%v)", calID, userID),
+		)
+	}
+
+	cal, err := s.repo.Calendar().Find(ctx, calID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		// The calendar was removed after the request.
+		return &transfer, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	// The calendar changed after the request. The owner has to request again.
+	if cal.UserID != transfer.FromUserID || !strs.Contains(cal.Shares, userID) {
+		return &transfer, cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("transfer of calendar(%v) is no longer valid", calID),
+		)
+	}
+
+	if transfer.TransferPlans {
+		plans, err := s.repo.Plan().FindByCalendarID(ctx, calID)
+		if err != nil {
+			return nil, err
+		}
+		for _, plan := range plans {
+			// Plans published from other calendars are kept by the owners.
+			if plan.UserID != transfer.FromUserID || plan.CalendarID != calID {
+				continue
+			}
+			before := plan
+			plan.UserID = userID
+			if err := s.repo.Plan().Update(ctx, plan); err != nil {
+				return nil, err
+			}
+			if err := s.recordPlan(ctx, userID, model.UPDATE, &before, &plan); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	before := cal
+	cal.UserID = userID
+	if err := s.repo.Calendar().Update(ctx, cal); err != nil {
+		return nil, err
+	}
+	if err := s.recordCalendar(ctx, userID, model.UPDATE, &before, &cal); err != nil {
+		return nil, err
+	}
+
+	return nil, s.repo.Transfer().Delete(ctx, calID)
+}
+
+// deleteStaleTransfer deletes the transfer unless it is replaced by a new request.
+func (s *Service) deleteStaleTransfer(ctx context.Context, transfer TransferData) error {
+	current, err := s.repo.Transfer().Find(ctx, transfer.CalendarID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if current != transfer {
+		return nil
+	}
+	return s.repo.Transfer().Delete(ctx, transfer.CalendarID)
+}
+
+// CancelTransfer deletes the pending transfer. The owner cancels it and the recipient declines it.
+func (s *Service) CancelTransfer(ctx context.Context, userID, calID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.cancelTransfer(ctx, userID, calID)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to cancel transfer: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Cancel transfer of calendar(%v)", calID))
+	}
+
+	return err
+}
+
+func (s *Service) cancelTransfer(ctx context.Context, userID, calID string) error {
+	transfer, err := s.repo.Transfer().Find(ctx, calID)
+	if err != nil {
+		return err
+	}
+
+	if transfer.FromUserID != userID && transfer.ToUserID != userID {
+		return cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to cancel transfer of calendar(%v)", userID, calID),
+		)
+	}
+
+	return s.repo.Transfer().Delete(ctx, calID)
+}
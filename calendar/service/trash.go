@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// GetTrash returns calendars and plans the user owns in the trash.
+func (s *Service) GetTrash(ctx context.Context, userID string) (model.Trash, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	trash, err := s.getTrash(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get trash: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get trash of user(%v)", userID))
+	}
+
+	return trash, err
+}
+
+func (s *Service) getTrash(ctx context.Context, userID string) (model.Trash, error) {
+	cl, err := s.repo.Calendar().FindTrashByUserID(ctx, userID)
+	if err != nil {
+		return model.Trash{}, err
+	}
+	pl, err := s.repo.Plan().FindTrashByUserID(ctx, userID)
+	if err != nil {
+		return model.Trash{}, err
+	}
+
+	trash := model.Trash{
+		Calendars: make([]model.Calendar, len(cl)),
+		Plans:     make([]model.Plan, len(pl)),
+	}
+	for i, c := range cl {
+		trash.Calendars[i] = c.model()
+	}
+	for i, p := range pl {
+		trash.Plans[i] = p.model()
+	}
+	return trash, nil
+}
+
+// RestoreCalendar takes the calendar out of the trash. Plans in it come back together.
+func (s *Service) RestoreCalendar(ctx context.Context, userID, id string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.restoreCalendar(ctx, userID, id)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to restore calendar: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Restore calendar(%v)", id))
+	}
+
+	return err
+}
+
+func (s *Service) restoreCalendar(ctx context.Context, userID, id string) error {
+	cal, err := s.repo.Calendar().FindTrashed(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	manage, err := s.canManage(ctx, userID, cal)
+	if err != nil {
+		return err
+	}
+	if !manage {
+		return cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to restore calendar(%v)", userID, id),
+		)
+	}
+
+	if err := s.repo.Calendar().Restore(ctx, id); err != nil {
+		return err
+	}
+	cal.DeletedAt = 0
+	return s.recordCalendar(ctx, userID, model.RESTORE, nil, &cal)
+}
+
+// RestorePlan takes the plan out of the trash. Its parent calendar must not be in the trash.
+func (s *Service) RestorePlan(ctx context.Context, userID, id string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.restorePlan(ctx, userID, id)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to restore plan: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Restore plan(%v)", id))
+	}
+
+	return err
+}
+
+func (s *Service) restorePlan(ctx context.Context, userID, id string) error {
+	plan, err := s.repo.Plan().FindTrashed(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if plan.UserID != userID {
+		return cerror.NewAuthorizationError(
+			nil,
+			fmt.Sprintf("user(%v) does not permit to restore plan(%v)", userID, id),
+		)
+	}
+
+	_, err = s.repo.Calendar().Find(ctx, plan.CalendarID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return cerror.NewInvalidContentError(
+			nil,
+			fmt.Sprintf("calendar(%v) of plan(%v) is removed", plan.CalendarID, id),
+		)
+	} else if err != nil {
+		return err
+	}
+
+	if err := s.repo.Plan().Restore(ctx, id); err != nil {
+		return err
+	}
+	plan.DeletedAt = 0
+	return s.recordPlan(ctx, userID, model.RESTORE, nil, &plan)
+}
+
+// PurgeTrash removes calendars and plans moved into the trash before the time permanently.
+func (s *Service) PurgeTrash(ctx context.Context, before time.Time) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	var plans, cals int
+	err := s.transaction(ctx, func(s *Service) error {
+		var err error
+		plans, cals, err = s.purgeTrash(ctx, before)
+		return err
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to purge trash: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Purge %v calendars and %v plans in trash", cals, plans))
+	}
+
+	return err
+}
+
+func (s *Service) purgeTrash(ctx context.Context, before time.Time) (int, int, error) {
+	plans, err := s.repo.Plan().Purge(ctx, before.Unix())
+	if err != nil {
+		return 0, 0, err
+	}
+	cals, err := s.repo.Calendar().Purge(ctx, before.Unix())
+	if err != nil {
+		return plans, 0, err
+	}
+	return plans, cals, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/x-color/calendar/calendar/model"
+	cctx "github.com/x-color/calendar/model/ctx"
+	cerror "github.com/x-color/calendar/model/error"
+)
+
+// undoWindow is how long an operation can be undone after it is made.
+const undoWindow = 5 * time.Minute
+
+// Undo reverts plans and calendars changed by the operation to the states before it.
+// Only the user who made the operation can undo it. Undo is also an operation and can be undone.
+func (s *Service) Undo(ctx context.Context, userID, opID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		return s.undo(ctx, userID, opID)
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to undo operation: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Undo operation(%v)", opID))
+	}
+
+	return err
+}
+
+func (s *Service) undo(ctx context.Context, userID, opID string) error {
+	rl, err := s.repo.Revision().FindByOperationID(ctx, opID)
+	if err != nil {
+		return err
+	}
+	if len(rl) == 0 {
+		return cerror.NewNotFoundError(
+			nil,
+			fmt.Sprintf("not found operation(%v)", opID),
+		)
+	}
+
+	for _, r := range rl {
+		if r.UserID != userID {
+			return cerror.NewAuthorizationError(
+				nil,
+				fmt.Sprintf("user(%v) does not permit to undo operation(%v)", userID, opID),
+			)
+		}
+		if s.clock.Now().Sub(time.Unix(r.CreatedAt, 0)) > undoWindow {
+			return cerror.NewInvalidContentError(
+				nil,
+				fmt.Sprintf("operation(%v) is too old to undo", opID),
+			)
+		}
+	}
+
+	// Revisions are reverted from the last one.
+	for i := len(rl) - 1; i >= 0; i-- {
+		var err error
+		switch model.Target(rl[i].Target) {
+		case model.PLAN:
+			err = s.revertPlan(ctx, userID, rl[i])
+		case model.CALENDAR:
+			err = s.revertCalendar(ctx, userID, rl[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revertPlan makes the plan the state before the revision.
+// It fails if the plan was changed after the revision.
+func (s *Service) revertPlan(ctx context.Context, userID string, r RevisionData) error {
+	before, after, err := r.plans()
+	if err != nil {
+		return err
+	}
+
+	changed := cerror.NewInvalidContentError(
+		nil,
+		fmt.Sprintf("plan(%v) is changed after operation(%v)", r.TargetID, r.OperationID),
+	)
+
+	// The plan was moved into the trash.
+	if after == nil {
+		plan, err := s.repo.Plan().FindTrashed(ctx, r.TargetID)
+		if errors.Is(err, cerror.ErrNotFound) {
+			return changed
+		} else if err != nil {
+			return err
+		}
+		if err := s.repo.Plan().Restore(ctx, plan.ID); err != nil {
+			return err
+		}
+		plan.DeletedAt = 0
+		return s.recordPlan(ctx, userID, model.RESTORE, nil, &plan)
+	}
+
+	plan, err := s.repo.Plan().Find(ctx, r.TargetID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return changed
+	} else if err != nil {
+		return err
+	}
+	if len(diffFields(planFields(&plan), planFields(after))) != 0 {
+		return changed
+	}
+
+	// The plan was made or restored.
+	if before == nil {
+		if err := s.repo.Plan().Trash(ctx, plan.ID, s.clock.Now().Unix()); err != nil {
+			return err
+		}
+		return s.recordPlan(ctx, userID, model.DELETE, &plan, nil)
+	}
+
+	before.DeletedAt = 0
+	if err := s.repo.Plan().Update(ctx, *before); err != nil {
+		return err
+	}
+	return s.recordPlan(ctx, userID, model.UPDATE, &plan, before)
+}
+
+// revertCalendar makes the calendar the state before the revision.
+// It fails if the calendar was changed after the revision.
+func (s *Service) revertCalendar(ctx context.Context, userID string, r RevisionData) error {
+	before, after, err := r.calendars()
+	if err != nil {
+		return err
+	}
+
+	changed := cerror.NewInvalidContentError(
+		nil,
+		fmt.Sprintf("calendar(%v) is changed after operation(%v)", r.TargetID, r.OperationID),
+	)
+
+	// The calendar was moved into the trash.
+	if after == nil {
+		cal, err := s.repo.Calendar().FindTrashed(ctx, r.TargetID)
+		if errors.Is(err, cerror.ErrNotFound) {
+			return changed
+		} else if err != nil {
+			return err
+		}
+		if err := s.repo.Calendar().Restore(ctx, cal.ID); err != nil {
+			return err
+		}
+		cal.DeletedAt = 0
+		return s.recordCalendar(ctx, userID, model.RESTORE, nil, &cal)
+	}
+
+	cal, err := s.repo.Calendar().Find(ctx, r.TargetID)
+	if errors.Is(err, cerror.ErrNotFound) {
+		return changed
+	} else if err != nil {
+		return err
+	}
+	if len(diffFields(calendarFields(&cal), calendarFields(after))) != 0 {
+		return changed
+	}
+
+	// The calendar was made or restored.
+	if before == nil {
+		if err := s.repo.Calendar().Trash(ctx, cal.ID, s.clock.Now().Unix()); err != nil {
+			return err
+		}
+		return s.recordCalendar(ctx, userID, model.DELETE, &cal, nil)
+	}
+
+	before.DeletedAt = 0
+	if err := s.repo.Calendar().Update(ctx, *before); err != nil {
+		return err
+	}
+	return s.recordCalendar(ctx, userID, model.UPDATE, &cal, before)
+}
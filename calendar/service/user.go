@@ -48,6 +48,34 @@ func (s *Service) registerUser(ctx context.Context, id string) (model.User, erro
 	return user, nil
 }
 
+// RegisterNewUser registers the user with a calendar as the client does after signup.
+// It is for users made without the client, such as users of identity providers.
+func (s *Service) RegisterNewUser(ctx context.Context, userID string) error {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	err := s.transaction(ctx, func(s *Service) error {
+		if _, err := s.registerUser(ctx, userID); err != nil {
+			return err
+		}
+		_, err := s.makeCalendar(ctx, userID, "", "calendar", "red")
+		return err
+	})
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to register new user: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Register new user(%v)", userID))
+	}
+
+	return err
+}
+
 func (s *Service) CheckRegistration(ctx context.Context, userID string) error {
 	reqID := ctx.Value(cctx.ReqIDKey).(string)
 	s.log = s.log.Uniq(reqID)
@@ -88,3 +116,38 @@ func (s *Service) checkRegistration(ctx context.Context, id string) error {
 
 	return nil
 }
+
+func (s *Service) GetUsage(ctx context.Context, userID string) (model.Usage, error) {
+	reqID := ctx.Value(cctx.ReqIDKey).(string)
+	s.log = s.log.Uniq(reqID)
+
+	usage, err := s.getUsage(ctx, userID)
+
+	if err != nil {
+		msg := strings.Replace(err.Error(), "\n", "%NL", -1)
+		if errors.Is(err, cerror.ErrInternal) {
+			s.log.Error(msg)
+		} else {
+			s.log.Info(fmt.Sprintf("Failed to get usage: %v", msg))
+		}
+	} else {
+		s.log.Info(fmt.Sprintf("Get usage of user(%v)", userID))
+	}
+
+	return usage, err
+}
+
+func (s *Service) getUsage(ctx context.Context, userID string) (model.Usage, error) {
+	cals, err := s.repo.Calendar().CountByUserID(ctx, userID)
+	if err != nil {
+		return model.Usage{}, err
+	}
+	plans, err := s.repo.Plan().CountByUserID(ctx, userID)
+	if err != nil {
+		return model.Usage{}, err
+	}
+	return model.Usage{
+		Calendars: cals,
+		Plans:     plans,
+	}, nil
+}
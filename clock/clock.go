@@ -0,0 +1,52 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time. Services read the time through it so that tests can set it.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Real is the clock of the system.
+var Real Clock = realClock{}
+
+// Fake is a clock which stays at the time set by tests.
+type Fake struct {
+	m   sync.Mutex
+	now time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{
+		now: now,
+	}
+}
+
+func (c *Fake) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+// Set changes the time to now.
+func (c *Fake) Set(now time.Time) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = now
+}
+
+// Advance moves the time forward by d.
+func (c *Fake) Advance(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	as "github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/auth/sessiontoken"
+	"github.com/x-color/calendar/logging"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Config is the configuration of the server. It is read from a YAML file, environment
+// variables and flags in this order. Later ones override earlier ones.
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Storage  Storage  `yaml:"storage"`
+	Session  Session  `yaml:"session"`
+	Cache    Cache    `yaml:"cache"`
+	Cookie   Cookie   `yaml:"cookie"`
+	Log      Log      `yaml:"log"`
+	Password Password `yaml:"password"`
+	Mail     Mail     `yaml:"mail"`
+	OIDC     OIDC     `yaml:"oidc"`
+	Trash    Trash    `yaml:"trash"`
+}
+
+type Server struct {
+	Port string `yaml:"port"`
+	// BaseURL is the URL of the application used in links of mails.
+	BaseURL string `yaml:"base_url"`
+	// TrustProxy uses X-Forwarded-For header as client IP.
+	// It must be enabled only behind a reverse proxy appending the header.
+	TrustProxy bool `yaml:"trust_proxy"`
+	// RequestTimeout is the deadline of API requests. Queries are cancelled after it. Zero means no deadline.
+	RequestTimeout Duration `yaml:"request_timeout"`
+}
+
+// Storage selects the backend of users and calendars: "postgres", "sqlite" or "inmem".
+// Data in "inmem" is lost on restart. PostgreSQL needs Redis for login attempts and tokens
+// unless sessions are kept in the database.
+type Storage struct {
+	Backend     string `yaml:"backend"`
+	DatabaseURL string `yaml:"database_url"`
+	SQLitePath  string `yaml:"sqlite_path"`
+	RedisURL    string `yaml:"redis_url"`
+}
+
+// Session selects the store of sessions: "redis", "database", "inmem" or "token".
+// The default depends on the storage backend. "database" keeps sessions in PostgreSQL
+// or SQLite of the storage backend, and expired ones are removed periodically on PostgreSQL.
+// "token" issues sessions as tokens sealed with TokenKeys instead of keeping them, and
+// keeps signed out ones in the revocation list of the storage backend.
+type Session struct {
+	Store    string   `yaml:"store"`
+	Lifetime Duration `yaml:"lifetime"`
+	// TokenKeys are "<ID>:<32 bytes secret in base64>" separated by commas. The first key seals
+	// new tokens and all keys open them, so that keys are rotated by adding a new key to the head.
+	TokenKeys string `yaml:"token_keys"`
+}
+
+// Cache selects the cache of sessions, users and calendars read on every request:
+// "none", "memory" or "redis". Caches in memory are not invalidated by writes on other servers,
+// so that they may read deleted sessions and changed calendars until TTL passes.
+type Cache struct {
+	Backend string   `yaml:"backend"`
+	TTL     Duration `yaml:"ttl"`
+	// Size is the maximum number of values in each cache in memory.
+	Size int `yaml:"size"`
+}
+
+type Cookie struct {
+	// Secure sends cookies only over HTTPS. It must be disabled only without TLS.
+	Secure bool `yaml:"secure"`
+	// CSRFKey must be shared by all servers behind a load balancer. It is required with Secure.
+	// Without it, a random key is used and tokens are reissued after restart.
+	CSRFKey string `yaml:"csrf_key"`
+}
+
+// Log selects the least level of messages ("info" or "error") and where they are written
+// ("stdout", "stderr" or a path of file).
+type Log struct {
+	Level  string `yaml:"level"`
+	Output string `yaml:"output"`
+}
+
+// Password configures hashing and the policy of new passwords. Hasher is "argon2id" or "bcrypt".
+type Password struct {
+	Hasher      string `yaml:"hasher"`
+	BcryptCost  int    `yaml:"bcrypt_cost"`
+	MinLength   int    `yaml:"min_length"`
+	MaxRepeated int    `yaml:"max_repeated"`
+	BreachedDir string `yaml:"breached_dir"`
+}
+
+// Mail is sent with SMTP server at SMTPAddr. Without it, mails are written to the log.
+type Mail struct {
+	SMTPAddr string `yaml:"smtp_addr"`
+	From     string `yaml:"from"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// OIDC enables login with the OpenID Connect provider at Issuer.
+type OIDC struct {
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	RedirectURL  string `yaml:"redirect_url"`
+}
+
+type Trash struct {
+	RetentionDays int `yaml:"retention_days"`
+}
+
+// Duration is time.Duration written as "720h" in YAML.
+type Duration time.Duration
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Default returns the configuration used without any settings.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:           "8080",
+			RequestTimeout: Duration(30 * time.Second),
+		},
+		Storage: Storage{
+			Backend:    "postgres",
+			SQLitePath: "calendar.db",
+		},
+		Session: Session{
+			Lifetime: Duration(as.DefaultSessionLifetime),
+		},
+		Cache: Cache{
+			Backend: "none",
+			TTL:     Duration(time.Minute),
+			Size:    10000,
+		},
+		Cookie: Cookie{
+			Secure: true,
+		},
+		Log: Log{
+			Level:  "info",
+			Output: "stdout",
+		},
+		Password: Password{
+			Hasher:      "argon2id",
+			BcryptCost:  bcrypt.DefaultCost,
+			MinLength:   as.DefaultPasswordPolicy.MinLength,
+			MaxRepeated: as.DefaultPasswordPolicy.MaxRepeated,
+		},
+		Trash: Trash{
+			RetentionDays: 30,
+		},
+	}
+}
+
+// defaultSessionStore returns the session store kept with the storage backend.
+func (c *Config) defaultSessionStore() string {
+	switch c.Storage.Backend {
+	case "postgres":
+		return "redis"
+	case "sqlite":
+		return "database"
+	default:
+		return "inmem"
+	}
+}
+
+// Validate checks that the configuration is complete and consistent.
+func (c *Config) Validate() error {
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || 65535 < port {
+		return fmt.Errorf("server.port(%v) is invalid", c.Server.Port)
+	}
+	if c.Server.RequestTimeout < 0 {
+		return errors.New("server.request_timeout must not be negative")
+	}
+	if c.Server.BaseURL != "" {
+		if u, err := url.Parse(c.Server.BaseURL); err != nil || !u.IsAbs() {
+			return fmt.Errorf("server.base_url(%v) is invalid", c.Server.BaseURL)
+		}
+	}
+
+	switch c.Storage.Backend {
+	case "postgres":
+		if c.Storage.DatabaseURL == "" {
+			return errors.New("storage.database_url is required by postgres")
+		}
+		// Sessions, attempts, tokens and revocations are kept in Redis unless they are in the database.
+		if c.Storage.RedisURL == "" && c.Session.Store != "database" {
+			return errors.New("storage.redis_url is required by postgres unless session.store is database")
+		}
+	case "sqlite":
+		if c.Storage.SQLitePath == "" {
+			return errors.New("storage.sqlite_path is required by sqlite")
+		}
+	case "inmem":
+	default:
+		return fmt.Errorf("storage.backend(%v) is invalid", c.Storage.Backend)
+	}
+
+	switch c.Session.Store {
+	case "redis":
+		if c.Storage.RedisURL == "" {
+			return errors.New("storage.redis_url is required by redis session store")
+		}
+	case "database":
+		if c.Storage.Backend == "inmem" {
+			return fmt.Errorf("database session store is not supported by %v", c.Storage.Backend)
+		}
+	case "inmem":
+	case "token":
+		keys, err := sessiontoken.ParseKeys(c.Session.TokenKeys)
+		if err != nil {
+			return fmt.Errorf("session.token_keys is invalid: %w", err)
+		}
+		if _, err := sessiontoken.NewSealer(keys); err != nil {
+			return fmt.Errorf("session.token_keys is invalid: %w", err)
+		}
+	default:
+		return fmt.Errorf("session.store(%v) is invalid", c.Session.Store)
+	}
+	if c.Session.Lifetime <= 0 {
+		return errors.New("session.lifetime must be positive")
+	}
+	if c.Cookie.Secure && c.Cookie.CSRFKey == "" {
+		return errors.New("cookie.csrf_key is required by secure cookies")
+	}
+
+	switch c.Cache.Backend {
+	case "none":
+	case "memory":
+		if c.Cache.Size < 1 {
+			return fmt.Errorf("cache.size(%v) is invalid", c.Cache.Size)
+		}
+	case "redis":
+		if c.Storage.RedisURL == "" {
+			return errors.New("storage.redis_url is required by redis cache")
+		}
+	default:
+		return fmt.Errorf("cache.backend(%v) is invalid", c.Cache.Backend)
+	}
+	if c.Cache.TTL <= 0 {
+		return errors.New("cache.ttl must be positive")
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		return err
+	}
+	if c.Log.Output == "" {
+		return errors.New("log.output is required")
+	}
+
+	switch c.Password.Hasher {
+	case "argon2id":
+	case "bcrypt":
+		if c.Password.BcryptCost < bcrypt.MinCost || bcrypt.MaxCost < c.Password.BcryptCost {
+			return fmt.Errorf("password.bcrypt_cost(%v) is invalid", c.Password.BcryptCost)
+		}
+	default:
+		return fmt.Errorf("password.hasher(%v) is invalid", c.Password.Hasher)
+	}
+	if c.Password.MinLength < 1 {
+		return fmt.Errorf("password.min_length(%v) is invalid", c.Password.MinLength)
+	}
+	if c.Password.MaxRepeated < 0 {
+		return fmt.Errorf("password.max_repeated(%v) is invalid", c.Password.MaxRepeated)
+	}
+
+	if c.OIDC.Issuer != "" && (c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "") {
+		return errors.New("oidc.client_id and oidc.redirect_url are required by oidc.issuer")
+	}
+
+	if c.Trash.RetentionDays < 0 {
+		return fmt.Errorf("trash.retention_days(%v) is invalid", c.Trash.RetentionDays)
+	}
+	return nil
+}
+
+// Redacted returns the configuration whose secrets are masked to print it.
+func (c Config) Redacted() Config {
+	c.Storage.DatabaseURL = redactURL(c.Storage.DatabaseURL)
+	c.Storage.RedisURL = redactURL(c.Storage.RedisURL)
+	c.Session.TokenKeys = redact(c.Session.TokenKeys)
+	c.Cookie.CSRFKey = redact(c.Cookie.CSRFKey)
+	c.Mail.Password = redact(c.Mail.Password)
+	c.OIDC.ClientSecret = redact(c.OIDC.ClientSecret)
+	return c
+}
+
+const mask = "xxxxx"
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return mask
+}
+
+// redactURL masks the password in the URL. Unparsable URLs are masked entirely.
+func redactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return mask
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), mask)
+	}
+	return u.String()
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Options are command line options which are not part of the configuration.
+type Options struct {
+	// File is the path of YAML file. It is also set with CONFIG_FILE.
+	File string
+	// PrintConfig prints the configuration instead of starting the server.
+	PrintConfig bool
+	// Args are arguments following the flags, such as subcommands.
+	Args []string
+}
+
+// envs are environment variables overriding the settings of the flags.
+var envs = []struct {
+	env  string
+	flag string
+}{
+	{"PORT", "port"},
+	{"BASE_URL", "base-url"},
+	{"REQUEST_TIMEOUT", "request-timeout"},
+	{"STORAGE", "storage"},
+	{"DATABASE_URL", "database-url"},
+	{"SQLITE_PATH", "sqlite-path"},
+	{"REDIS_URL", "redis-url"},
+	{"SESSION_STORE", "session-store"},
+	{"SESSION_LIFETIME", "session-lifetime"},
+	{"SESSION_TOKEN_KEYS", "session-token-keys"},
+	{"CACHE", "cache"},
+	{"CACHE_TTL", "cache-ttl"},
+	{"CACHE_SIZE", "cache-size"},
+	{"CSRF_KEY", "csrf-key"},
+	{"LOG_LEVEL", "log-level"},
+	{"LOG_OUTPUT", "log-output"},
+	{"PASSWORD_HASHER", "password-hasher"},
+	{"BCRYPT_COST", "bcrypt-cost"},
+	{"PASSWORD_MIN_LENGTH", "password-min-length"},
+	{"PASSWORD_MAX_REPEATED", "password-max-repeated"},
+	{"BREACHED_PASSWORDS_DIR", "breached-passwords-dir"},
+	{"SMTP_ADDR", "smtp-addr"},
+	{"MAIL_FROM", "mail-from"},
+	{"SMTP_USERNAME", "smtp-username"},
+	{"SMTP_PASSWORD", "smtp-password"},
+	{"OIDC_ISSUER", "oidc-issuer"},
+	{"OIDC_CLIENT_ID", "oidc-client-id"},
+	{"OIDC_CLIENT_SECRET", "oidc-client-secret"},
+	{"OIDC_REDIRECT_URL", "oidc-redirect-url"},
+	{"TRASH_RETENTION_DAYS", "trash-retention-days"},
+}
+
+// flagSet returns flags setting the configuration c. Their defaults are current values of c.
+func flagSet(c *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	fs.StringVar(&c.Server.Port, "port", c.Server.Port, "port to listen on")
+	fs.StringVar(&c.Server.BaseURL, "base-url", c.Server.BaseURL, "URL of the application used in mails")
+	fs.DurationVar((*time.Duration)(&c.Server.RequestTimeout), "request-timeout", time.Duration(c.Server.RequestTimeout), "deadline of API requests (0 means no deadline)")
+	fs.BoolVar(&c.Server.TrustProxy, "trust-proxy", c.Server.TrustProxy, "use X-Forwarded-For header as client IP")
+	fs.StringVar(&c.Storage.Backend, "storage", c.Storage.Backend, "storage backend: postgres, sqlite or inmem")
+	fs.StringVar(&c.Storage.DatabaseURL, "database-url", c.Storage.DatabaseURL, "URL of PostgreSQL")
+	fs.StringVar(&c.Storage.SQLitePath, "sqlite-path", c.Storage.SQLitePath, "path of SQLite database")
+	fs.StringVar(&c.Storage.RedisURL, "redis-url", c.Storage.RedisURL, "URL of Redis")
+	fs.StringVar(&c.Session.Store, "session-store", c.Session.Store, "session store: redis, database, inmem or token (default depends on storage)")
+	fs.DurationVar((*time.Duration)(&c.Session.Lifetime), "session-lifetime", time.Duration(c.Session.Lifetime), "lifetime of sessions")
+	fs.StringVar(&c.Session.TokenKeys, "session-token-keys", c.Session.TokenKeys, "keys of session tokens: <ID>:<secret in base64> separated by commas")
+	fs.StringVar(&c.Cache.Backend, "cache", c.Cache.Backend, "cache of sessions, users and calendars: none, memory or redis")
+	fs.DurationVar((*time.Duration)(&c.Cache.TTL), "cache-ttl", time.Duration(c.Cache.TTL), "TTL of cached values")
+	fs.IntVar(&c.Cache.Size, "cache-size", c.Cache.Size, "maximum number of values in each cache in memory")
+	fs.BoolVar(&c.Cookie.Secure, "cookie-secure", c.Cookie.Secure, "send cookies only over HTTPS")
+	fs.StringVar(&c.Cookie.CSRFKey, "csrf-key", c.Cookie.CSRFKey, "key of CSRF tokens shared by all servers")
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "least level of logs: info or error")
+	fs.StringVar(&c.Log.Output, "log-output", c.Log.Output, "output of logs: stdout, stderr or path of file")
+	fs.StringVar(&c.Password.Hasher, "password-hasher", c.Password.Hasher, "hasher of new passwords: argon2id or bcrypt")
+	fs.IntVar(&c.Password.BcryptCost, "bcrypt-cost", c.Password.BcryptCost, "cost of bcrypt")
+	fs.IntVar(&c.Password.MinLength, "password-min-length", c.Password.MinLength, "minimum length of passwords")
+	fs.IntVar(&c.Password.MaxRepeated, "password-max-repeated", c.Password.MaxRepeated, "maximum number of the same character in a row (0 means no limit)")
+	fs.StringVar(&c.Password.BreachedDir, "breached-passwords-dir", c.Password.BreachedDir, "directory of breached password hashes")
+	fs.StringVar(&c.Mail.SMTPAddr, "smtp-addr", c.Mail.SMTPAddr, "address of SMTP server (mails are logged without it)")
+	fs.StringVar(&c.Mail.From, "mail-from", c.Mail.From, "sender of mails")
+	fs.StringVar(&c.Mail.Username, "smtp-username", c.Mail.Username, "user name of SMTP server")
+	fs.StringVar(&c.Mail.Password, "smtp-password", c.Mail.Password, "password of SMTP server")
+	fs.StringVar(&c.OIDC.Issuer, "oidc-issuer", c.OIDC.Issuer, "issuer of OpenID Connect provider")
+	fs.StringVar(&c.OIDC.ClientID, "oidc-client-id", c.OIDC.ClientID, "client ID of OpenID Connect")
+	fs.StringVar(&c.OIDC.ClientSecret, "oidc-client-secret", c.OIDC.ClientSecret, "client secret of OpenID Connect")
+	fs.StringVar(&c.OIDC.RedirectURL, "oidc-redirect-url", c.OIDC.RedirectURL, "redirect URL of OpenID Connect")
+	fs.IntVar(&c.Trash.RetentionDays, "trash-retention-days", c.Trash.RetentionDays, "days calendars and plans are kept in the trash")
+	return fs
+}
+
+// Load reads the configuration from the file, environment variables got with getenv and
+// flags in args, and validates it.
+func Load(args []string, getenv func(string) string) (Config, Options, error) {
+	// Flags are parsed first to find the file. They are applied again after it is read.
+	c := Default()
+	opts := Options{}
+	fs := flagSet(&c)
+	fs.StringVar(&opts.File, "config", getenv("CONFIG_FILE"), "path of YAML configuration file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the configuration and exit")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, Options{}, err
+	}
+	opts.Args = fs.Args()
+	set := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+
+	c = Default()
+	if opts.File != "" {
+		if err := readFile(&c, opts.File); err != nil {
+			return Config{}, Options{}, err
+		}
+	}
+
+	fs = flagSet(&c)
+	for _, e := range envs {
+		v := getenv(e.env)
+		if v == "" {
+			continue
+		}
+		if err := fs.Set(e.flag, v); err != nil {
+			return Config{}, Options{}, fmt.Errorf("%v is invalid: %w", e.env, err)
+		}
+	}
+	// They have been enabled with any value.
+	if getenv("SSL_DISABLE") != "" {
+		c.Cookie.Secure = false
+	}
+	if getenv("TRUST_PROXY") != "" {
+		c.Server.TrustProxy = true
+	}
+
+	for name, v := range set {
+		if fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, v); err != nil {
+			return Config{}, Options{}, err
+		}
+	}
+
+	if c.Session.Store == "" {
+		c.Session.Store = c.defaultSessionStore()
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, Options{}, err
+	}
+	return c, opts, nil
+}
+
+// readFile reads the YAML file into c. Unknown keys are errors to find typos.
+func readFile(c *Config, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalStrict(b, c); err != nil {
+		return fmt.Errorf("failed to read %v: %w", path, err)
+	}
+	return nil
+}
+
+// Print returns the configuration with masked secrets in YAML.
+func (c Config) Print() ([]byte, error) {
+	return yaml.Marshal(c.Redacted())
+}
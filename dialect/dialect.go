@@ -0,0 +1,118 @@
+// Package dialect adapts queries written for PostgreSQL to other databases,
+// so that repogitories share their queries between databases.
+package dialect
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect is the SQL of a database.
+type Dialect struct {
+	rebind        func(query string) string
+	isDuplication func(err error) bool
+}
+
+// Rebind rewrites the query written for PostgreSQL for the database.
+func (d Dialect) Rebind(query string) string {
+	return d.rebind(query)
+}
+
+// IsDuplication tells whether err is caused by a primary key or an unique constraint.
+func (d Dialect) IsDuplication(err error) bool {
+	return d.isDuplication(err)
+}
+
+// PostgreSQL runs queries as they are.
+var PostgreSQL = Dialect{
+	rebind: func(query string) string {
+		return query
+	},
+	isDuplication: func(err error) bool {
+		var pqErr *pq.Error
+		return errors.As(err, &pqErr) && pqErr.Code == "23505"
+	},
+}
+
+// sqliteTables are names of tables on SQLite keyed by the names on PostgreSQL. SQLite does not
+// have schemas, so that they are prefixes of table names instead.
+var sqliteTables = map[string]string{
+	"auth.users":                  "auth_users",
+	"auth.identities":             "auth_identities",
+	"auth.sessions":               "auth_sessions",
+	"auth.attempts":               "auth_attempts",
+	"auth.tokens":                 "auth_tokens",
+	"auth.revocations":            "auth_revocations",
+	"calendar.users":              "calendar_users",
+	"calendar.orgs":               "calendar_orgs",
+	"calendar.org_members":        "calendar_org_members",
+	"calendar.calendars":          "calendar_calendars",
+	"calendar.profiles":           "calendar_profiles",
+	"calendar.groups":             "calendar_groups",
+	"calendar.group_members":      "calendar_group_members",
+	"calendar.calendar_shares":    "calendar_calendar_shares",
+	"calendar.calendar_transfers": "calendar_calendar_transfers",
+	"calendar.plans":              "calendar_plans",
+	"calendar.plan_shares":        "calendar_plan_shares",
+	"calendar.revisions":          "calendar_revisions",
+}
+
+var (
+	literal     = regexp.MustCompile(`'(?:[^']|'')*'`)
+	placeholder = regexp.MustCompile(`\$(\d+)`)
+	table       = regexp.MustCompile(`\b\w+\.\w+\b`)
+	ctid        = regexp.MustCompile(`\bctid\b`)
+)
+
+// SQLite names tables by sqliteTables. Placeholders are "?N" instead of "$N", and rowid is used
+// instead of ctid. Only names of known tables are renamed, and string literals are kept as they are.
+// Types of errors are defined only by the driver built with cgo, which the driver requires anyway.
+var SQLite = Dialect{
+	rebind: func(query string) string {
+		return outsideLiterals(query, func(s string) string {
+			s = placeholder.ReplaceAllString(s, "?$1")
+			s = table.ReplaceAllStringFunc(s, func(name string) string {
+				if t, ok := sqliteTables[name]; ok {
+					return t
+				}
+				return name
+			})
+			return ctid.ReplaceAllString(s, "rowid")
+		})
+	},
+	isDuplication: func(err error) bool {
+		var sqliteErr sqlite3.Error
+		return errors.As(err, &sqliteErr) &&
+			(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+	},
+}
+
+// outsideLiterals rewrites parts of the query out of string literals with f.
+func outsideLiterals(query string, f func(s string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range literal.FindAllStringIndex(query, -1) {
+		b.WriteString(f(query[last:loc[0]]))
+		b.WriteString(query[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(f(query[last:]))
+	return b.String()
+}
+
+// InList returns placeholders of the values numbered from first for IN operator and the arguments
+// bound to them. Arrays are not used because SQLite does not have them. values must not be empty.
+func InList(first int, values []string) (string, []interface{}) {
+	placeholders := make([]string, len(values))
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		placeholders[i] = fmt.Sprintf("$%d", first+i)
+		args[i] = v
+	}
+	return strings.Join(placeholders, ", "), args
+}
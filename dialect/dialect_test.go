@@ -0,0 +1,66 @@
+package dialect
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/x-color/calendar/migration"
+)
+
+func TestSQLite_Rebind(t *testing.T) {
+	testcases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "tables and placeholders",
+			query: "SELECT id FROM calendar.users WHERE id = $1",
+			want:  "SELECT id FROM calendar_users WHERE id = ?1",
+		},
+		{
+			name:  "aliases and ctid",
+			query: "SELECT calendar.id FROM calendar.calendars calendar ORDER BY calendar.ctid",
+			want:  "SELECT calendar.id FROM calendar_calendars calendar ORDER BY calendar.rowid",
+		},
+		{
+			name:  "columns qualified by tables",
+			query: "SELECT auth.users.id FROM auth.users",
+			want:  "SELECT auth_users.id FROM auth_users",
+		},
+		{
+			name:  "string literals",
+			query: "SELECT id FROM auth.users WHERE name = 'auth.users $1' OR name LIKE $2 ESCAPE '\\'",
+			want:  "SELECT id FROM auth_users WHERE name = 'auth.users $1' OR name LIKE ?2 ESCAPE '\\'",
+		},
+		{
+			name:  "quotes in string literals",
+			query: "SELECT 'it''s calendar.users', $1",
+			want:  "SELECT 'it''s calendar.users', ?1",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SQLite.Rebind(tc.query); got != tc.want {
+				t.Errorf("want %q but %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSQLiteTables(t *testing.T) {
+	names := map[string]bool{}
+	for _, name := range sqliteTables {
+		names[name] = true
+	}
+
+	create := regexp.MustCompile(`CREATE TABLE (\w+)`)
+	for _, m := range migration.SQLite {
+		for _, match := range create.FindAllStringSubmatch(m.Up, -1) {
+			if !names[match[1]] {
+				t.Errorf("table(%v) of migration(%v) is not in tables", match[1], m.Version)
+			}
+		}
+	}
+}
@@ -8,6 +8,8 @@ require (
 	github.com/google/uuid v1.1.1
 	github.com/gorilla/mux v1.7.4
 	github.com/lib/pq v1.7.0
+	github.com/mattn/go-sqlite3 v1.14.0
 	github.com/x-color/slice v0.0.0-20200509103501-1771bd87db16
 	golang.org/x/crypto v0.0.0-20200423211502-4bdfaf469ed5
+	gopkg.in/yaml.v2 v2.3.0
 )
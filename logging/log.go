@@ -1,24 +1,50 @@
 package logging
 
 import (
+	"fmt"
 	"io"
 
 	"log"
 )
 
+// Level is the least severity of messages written by loggers.
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelError
+)
+
+// ParseLevel returns the level named "info" or "error".
+func ParseLevel(name string) (Level, error) {
+	switch name {
+	case "info":
+		return LevelInfo, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("log level(%v) is invalid", name)
+	}
+}
+
 type logger struct {
-	l   *log.Logger
-	uid string
+	l     *log.Logger
+	uid   string
+	level Level
 }
 
 func (l *logger) Uniq(id string) Logger {
 	return &logger{
-		l:   l.l,
-		uid: id,
+		l:     l.l,
+		uid:   id,
+		level: l.level,
 	}
 }
 
 func (l *logger) Info(msg string) {
+	if l.level > LevelInfo {
+		return
+	}
 	l.l.Println("[INFO]", l.uid, msg)
 }
 
@@ -27,6 +53,11 @@ func (l *logger) Error(msg string) {
 }
 
 func NewLogger(output io.Writer) logger {
+	return NewLevelLogger(output, LevelInfo)
+}
+
+// NewLevelLogger returns logger writing messages at level or above.
+func NewLevelLogger(output io.Writer, level Level) logger {
 	l := log.New(output, "", log.Ldate|log.Ltime)
-	return logger{l, ""}
+	return logger{l, "", level}
 }
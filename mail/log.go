@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+type logMailer struct {
+	m    sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// NewLogMailer returns mailer writing mails to w instead of sending them.
+// It is for development without SMTP server.
+func NewLogMailer(w io.Writer, from string) logMailer {
+	return logMailer{
+		w:    w,
+		from: from,
+	}
+}
+
+func (m *logMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	msg := message(m.from, to, subject, body)
+	if _, err := m.w.Write(append(msg, "\r\n\r\n"...)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -2,134 +2,377 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/x-color/calendar/app/rest"
+	ase "github.com/x-color/calendar/app/rest/auth"
+	"github.com/x-color/calendar/app/rest/middlewares"
+	"github.com/x-color/calendar/auth/oidc"
+	"github.com/x-color/calendar/auth/pwned"
+	authCached "github.com/x-color/calendar/auth/repogitory/cached"
+	authInmem "github.com/x-color/calendar/auth/repogitory/inmem"
 	authStore "github.com/x-color/calendar/auth/repogitory/store"
 	as "github.com/x-color/calendar/auth/service"
+	"github.com/x-color/calendar/auth/sessiontoken"
+	"github.com/x-color/calendar/cache"
+	calCached "github.com/x-color/calendar/calendar/repogitory/cached"
+	calInmem "github.com/x-color/calendar/calendar/repogitory/inmem"
 	calStore "github.com/x-color/calendar/calendar/repogitory/store"
 	cs "github.com/x-color/calendar/calendar/service"
+	"github.com/x-color/calendar/clock"
+	"github.com/x-color/calendar/config"
 	"github.com/x-color/calendar/logging"
+	"github.com/x-color/calendar/mail"
+	"github.com/x-color/calendar/migration"
+	cctx "github.com/x-color/calendar/model/ctx"
 )
 
 func main() {
-	redisURL, err := url.ParseRequestURI(os.Getenv("REDIS_URL"))
+	cfg, opts, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		log.Fatalln(err)
+	}
+	if opts.PrintConfig {
+		b, err := cfg.Print()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		os.Stdout.Write(b)
+		return
+	}
+	if len(opts.Args) > 0 && opts.Args[0] == "migrate" {
+		if err := migrate(cfg.Storage, opts.Args[1:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	var ar as.Repogitory
+	var cr cs.Repogitory
+	var rdb *redis.Client
+	// expiring is the session store in the database whose expired sessions are removed periodically.
+	var expiring expiringSessions
+	if cfg.Storage.RedisURL != "" {
+		rdb, err = openRedis(cfg.Storage.RedisURL)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer rdb.Close()
+	}
+	switch cfg.Storage.Backend {
+	case "inmem":
+		ar, cr = authInmem.NewRepogitory(), calInmem.NewRepogitory()
+	default:
+		db, driver, err := openDB(cfg.Storage)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer db.Close()
+		if err = db.Ping(); err != nil {
+			log.Fatalln(err)
+		}
+
+		m, err := migration.NewMigrator(db, driver)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if _, err := m.Up(context.Background()); err != nil {
+			log.Fatalln(err)
+		}
+
+		if driver == "sqlite3" {
+			authRepo := authStore.NewSQLiteRepogitory(db)
+			calRepo := calStore.NewSQLiteRepogitory(db)
+			ar, cr = &authRepo, &calRepo
+			if cfg.Session.Store == "database" {
+				// Sessions of SQLite are kept in the database as well.
+				expiring = ar.Session().(expiringSessions)
+			}
+		} else if cfg.Session.Store == "database" {
+			// All data are kept in PostgreSQL, so that Redis is not required.
+			authRepo := authStore.NewDatabaseRepogitory(db)
+			calRepo := calStore.NewRepogitory(db)
+			ar, cr = &authRepo, &calRepo
+			expiring = ar.Session().(expiringSessions)
+		} else {
+			authRepo := authStore.NewRepogitory(db, rdb)
+			calRepo := calStore.NewRepogitory(db)
+			ar, cr = &authRepo, &calRepo
+		}
+	}
+	ar = withSessionStore(ar, cfg.Session.Store, rdb)
+
+	out, err := openLog(cfg.Log.Output)
 	if err != nil {
 		log.Fatalln(err)
 	}
-	pwd, ok := redisURL.User.Password()
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	l := logging.NewLevelLogger(out, level)
+	if expiring != nil {
+		go deleteExpiredSessions(expiring, &l)
+	}
+
+	if cfg.Cache.Backend != "none" {
+		sessions := newCache(cfg.Cache, rdb, "cache:sessions:")
+		users := newCache(cfg.Cache, rdb, "cache:users:")
+		calendars := newCache(cfg.Cache, rdb, "cache:calendars:")
+		sessionRepo := authCached.NewSessionRepogitory(ar.Session(), sessions)
+		userRepo := authCached.NewUserRepogitory(ar.User(), users)
+		calRepo := calCached.NewRepogitory(cr, calendars)
+		ar, cr = &userStore{&sessionStore{ar, &sessionRepo}, &userRepo}, &calRepo
+		go logCacheStats(&l, map[string]cache.Cache{"sessions": sessions, "users": users, "calendars": calendars})
+	}
+
+	if cfg.Session.Store == "token" {
+		revocations := authCached.NewRevocationRepogitory(ar.Revocation(), revocationRefresh, clock.Real)
+		ar = &revocationStore{ar, &revocations}
+	}
+
+	a := as.NewService(ar, &l)
+	if cfg.Password.Hasher == "bcrypt" {
+		a.SetPasswordHasher(as.NewBcryptHasher(cfg.Password.BcryptCost))
+	}
+	policy := as.DefaultPasswordPolicy
+	policy.MinLength = cfg.Password.MinLength
+	policy.MaxRepeated = cfg.Password.MaxRepeated
+	a.SetPasswordPolicy(policy)
+	if dir := cfg.Password.BreachedDir; dir != "" {
+		a.SetBreachedPasswords(pwned.NewRangeDir(dir))
+	}
+	a.SetSessionLifetime(time.Duration(cfg.Session.Lifetime))
+	if cfg.Session.Store == "token" {
+		keys, _ := sessiontoken.ParseKeys(cfg.Session.TokenKeys)
+		sealer, err := sessiontoken.NewSealer(keys)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		a.SetSessionTokens(sealer)
+	}
+	if addr := cfg.Mail.SMTPAddr; addr != "" {
+		m := mail.NewSMTPMailer(addr, cfg.Mail.From, cfg.Mail.Username, cfg.Mail.Password)
+		a.SetMailer(&m, cfg.Server.BaseURL)
+	} else {
+		m := mail.NewLogMailer(out, cfg.Mail.From)
+		a.SetMailer(&m, cfg.Server.BaseURL)
+	}
+	if issuer := cfg.OIDC.Issuer; issuer != "" {
+		idp, err := oidc.NewProvider(
+			context.Background(),
+			issuer,
+			cfg.OIDC.ClientID,
+			cfg.OIDC.ClientSecret,
+			cfg.OIDC.RedirectURL,
+		)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		a.SetIdentityProvider(idp)
+	}
+	c := cs.NewService(cr, &l)
+	c.SetUserVerifier(&a)
+	c.SetUserNamer(&a)
+	a.SetUserRegistrar(&c)
+	go purgeTrash(c, time.Duration(cfg.Trash.RetentionDays)*24*time.Hour)
+
+	serverOpts := rest.Options{
+		Port:           cfg.Server.Port,
+		RequestTimeout: time.Duration(cfg.Server.RequestTimeout),
+		Auth: ase.Options{
+			Secure:     cfg.Cookie.Secure,
+			TrustProxy: cfg.Server.TrustProxy,
+		},
+	}
+	err = rest.StartServer(a, c, newCSRF(cfg.Cookie), &l, serverOpts)
+	log.Fatalln(err)
+}
+
+// revocationRefresh is how often the revocation list of session tokens is reloaded. Tokens revoked
+// on other servers are accepted until then.
+const revocationRefresh = 10 * time.Second
+
+// sqliteOptions are options of SQLite databases. Foreign keys are disabled by default.
+// Transactions take the lock of writing first and wait for others instead of failing.
+const sqliteOptions = "_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+
+// openDB opens the database of the storage backend and returns it with the name of its driver.
+func openDB(cfg config.Storage) (*sql.DB, string, error) {
+	switch cfg.Backend {
+	case "postgres":
+		db, err := sql.Open("postgres", cfg.DatabaseURL)
+		return db, "postgres", err
+	case "sqlite":
+		db, err := sql.Open("sqlite3", "file:"+cfg.SQLitePath+"?"+sqliteOptions)
+		return db, "sqlite3", err
+	default:
+		return nil, "", fmt.Errorf("storage backend(%v) does not have a database", cfg.Backend)
+	}
+}
+
+// openRedis connects to Redis at redisURL.
+func openRedis(redisURL string) (*redis.Client, error) {
+	u, err := url.ParseRequestURI(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	pwd, ok := u.User.Password()
 	if !ok {
-		log.Fatalln("REDIS_URL does not have password")
+		return nil, errors.New("URL of Redis does not have password")
 	}
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     redisURL.Host,
+		Addr:     u.Host,
 		Password: pwd,
 	})
-	defer rdb.Close()
 	if err := rdb.Ping(context.Background()).Err(); err != nil {
-		log.Fatalln(err)
+		rdb.Close()
+		return nil, err
 	}
+	return rdb, nil
+}
 
-	pdb, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
-	if err != nil {
-		log.Fatalln(err)
+// sessionStore replaces sessions of the auth repogitory with another store.
+type sessionStore struct {
+	as.Repogitory
+	sessions as.SessionRepogitory
+}
+
+func (r *sessionStore) Session() as.SessionRepogitory {
+	return r.sessions
+}
+
+// Transaction keeps the sessions replaced in the transaction.
+func (r *sessionStore) Transaction(ctx context.Context, f func(as.Repogitory) error) error {
+	return r.Repogitory.Transaction(ctx, func(repo as.Repogitory) error {
+		return f(&sessionStore{repo, r.sessions})
+	})
+}
+
+// userStore replaces users of the auth repogitory with another store.
+// Transactions use users of the repogitory because the store is not in them.
+type userStore struct {
+	as.Repogitory
+	users as.UserRepogitory
+}
+
+func (r *userStore) User() as.UserRepogitory {
+	return r.users
+}
+
+// revocationStore replaces revocations of the auth repogitory with another store.
+type revocationStore struct {
+	as.Repogitory
+	revocations as.RevocationRepogitory
+}
+
+func (r *revocationStore) Revocation() as.RevocationRepogitory {
+	return r.revocations
+}
+
+// Transaction keeps the revocations replaced in the transaction.
+func (r *revocationStore) Transaction(ctx context.Context, f func(as.Repogitory) error) error {
+	return r.Repogitory.Transaction(ctx, func(repo as.Repogitory) error {
+		return f(&revocationStore{repo, r.revocations})
+	})
+}
+
+// withSessionStore returns repo keeping sessions in store. "database" keeps them in the database
+// of repo, which is already set up with the storage backend. "token" does not keep sessions.
+func withSessionStore(repo as.Repogitory, store string, rdb *redis.Client) as.Repogitory {
+	switch store {
+	case "redis":
+		r := authStore.NewRepogitory(nil, rdb)
+		return &sessionStore{repo, r.Session()}
+	case "inmem":
+		r := authInmem.NewRepogitory()
+		return &sessionStore{repo, r.Session()}
+	default:
+		return repo
 	}
-	defer pdb.Close()
-	if err = pdb.Ping(); err != nil {
-		log.Fatalln(err)
+}
+
+// openLog opens output of logs: "stdout", "stderr" or a path of file appended to.
+func openLog(output string) (io.Writer, error) {
+	switch output {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		return os.OpenFile(output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
 	}
+}
 
-	if err := createTables(pdb); err != nil {
-		log.Fatalln(err)
+// purgeTrash removes calendars and plans which have been in the trash longer than retention every hour.
+func purgeTrash(c cs.Service, retention time.Duration) {
+	for {
+		ctx := context.WithValue(context.Background(), cctx.ReqIDKey, uuid.New().String())
+		c.PurgeTrash(ctx, time.Now().Add(-retention))
+		time.Sleep(time.Hour)
 	}
+}
 
-	l := logging.NewLogger(os.Stdout)
-	ar := authStore.NewRepogitory(pdb, rdb)
-	cr := calStore.NewRepogitory(pdb)
-	a := as.NewService(&ar, &l)
-	c := cs.NewService(&cr, &l)
-	rest.StartServer(a, c, &l, os.Getenv("PORT"))
+type expiringSessions interface {
+	DeleteExpired(ctx context.Context) (int, error)
 }
 
-func createTables(db *sql.DB) error {
-	_, err := db.Exec("CREATE SCHEMA IF NOT EXISTS auth")
-	if err != nil {
-		return err
+// deleteExpiredSessions removes expired sessions every hour. Sessions in Redis expire by themselves.
+func deleteExpiredSessions(sessions expiringSessions, l logging.Logger) {
+	for {
+		n, err := sessions.DeleteExpired(context.Background())
+		if err != nil {
+			l.Error(strings.Replace(err.Error(), "\n", "%NL", -1))
+		} else {
+			l.Info(fmt.Sprintf("Delete %v expired sessions", n))
+		}
+		time.Sleep(time.Hour)
 	}
-	_, err = db.Exec("CREATE SCHEMA IF NOT EXISTS calendar")
-	if err != nil {
-		return err
+}
+
+// newCache returns the cache of cfg. Keys in Redis have the prefix.
+func newCache(cfg config.Cache, rdb *redis.Client, prefix string) cache.Cache {
+	if cfg.Backend == "redis" {
+		c := cache.NewRedis(rdb, prefix, time.Duration(cfg.TTL))
+		return &c
 	}
+	c := cache.NewLRU(cfg.Size, time.Duration(cfg.TTL))
+	return &c
+}
 
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS auth.users (
-		id CHAR(36) PRIMARY KEY,
-		name VARCHAR(64) NOT NULL,
-		password VARCHAR(72) NOT NULL
-	)`)
-	if err != nil {
-		return err
+// logCacheStats logs hits and misses of the caches every hour.
+func logCacheStats(l logging.Logger, caches map[string]cache.Cache) {
+	for {
+		time.Sleep(time.Hour)
+		for name, c := range caches {
+			stats := c.Stats()
+			l.Info(fmt.Sprintf("cache(%v) hits: %v, misses: %v", name, stats.Hits, stats.Misses))
+		}
 	}
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS calendar.users (
-		id CHAR(36) PRIMARY KEY,
-		FOREIGN KEY (id) REFERENCES auth.users(id) ON DELETE CASCADE
-	)`)
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS calendar.calendars (
-		id CHAR(36) PRIMARY KEY,
-		userid CHAR(36),
-		name NAME NOT NULL,
-		color VARCHAR(20) NOT NULL,
-		FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE
-	)`)
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS calendar.calendar_shares (
-		userid CHAR(36),
-		calendarid CHAR(36),
-		PRIMARY KEY(userid, calendarid),
-		FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE,
-		FOREIGN KEY (calendarid) REFERENCES calendar.calendars(id) ON DELETE CASCADE
-	)`)
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS calendar.plans (
-		id CHAR(36) PRIMARY KEY,
-		userid CHAR(36),
-		calendarid CHAR(36),
-		name NAME NOT NULL,
-		memo VARCHAR(400),
-		color VARCHAR(20) NOT NULL,
-		private BOOLEAN NOT NULL,
-		isallday BOOLEAN NOT NULL,
-		begintime BIGINT NOT NULL,
-		endtime BIGINT NOT NULL,
-		FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE,
-		FOREIGN KEY (calendarid) REFERENCES calendar.calendars(id) ON DELETE CASCADE
-	)`)
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS calendar.plan_shares (
-		calendarid CHAR(36),
-		planid CHAR(36),
-		PRIMARY KEY(calendarid, planid),
-		FOREIGN KEY (calendarid) REFERENCES calendar.calendars(id) ON DELETE CASCADE,
-		FOREIGN KEY (planid) REFERENCES calendar.plans(id) ON DELETE CASCADE
-	)`)
-	return err
+}
+
+// newCSRF returns CSRF protection with the key of cfg. All servers behind a load balancer
+// must share it. Without it, which is allowed only without secure cookies, a random key is used.
+func newCSRF(cfg config.Cookie) middlewares.CSRF {
+	key := []byte(cfg.CSRFKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	return middlewares.NewCSRF(key, cfg.Secure)
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/x-color/calendar/config"
+	"github.com/x-color/calendar/migration"
+)
+
+const migrateUsage = `usage: calendar [flags] migrate [command]
+
+commands:
+  up                    apply all pending migrations (default)
+  down [-baseline] [n]  revert the latest n migrations (default 1). The first migration
+                        drops all data and is reverted only with -baseline
+  status                show applied and pending migrations`
+
+// migrate runs the migrate subcommand on the database of the storage backend.
+func migrate(cfg config.Storage, args []string) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	n := 1
+	baseline := false
+	switch cmd {
+	case "up", "status":
+		if len(args) > 1 {
+			return errors.New(migrateUsage)
+		}
+	case "down":
+		fs := flag.NewFlagSet("down", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.BoolVar(&baseline, "baseline", false, "")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() > 1 {
+			return errors.New(migrateUsage)
+		}
+		if fs.NArg() == 1 {
+			var err error
+			n, err = strconv.Atoi(fs.Arg(0))
+			if err != nil || n < 1 {
+				return errors.New(migrateUsage)
+			}
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	db, driver, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m, err := migration.NewMigrator(db, driver)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch cmd {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mg := range applied {
+			fmt.Printf("applied %v %v\n", mg.Version, mg.Name)
+		}
+		return err
+	case "down":
+		reverted, err := m.Down(ctx, n, baseline)
+		for _, mg := range reverted {
+			fmt.Printf("reverted %v %v\n", mg.Version, mg.Name)
+		}
+		return err
+	default:
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			state := "pending"
+			if s.Applied {
+				state = "applied at " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%v %v: %v\n", s.Migration.Version, s.Migration.Name, state)
+		}
+		return nil
+	}
+}
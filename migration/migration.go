@@ -0,0 +1,219 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// lockID is the key of the advisory lock held while migrating. It is "calendar" in ASCII.
+// Servers started at the same time wait for the first one instead of applying migrations twice.
+const lockID int64 = 0x63616c656e646172
+
+// Migration is a version of the schema. Up applies it and Down reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// Baseline is set to the first migration, which makes the schemas. Its down drops all data.
+	Baseline bool
+}
+
+// ErrBaseline is returned when the baseline migration is reverted without being allowed.
+var ErrBaseline = errors.New("the baseline migration drops all data and is reverted only when it is allowed")
+
+// Status tells whether the migration is applied.
+type Status struct {
+	Migration Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type migrator struct {
+	db           *sql.DB
+	migrations   []Migration
+	advisoryLock bool
+}
+
+// NewMigrator returns migrator applying migrations for the driver ("postgres" or "sqlite3")
+// in order of their versions.
+func NewMigrator(db *sql.DB, driver string) (migrator, error) {
+	m := migrator{db: db}
+	switch driver {
+	case "postgres":
+		m.migrations = Postgres
+		m.advisoryLock = true
+	case "sqlite3":
+		// SQLite locks the whole database while migrations are applied in transactions.
+		m.migrations = SQLite
+	default:
+		return migrator{}, fmt.Errorf("migrations for driver(%v) are not supported", driver)
+	}
+	if err := validate(m.migrations); err != nil {
+		return migrator{}, err
+	}
+	return m, nil
+}
+
+// validate checks that versions of migrations are positive and ascending.
+func validate(migrations []Migration) error {
+	prev := 0
+	for i, m := range migrations {
+		if m.Baseline && i != 0 {
+			return fmt.Errorf("migration(%v) is not first but baseline", m.Version)
+		}
+		if m.Version <= prev {
+			return fmt.Errorf("version of migration(%v) must be greater than %v", m.Name, prev)
+		}
+		if m.Up == "" || m.Down == "" {
+			return fmt.Errorf("migration(%v) does not have up or down", m.Version)
+		}
+		prev = m.Version
+	}
+	return nil
+}
+
+// Up applies all pending migrations and returns them.
+func (m *migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied := []Migration{}
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mg := range m.migrations {
+			if _, ok := versions[mg.Version]; ok {
+				continue
+			}
+			err := apply(ctx, conn, mg.Up,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+				mg.Version, mg.Name, time.Now().Unix(),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to apply migration(%v): %w", mg.Version, err)
+			}
+			applied = append(applied, mg)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest n applied migrations and returns them. It stops with ErrBaseline
+// before the baseline migration unless baseline is true.
+func (m *migrator) Down(ctx context.Context, n int, baseline bool) ([]Migration, error) {
+	reverted := []Migration{}
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+			mg := m.migrations[i]
+			if _, ok := versions[mg.Version]; !ok {
+				continue
+			}
+			if mg.Baseline && !baseline {
+				return ErrBaseline
+			}
+			err := apply(ctx, conn, mg.Down,
+				"DELETE FROM schema_migrations WHERE version = $1",
+				mg.Version,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to revert migration(%v): %w", mg.Version, err)
+			}
+			reverted = append(reverted, mg)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns states of all migrations in order of their versions.
+func (m *migrator) Status(ctx context.Context) ([]Status, error) {
+	status := make([]Status, len(m.migrations))
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i, mg := range m.migrations {
+			status[i].Migration = mg
+			if at, ok := versions[mg.Version]; ok {
+				status[i].Applied = true
+				status[i].AppliedAt = time.Unix(at, 0)
+			}
+		}
+		return nil
+	})
+	return status, err
+}
+
+// locked runs f on a connection holding the advisory lock if the database needs it.
+func (m *migrator) locked(ctx context.Context, f func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.advisoryLock {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+	}
+
+	const query = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at BIGINT NOT NULL
+	)`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	return f(conn)
+}
+
+// appliedVersions returns versions of applied migrations and when they are applied.
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]int64, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int]int64{}
+	for rows.Next() {
+		var version int
+		var at int64
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		versions[version] = at
+	}
+	return versions, rows.Err()
+}
+
+// apply runs the migration and updates schema_migrations with query in a transaction.
+func apply(ctx context.Context, conn *sql.Conn, migration, query string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,131 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestValidate(t *testing.T) {
+	testcases := []struct {
+		name       string
+		migrations []Migration
+		valid      bool
+	}{
+		{
+			name:       "migrations on postgres",
+			migrations: Postgres,
+			valid:      true,
+		},
+		{
+			name:       "migrations on sqlite",
+			migrations: SQLite,
+			valid:      true,
+		},
+		{
+			name: "version is not ascending",
+			migrations: []Migration{
+				{Version: 2, Name: "second", Up: "SELECT 1", Down: "SELECT 1"},
+				{Version: 1, Name: "first", Up: "SELECT 1", Down: "SELECT 1"},
+			},
+			valid: false,
+		},
+		{
+			name: "version is duplicated",
+			migrations: []Migration{
+				{Version: 1, Name: "first", Up: "SELECT 1", Down: "SELECT 1"},
+				{Version: 1, Name: "second", Up: "SELECT 1", Down: "SELECT 1"},
+			},
+			valid: false,
+		},
+		{
+			name: "baseline is not first",
+			migrations: []Migration{
+				{Version: 1, Name: "first", Up: "SELECT 1", Down: "SELECT 1", Baseline: true},
+				{Version: 2, Name: "second", Up: "SELECT 1", Down: "SELECT 1", Baseline: true},
+			},
+			valid: false,
+		},
+		{
+			name: "down is empty",
+			migrations: []Migration{
+				{Version: 1, Name: "first", Up: "SELECT 1"},
+			},
+			valid: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validate(tc.migrations)
+			if tc.valid && err != nil {
+				t.Errorf("want valid but %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("want invalid but valid")
+			}
+		})
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	dir, err := ioutil.TempDir("", "calendar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(dir, "calendar.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.RemoveAll(dir)
+	})
+
+	ctx := context.Background()
+	m, err := NewMigrator(db, "sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := m.Down(ctx, len(SQLite), false)
+	if !errors.Is(err, ErrBaseline) {
+		t.Errorf("revert baseline without being allowed: want %v but %v", ErrBaseline, err)
+	}
+	if len(reverted) != len(SQLite)-1 {
+		t.Errorf("number of reverted migrations: want %v but %v", len(SQLite)-1, len(reverted))
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Applied {
+		t.Errorf("baseline is reverted without being allowed")
+	}
+
+	reverted, err = m.Down(ctx, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || !reverted[0].Baseline {
+		t.Errorf("baseline is not reverted: %v", reverted)
+	}
+
+	// All migrations are applied again after they are reverted.
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(SQLite) {
+		t.Errorf("number of applied migrations: want %v but %v", len(SQLite), len(applied))
+	}
+}
@@ -0,0 +1,335 @@
+package migration
+
+// Postgres is migrations of the schema on PostgreSQL. Append new migrations to the end
+// and never change applied ones.
+//
+// Migrations up to "trash" make the schema the server made on every start before migrations
+// are introduced. Their statements are idempotent to adopt databases made by it.
+var Postgres = []Migration{
+	{
+		Version:  1,
+		Name:     "initial",
+		Baseline: true,
+		Up: `
+CREATE SCHEMA IF NOT EXISTS auth;
+CREATE SCHEMA IF NOT EXISTS calendar;
+CREATE TABLE IF NOT EXISTS auth.users (
+	id CHAR(36) PRIMARY KEY,
+	name VARCHAR(64) NOT NULL,
+	password VARCHAR(255) NOT NULL
+);
+-- Encoded hashes of Argon2id are longer than bcrypt ones.
+ALTER TABLE auth.users ALTER COLUMN password TYPE VARCHAR(255);
+CREATE TABLE IF NOT EXISTS calendar.users (
+	id CHAR(36) PRIMARY KEY,
+	FOREIGN KEY (id) REFERENCES auth.users(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS calendar.calendars (
+	id CHAR(36) PRIMARY KEY,
+	userid CHAR(36),
+	name NAME NOT NULL,
+	color VARCHAR(20) NOT NULL,
+	FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS calendar.calendar_shares (
+	userid CHAR(36),
+	calendarid CHAR(36),
+	PRIMARY KEY(userid, calendarid),
+	FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE,
+	FOREIGN KEY (calendarid) REFERENCES calendar.calendars(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS calendar.plans (
+	id CHAR(36) PRIMARY KEY,
+	userid CHAR(36),
+	calendarid CHAR(36),
+	name NAME NOT NULL,
+	memo VARCHAR(400),
+	color VARCHAR(20) NOT NULL,
+	private BOOLEAN NOT NULL,
+	isallday BOOLEAN NOT NULL,
+	begintime BIGINT NOT NULL,
+	endtime BIGINT NOT NULL,
+	FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE,
+	FOREIGN KEY (calendarid) REFERENCES calendar.calendars(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS calendar.plan_shares (
+	calendarid CHAR(36),
+	planid CHAR(36),
+	PRIMARY KEY(calendarid, planid),
+	FOREIGN KEY (calendarid) REFERENCES calendar.calendars(id) ON DELETE CASCADE,
+	FOREIGN KEY (planid) REFERENCES calendar.plans(id) ON DELETE CASCADE
+);
+`,
+		Down: `
+DROP SCHEMA IF EXISTS calendar CASCADE;
+DROP SCHEMA IF EXISTS auth CASCADE;
+`,
+	},
+	{
+		Version: 2,
+		Name:    "email_verification",
+		Up: `
+ALTER TABLE auth.users ADD COLUMN IF NOT EXISTS email VARCHAR(254) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON auth.users (email) WHERE email <> '';
+-- Users existing before email verification is introduced are regarded as verified.
+ALTER TABLE auth.users ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT TRUE;
+`,
+		Down: `
+ALTER TABLE auth.users DROP COLUMN IF EXISTS verified;
+DROP INDEX IF EXISTS auth.users_email_key;
+ALTER TABLE auth.users DROP COLUMN IF EXISTS email;
+`,
+	},
+	{
+		Version: 3,
+		Name:    "admins",
+		// Admins are granted by operators with SQL such as
+		// "UPDATE auth.users SET admin = TRUE WHERE name = 'alice'".
+		Up: `
+ALTER TABLE auth.users ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE auth.users ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+`,
+		Down: `
+ALTER TABLE auth.users DROP COLUMN IF EXISTS disabled;
+ALTER TABLE auth.users DROP COLUMN IF EXISTS admin;
+`,
+	},
+	{
+		Version: 4,
+		Name:    "identities",
+		Up: `
+CREATE TABLE IF NOT EXISTS auth.identities (
+	issuer VARCHAR(255),
+	subject VARCHAR(255),
+	userid CHAR(36) NOT NULL,
+	PRIMARY KEY(issuer, subject),
+	FOREIGN KEY (userid) REFERENCES auth.users(id) ON DELETE CASCADE
+);
+`,
+		Down: `
+DROP TABLE IF EXISTS auth.identities;
+`,
+	},
+	{
+		Version: 5,
+		Name:    "orgs",
+		Up: `
+CREATE TABLE IF NOT EXISTS calendar.orgs (
+	id CHAR(36) PRIMARY KEY,
+	name VARCHAR(64) NOT NULL
+);
+CREATE TABLE IF NOT EXISTS calendar.org_members (
+	orgid CHAR(36),
+	userid CHAR(36),
+	role VARCHAR(16) NOT NULL,
+	PRIMARY KEY(orgid, userid),
+	FOREIGN KEY (orgid) REFERENCES calendar.orgs(id) ON DELETE CASCADE,
+	FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE
+);
+-- Calendars owned by organizations have orgid. userid is the member who made it.
+ALTER TABLE calendar.calendars ADD COLUMN IF NOT EXISTS orgid CHAR(36) REFERENCES calendar.orgs(id) ON DELETE CASCADE;
+`,
+		// Calendars of organizations are removed with them.
+		Down: `
+DELETE FROM calendar.calendars WHERE orgid IS NOT NULL;
+ALTER TABLE calendar.calendars DROP COLUMN IF EXISTS orgid;
+DROP TABLE IF EXISTS calendar.org_members;
+DROP TABLE IF EXISTS calendar.orgs;
+`,
+	},
+	{
+		Version: 6,
+		Name:    "profiles",
+		Up: `
+CREATE TABLE IF NOT EXISTS calendar.profiles (
+	userid CHAR(36) PRIMARY KEY,
+	display_name VARCHAR(64) NOT NULL,
+	avatar_url VARCHAR(2048) NOT NULL,
+	time_zone VARCHAR(64) NOT NULL,
+	locale VARCHAR(35) NOT NULL,
+	week_start SMALLINT NOT NULL,
+	default_calendar_id CHAR(36),
+	FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE,
+	FOREIGN KEY (default_calendar_id) REFERENCES calendar.calendars(id) ON DELETE SET NULL
+);
+`,
+		Down: `
+DROP TABLE IF EXISTS calendar.profiles;
+`,
+	},
+	{
+		Version: 7,
+		Name:    "groups",
+		Up: `
+CREATE TABLE IF NOT EXISTS calendar.groups (
+	id CHAR(36) PRIMARY KEY,
+	userid CHAR(36) NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS calendar.group_members (
+	groupid CHAR(36),
+	userid CHAR(36),
+	PRIMARY KEY(groupid, userid),
+	FOREIGN KEY (groupid) REFERENCES calendar.groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (userid) REFERENCES calendar.users(id) ON DELETE CASCADE
+);
+-- A share of calendar targets either a user or a group.
+ALTER TABLE calendar.calendar_shares ADD COLUMN IF NOT EXISTS groupid CHAR(36) REFERENCES calendar.groups(id) ON DELETE CASCADE;
+ALTER TABLE calendar.calendar_shares DROP CONSTRAINT IF EXISTS calendar_shares_pkey;
+ALTER TABLE calendar.calendar_shares ALTER COLUMN userid DROP NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_shares_user_key ON calendar.calendar_shares (userid, calendarid) WHERE userid IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_shares_group_key ON calendar.calendar_shares (groupid, calendarid) WHERE groupid IS NOT NULL;
+`,
+		// Shares of calendars with groups are removed with them.
+		Down: `
+DELETE FROM calendar.calendar_shares WHERE userid IS NULL;
+DROP INDEX IF EXISTS calendar.calendar_shares_group_key;
+DROP INDEX IF EXISTS calendar.calendar_shares_user_key;
+ALTER TABLE calendar.calendar_shares DROP COLUMN IF EXISTS groupid;
+ALTER TABLE calendar.calendar_shares ADD PRIMARY KEY (userid, calendarid);
+DROP TABLE IF EXISTS calendar.group_members;
+DROP TABLE IF EXISTS calendar.groups;
+`,
+	},
+	{
+		Version: 8,
+		Name:    "transfers",
+		Up: `
+CREATE TABLE IF NOT EXISTS calendar.calendar_transfers (
+	calendarid CHAR(36) PRIMARY KEY,
+	from_userid CHAR(36) NOT NULL,
+	to_userid CHAR(36) NOT NULL,
+	transfer_plans BOOLEAN NOT NULL,
+	FOREIGN KEY (calendarid) REFERENCES calendar.calendars(id) ON DELETE CASCADE,
+	FOREIGN KEY (from_userid) REFERENCES calendar.users(id) ON DELETE CASCADE,
+	FOREIGN KEY (to_userid) REFERENCES calendar.users(id) ON DELETE CASCADE
+);
+`,
+		Down: `
+DROP TABLE IF EXISTS calendar.calendar_transfers;
+`,
+	},
+	{
+		Version: 9,
+		Name:    "revisions",
+		// Revisions are never updated. Actors are kept after they leave.
+		Up: `
+CREATE TABLE IF NOT EXISTS calendar.revisions (
+	seq BIGSERIAL PRIMARY KEY,
+	id CHAR(36) UNIQUE NOT NULL,
+	target VARCHAR(16) NOT NULL,
+	targetid CHAR(36) NOT NULL,
+	calendarid CHAR(36) NOT NULL,
+	userid CHAR(36) NOT NULL,
+	action VARCHAR(16) NOT NULL,
+	created_at BIGINT NOT NULL,
+	before_data TEXT NOT NULL,
+	after_data TEXT NOT NULL,
+	FOREIGN KEY (calendarid) REFERENCES calendar.calendars(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS revisions_targetid ON calendar.revisions (targetid);
+CREATE INDEX IF NOT EXISTS revisions_calendarid ON calendar.revisions (calendarid);
+`,
+		Down: `
+DROP TABLE IF EXISTS calendar.revisions;
+`,
+	},
+	{
+		Version: 10,
+		Name:    "undo",
+		// Revisions made by a request are undone together with operationid.
+		Up: `
+ALTER TABLE calendar.revisions ADD COLUMN IF NOT EXISTS operationid CHAR(36) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS revisions_operationid ON calendar.revisions (operationid);
+`,
+		Down: `
+DROP INDEX IF EXISTS calendar.revisions_operationid;
+ALTER TABLE calendar.revisions DROP COLUMN IF EXISTS operationid;
+`,
+	},
+	{
+		Version: 11,
+		Name:    "trash",
+		// Removed calendars and plans stay in the trash with deleted_at until they are purged.
+		Up: `
+ALTER TABLE calendar.calendars ADD COLUMN IF NOT EXISTS deleted_at BIGINT;
+ALTER TABLE calendar.plans ADD COLUMN IF NOT EXISTS deleted_at BIGINT;
+`,
+		// The trash is purged not to restore calendars and plans in it.
+		Down: `
+DELETE FROM calendar.plans WHERE deleted_at IS NOT NULL;
+DELETE FROM calendar.calendars WHERE deleted_at IS NOT NULL;
+ALTER TABLE calendar.plans DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE calendar.calendars DROP COLUMN IF EXISTS deleted_at;
+`,
+	},
+	{
+		Version: 12,
+		Name:    "sessions",
+		// Sessions are kept here instead of Redis with the "database" session store.
+		// Expired sessions are not found and removed by a periodic job.
+		Up: `
+CREATE TABLE auth.sessions (
+	id VARCHAR(255) PRIMARY KEY,
+	userid CHAR(36) NOT NULL,
+	expires BIGINT NOT NULL,
+	FOREIGN KEY (userid) REFERENCES auth.users(id) ON DELETE CASCADE
+);
+CREATE INDEX sessions_userid ON auth.sessions (userid);
+CREATE INDEX sessions_expires ON auth.sessions (expires);
+`,
+		Down: `
+DROP TABLE IF EXISTS auth.sessions;
+`,
+	},
+	{
+		Version: 13,
+		Name:    "attempts",
+		// Attempts, tokens and revocations are kept here instead of Redis with the "database"
+		// session store. Expired ones are not found and removed by later writes.
+		Up: `
+CREATE TABLE auth.attempts (
+	key VARCHAR(255) PRIMARY KEY,
+	failures INTEGER NOT NULL,
+	locked_until BIGINT NOT NULL,
+	expires BIGINT NOT NULL
+);
+CREATE INDEX attempts_expires ON auth.attempts (expires);
+`,
+		Down: `
+DROP TABLE IF EXISTS auth.attempts;
+`,
+	},
+	{
+		Version: 14,
+		Name:    "tokens",
+		Up: `
+CREATE TABLE auth.tokens (
+	id VARCHAR(255) PRIMARY KEY,
+	userid CHAR(36) NOT NULL,
+	purpose VARCHAR(64) NOT NULL,
+	expires BIGINT NOT NULL
+);
+CREATE INDEX tokens_expires ON auth.tokens (expires);
+`,
+		Down: `
+DROP TABLE IF EXISTS auth.tokens;
+`,
+	},
+	{
+		Version: 15,
+		Name:    "revocations",
+		Up: `
+CREATE TABLE auth.revocations (
+	id VARCHAR(255) PRIMARY KEY,
+	revoked_at BIGINT NOT NULL,
+	expires BIGINT NOT NULL
+);
+CREATE INDEX revocations_expires ON auth.revocations (expires);
+`,
+		Down: `
+DROP TABLE IF EXISTS auth.revocations;
+`,
+	},
+}
@@ -0,0 +1,200 @@
+package migration
+
+// SQLite is migrations of the schema on SQLite. SQLite does not have schemas
+// so that tables are prefixed with names of schemas on PostgreSQL instead.
+// Append new migrations to the end and never change applied ones.
+var SQLite = []Migration{
+	{
+		Version:  1,
+		Name:     "initial",
+		Baseline: true,
+		Up: `
+CREATE TABLE auth_users (
+	id CHAR(36) PRIMARY KEY,
+	name VARCHAR(64) NOT NULL,
+	password VARCHAR(255) NOT NULL,
+	email VARCHAR(254) NOT NULL DEFAULT '',
+	verified BOOLEAN NOT NULL DEFAULT TRUE,
+	admin BOOLEAN NOT NULL DEFAULT FALSE,
+	disabled BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE UNIQUE INDEX auth_users_email_key ON auth_users (email) WHERE email <> '';
+CREATE TABLE auth_identities (
+	issuer VARCHAR(255),
+	subject VARCHAR(255),
+	userid CHAR(36) NOT NULL,
+	PRIMARY KEY(issuer, subject),
+	FOREIGN KEY (userid) REFERENCES auth_users(id) ON DELETE CASCADE
+);
+-- Sessions, attempts and tokens are kept in Redis on PostgreSQL.
+-- They are not found after expires and removed by later writes.
+CREATE TABLE auth_sessions (
+	id VARCHAR(255) PRIMARY KEY,
+	userid CHAR(36) NOT NULL,
+	expires BIGINT NOT NULL
+);
+CREATE INDEX auth_sessions_userid ON auth_sessions (userid);
+CREATE INDEX auth_sessions_expires ON auth_sessions (expires);
+CREATE TABLE auth_attempts (
+	key VARCHAR(255) PRIMARY KEY,
+	failures INTEGER NOT NULL,
+	locked_until BIGINT NOT NULL,
+	expires BIGINT NOT NULL
+);
+CREATE INDEX auth_attempts_expires ON auth_attempts (expires);
+CREATE TABLE auth_tokens (
+	id VARCHAR(255) PRIMARY KEY,
+	userid CHAR(36) NOT NULL,
+	purpose VARCHAR(64) NOT NULL,
+	expires BIGINT NOT NULL
+);
+CREATE INDEX auth_tokens_expires ON auth_tokens (expires);
+CREATE TABLE calendar_users (
+	id CHAR(36) PRIMARY KEY,
+	FOREIGN KEY (id) REFERENCES auth_users(id) ON DELETE CASCADE
+);
+CREATE TABLE calendar_orgs (
+	id CHAR(36) PRIMARY KEY,
+	name VARCHAR(64) NOT NULL
+);
+CREATE TABLE calendar_org_members (
+	orgid CHAR(36),
+	userid CHAR(36),
+	role VARCHAR(16) NOT NULL,
+	PRIMARY KEY(orgid, userid),
+	FOREIGN KEY (orgid) REFERENCES calendar_orgs(id) ON DELETE CASCADE,
+	FOREIGN KEY (userid) REFERENCES calendar_users(id) ON DELETE CASCADE
+);
+-- Calendars owned by organizations have orgid. userid is the member who made it.
+CREATE TABLE calendar_calendars (
+	id CHAR(36) PRIMARY KEY,
+	userid CHAR(36),
+	orgid CHAR(36),
+	name VARCHAR(64) NOT NULL,
+	color VARCHAR(20) NOT NULL,
+	deleted_at BIGINT,
+	FOREIGN KEY (userid) REFERENCES calendar_users(id) ON DELETE CASCADE,
+	FOREIGN KEY (orgid) REFERENCES calendar_orgs(id) ON DELETE CASCADE
+);
+CREATE TABLE calendar_profiles (
+	userid CHAR(36) PRIMARY KEY,
+	display_name VARCHAR(64) NOT NULL,
+	avatar_url VARCHAR(2048) NOT NULL,
+	time_zone VARCHAR(64) NOT NULL,
+	locale VARCHAR(35) NOT NULL,
+	week_start SMALLINT NOT NULL,
+	default_calendar_id CHAR(36),
+	FOREIGN KEY (userid) REFERENCES calendar_users(id) ON DELETE CASCADE,
+	FOREIGN KEY (default_calendar_id) REFERENCES calendar_calendars(id) ON DELETE SET NULL
+);
+CREATE TABLE calendar_groups (
+	id CHAR(36) PRIMARY KEY,
+	userid CHAR(36) NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	FOREIGN KEY (userid) REFERENCES calendar_users(id) ON DELETE CASCADE
+);
+CREATE TABLE calendar_group_members (
+	groupid CHAR(36),
+	userid CHAR(36),
+	PRIMARY KEY(groupid, userid),
+	FOREIGN KEY (groupid) REFERENCES calendar_groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (userid) REFERENCES calendar_users(id) ON DELETE CASCADE
+);
+-- A share of calendar targets either a user or a group.
+CREATE TABLE calendar_calendar_shares (
+	userid CHAR(36),
+	groupid CHAR(36),
+	calendarid CHAR(36) NOT NULL,
+	FOREIGN KEY (userid) REFERENCES calendar_users(id) ON DELETE CASCADE,
+	FOREIGN KEY (groupid) REFERENCES calendar_groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (calendarid) REFERENCES calendar_calendars(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX calendar_shares_user_key ON calendar_calendar_shares (userid, calendarid) WHERE userid IS NOT NULL;
+CREATE UNIQUE INDEX calendar_shares_group_key ON calendar_calendar_shares (groupid, calendarid) WHERE groupid IS NOT NULL;
+CREATE TABLE calendar_calendar_transfers (
+	calendarid CHAR(36) PRIMARY KEY,
+	from_userid CHAR(36) NOT NULL,
+	to_userid CHAR(36) NOT NULL,
+	transfer_plans BOOLEAN NOT NULL,
+	FOREIGN KEY (calendarid) REFERENCES calendar_calendars(id) ON DELETE CASCADE,
+	FOREIGN KEY (from_userid) REFERENCES calendar_users(id) ON DELETE CASCADE,
+	FOREIGN KEY (to_userid) REFERENCES calendar_users(id) ON DELETE CASCADE
+);
+CREATE TABLE calendar_plans (
+	id CHAR(36) PRIMARY KEY,
+	userid CHAR(36),
+	calendarid CHAR(36),
+	name VARCHAR(64) NOT NULL,
+	memo VARCHAR(400),
+	color VARCHAR(20) NOT NULL,
+	private BOOLEAN NOT NULL,
+	isallday BOOLEAN NOT NULL,
+	begintime BIGINT NOT NULL,
+	endtime BIGINT NOT NULL,
+	deleted_at BIGINT,
+	FOREIGN KEY (userid) REFERENCES calendar_users(id) ON DELETE CASCADE,
+	FOREIGN KEY (calendarid) REFERENCES calendar_calendars(id) ON DELETE CASCADE
+);
+CREATE TABLE calendar_plan_shares (
+	calendarid CHAR(36),
+	planid CHAR(36),
+	PRIMARY KEY(calendarid, planid),
+	FOREIGN KEY (calendarid) REFERENCES calendar_calendars(id) ON DELETE CASCADE,
+	FOREIGN KEY (planid) REFERENCES calendar_plans(id) ON DELETE CASCADE
+);
+-- Revisions are never updated. Actors are kept after they leave.
+CREATE TABLE calendar_revisions (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	id CHAR(36) UNIQUE NOT NULL,
+	operationid CHAR(36) NOT NULL DEFAULT '',
+	target VARCHAR(16) NOT NULL,
+	targetid CHAR(36) NOT NULL,
+	calendarid CHAR(36) NOT NULL,
+	userid CHAR(36) NOT NULL,
+	action VARCHAR(16) NOT NULL,
+	created_at BIGINT NOT NULL,
+	before_data TEXT NOT NULL,
+	after_data TEXT NOT NULL,
+	FOREIGN KEY (calendarid) REFERENCES calendar_calendars(id) ON DELETE CASCADE
+);
+CREATE INDEX calendar_revisions_targetid ON calendar_revisions (targetid);
+CREATE INDEX calendar_revisions_calendarid ON calendar_revisions (calendarid);
+CREATE INDEX calendar_revisions_operationid ON calendar_revisions (operationid);
+`,
+		Down: `
+DROP TABLE IF EXISTS calendar_revisions;
+DROP TABLE IF EXISTS calendar_plan_shares;
+DROP TABLE IF EXISTS calendar_plans;
+DROP TABLE IF EXISTS calendar_calendar_transfers;
+DROP TABLE IF EXISTS calendar_calendar_shares;
+DROP TABLE IF EXISTS calendar_group_members;
+DROP TABLE IF EXISTS calendar_groups;
+DROP TABLE IF EXISTS calendar_profiles;
+DROP TABLE IF EXISTS calendar_calendars;
+DROP TABLE IF EXISTS calendar_org_members;
+DROP TABLE IF EXISTS calendar_orgs;
+DROP TABLE IF EXISTS calendar_users;
+DROP TABLE IF EXISTS auth_tokens;
+DROP TABLE IF EXISTS auth_attempts;
+DROP TABLE IF EXISTS auth_sessions;
+DROP TABLE IF EXISTS auth_identities;
+DROP TABLE IF EXISTS auth_users;
+`,
+	},
+	{
+		Version: 2,
+		Name:    "revocations",
+		// Revoked session tokens are not found after expires and removed by later writes.
+		Up: `
+CREATE TABLE auth_revocations (
+	id VARCHAR(255) PRIMARY KEY,
+	revoked_at BIGINT NOT NULL,
+	expires BIGINT NOT NULL
+);
+CREATE INDEX auth_revocations_expires ON auth_revocations (expires);
+`,
+		Down: `
+DROP TABLE IF EXISTS auth_revocations;
+`,
+	},
+}
@@ -1,7 +1,10 @@
 package error
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 )
 
 // ErrInvalidContent is default invalid-content-error retured
@@ -124,6 +127,46 @@ func NewInternalError(inner error, message string) internalError {
 	}
 }
 
+// ErrTimeout is default timeout-error retured
+// when the deadline of the request is exceeded before the operation completes.
+var ErrTimeout = timeoutError{}
+
+type timeoutError struct {
+	message string
+	inner   error
+}
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("TimeoutError: %v\n  %v", e.message, e.inner)
+}
+
+func (e timeoutError) Unwrap() error {
+	return e.inner
+}
+
+func (timeoutError) Is(target error) bool {
+	_, ok := target.(timeoutError)
+	return ok
+}
+
+// NewTimeoutError generates a timeout-error
+func NewTimeoutError(inner error, message string) timeoutError {
+	return timeoutError{
+		message: message,
+		inner:   inner,
+	}
+}
+
+// NewQueryError generates a timeout-error if the deadline of ctx is exceeded,
+// otherwise an internal-error. Drivers may return their own errors for queries
+// cancelled with ctx, so that ctx is checked instead of inner.
+func NewQueryError(ctx context.Context, inner error, message string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return NewTimeoutError(inner, message)
+	}
+	return NewInternalError(inner, message)
+}
+
 // ErrAuthorization is default authorization-error retured
 // when authorization failed.
 var ErrAuthorization = authorizationError{}
@@ -153,3 +196,50 @@ func NewAuthorizationError(inner error, message string) authorizationError {
 		inner:   inner,
 	}
 }
+
+// ErrTooManyAttempts is default too-many-attempts-error retured
+// when same operation is failed too many times and is locked temporarily.
+var ErrTooManyAttempts = tooManyAttemptsError{}
+
+type tooManyAttemptsError struct {
+	message    string
+	inner      error
+	retryAfter time.Duration
+}
+
+func (e tooManyAttemptsError) Error() string {
+	return fmt.Sprintf("TooManyAttemptsError: %v\n  %v", e.message, e.inner)
+}
+
+func (e tooManyAttemptsError) Unwrap() error {
+	return e.inner
+}
+
+func (tooManyAttemptsError) Is(target error) bool {
+	_, ok := target.(tooManyAttemptsError)
+	return ok
+}
+
+// RetryAfter returns duration until the operation is unlocked.
+func (e tooManyAttemptsError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// NewTooManyAttemptsError generates a too-many-attempts-error
+func NewTooManyAttemptsError(inner error, message string, retryAfter time.Duration) tooManyAttemptsError {
+	return tooManyAttemptsError{
+		message:    message,
+		inner:      inner,
+		retryAfter: retryAfter,
+	}
+}
+
+// RetryAfter returns duration until the operation is unlocked
+// if err is a too-many-attempts-error.
+func RetryAfter(err error) (time.Duration, bool) {
+	var e tooManyAttemptsError
+	if !errors.As(err, &e) {
+		return 0, false
+	}
+	return e.retryAfter, true
+}
@@ -25,10 +25,13 @@ const actions = {
       })
       .catch(() => callback(false));
   },
-  signup(st, { username, password, callback }) {
+  signup(st, {
+    username, password, email, callback,
+  }) {
     const body = {
       name: username,
       password,
+      email,
     };
     fetchAPI('/auth/signup', 'POST', JSON.stringify(body), false)
       .then(() => callback(true))
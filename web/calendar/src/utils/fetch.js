@@ -1,8 +1,13 @@
+function xsrfToken() {
+  const match = document.cookie.match(/(?:^|;\s*)XSRF-TOKEN=([^;]*)/);
+  return match ? decodeURIComponent(match[1]) : '';
+}
+
 export default function fetchAPI(url, method = 'GET', body = '', signin = true) {
   const options = {
     method,
     headers: {
-      'X-XSRF-TOKEN': 'csrf',
+      'X-XSRF-TOKEN': xsrfToken(),
       'Content-Type': 'application/json; charset=UTF-8',
     },
   };